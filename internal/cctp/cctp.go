@@ -0,0 +1,91 @@
+// Package cctp provides the contracts and events of Circle's Cross-Chain Transfer
+// Protocol (CCTP), which moves USDC between chains by burning it on the source
+// chain and minting it on the destination
+package cctp
+
+import "usdc-event-tracker/internal/erc20"
+
+// CCTP events decoded once RegisterEvents is called
+const (
+	// Emitted by the TokenMessenger when USDC is burned for a transfer to another chain
+	DepositForBurn erc20.Event = "DepositForBurn"
+	// Emitted by the TokenMessenger when USDC is minted for a transfer from another chain
+	MintAndWithdraw erc20.Event = "MintAndWithdraw"
+	// Emitted by the MessageTransmitter for every outgoing cross-chain message
+	MessageSent erc20.Event = "MessageSent"
+	// Emitted by the MessageTransmitter for every attested incoming message
+	MessageReceived erc20.Event = "MessageReceived"
+)
+
+// TokenMessengers maps networks to their CCTP TokenMessenger contract address
+var TokenMessengers = map[string]string{
+	"mainnet":     "0xBd3fa81B58Ba92a82136038B25aDec7066af3155",
+	"ethereum":    "0xBd3fa81B58Ba92a82136038B25aDec7066af3155",
+	"sepolia":     "0x9f3B8679c73C2Fef8b59B4f3444d4e156fb70AA5",
+	"arbitrum":    "0x19330d10D9Cc8751218eaf51E8885D058642E08A",
+	"optimism":    "0x2B4069517957735bE00ceE0fadAE88a26365528f",
+	"polygon":     "0x9daF8c91AEFAE50b9c0E69629D3F6Ca40cA3B3FE",
+	"avalanche-c": "0x6B25532e1060CE10cc3B0A99e5683b91BFDe6982",
+	"base":        "0x1682Ae6375C4E4A97e4B583BC394c861A46D8962",
+}
+
+// MessageTransmitters maps networks to their CCTP MessageTransmitter contract address
+var MessageTransmitters = map[string]string{
+	"mainnet":     "0x0a992d191DEeC32aFe36203Ad87D7d289a738F81",
+	"ethereum":    "0x0a992d191DEeC32aFe36203Ad87D7d289a738F81",
+	"sepolia":     "0x7865fAfC2db2093669d92c0F33AeEF291086BEFD",
+	"arbitrum":    "0xC30362313FBBA5cf9163F0bb16a0e01f01A896ca",
+	"optimism":    "0x4D41f22c5a0e5c74090899E5a8Fb597a8842b3e8",
+	"polygon":     "0xF3be9355363857F3e001be68856A2f96b4C39Ba9",
+	"avalanche-c": "0x8186359aF5F57FbB40c6b14A588d2A59C0C29880",
+	"base":        "0xAD09780d193884d503182aD4588450C416D6F9D4",
+}
+
+// eventsABI defines the TokenMessenger and MessageTransmitter events. Parameter
+// names become the decoded field names, e.g. nonce, burnToken, amount,
+// destinationDomain and mintRecipient of DepositForBurn. Recipients and callers
+// on other chains are bytes32, since not every domain uses 20-byte addresses.
+const eventsABI = `[
+	{"type": "event", "name": "DepositForBurn", "anonymous": false, "inputs": [
+		{"name": "nonce", "type": "uint64", "indexed": true},
+		{"name": "burnToken", "type": "address", "indexed": true},
+		{"name": "amount", "type": "uint256", "indexed": false},
+		{"name": "depositor", "type": "address", "indexed": true},
+		{"name": "mintRecipient", "type": "bytes32", "indexed": false},
+		{"name": "destinationDomain", "type": "uint32", "indexed": false},
+		{"name": "destinationTokenMessenger", "type": "bytes32", "indexed": false},
+		{"name": "destinationCaller", "type": "bytes32", "indexed": false}
+	]},
+	{"type": "event", "name": "MintAndWithdraw", "anonymous": false, "inputs": [
+		{"name": "mintRecipient", "type": "address", "indexed": true},
+		{"name": "amount", "type": "uint256", "indexed": false},
+		{"name": "mintToken", "type": "address", "indexed": true}
+	]},
+	{"type": "event", "name": "MessageSent", "anonymous": false, "inputs": [
+		{"name": "message", "type": "bytes", "indexed": false}
+	]},
+	{"type": "event", "name": "MessageReceived", "anonymous": false, "inputs": [
+		{"name": "caller", "type": "address", "indexed": true},
+		{"name": "sourceDomain", "type": "uint32", "indexed": false},
+		{"name": "nonce", "type": "uint64", "indexed": true},
+		{"name": "sender", "type": "bytes32", "indexed": false},
+		{"name": "messageBody", "type": "bytes", "indexed": false}
+	]}
+]`
+
+// Contracts returns the TokenMessenger and MessageTransmitter addresses of
+// network, or false if CCTP is not deployed there
+func Contracts(network string) ([]string, bool) {
+	tokenMessenger, ok := TokenMessengers[network]
+	if !ok {
+		return nil, false
+	}
+	return []string{tokenMessenger, MessageTransmitters[network]}, true
+}
+
+// RegisterEvents registers the CCTP events with the erc20 package, so they are
+// recognized and decoded like custom events. It must be called at startup,
+// before any events are processed.
+func RegisterEvents() error {
+	return erc20.RegisterABI(eventsABI, "CCTP ABI")
+}
@@ -2,12 +2,23 @@
 package config
 
 import (
+	"encoding/hex"
 	"log"
+	"math/big"
+	"net/url"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/joho/godotenv"
+
+	"usdc-event-tracker/internal/cctp"
+	"usdc-event-tracker/internal/erc20"
+	"usdc-event-tracker/internal/logging"
+	"usdc-event-tracker/internal/sinks"
 )
 
 const (
@@ -15,40 +26,309 @@ const (
 	USDCMainnet   = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
 	USDCSepolia   = "0x1c7D4B196Cb0C7B01d743Fbc6116a902379C7238"
 	USDCArbitrum  = "0xaf88d065e77c8cC2239327C5EDb3A432268e5831"
-	USDCAvalanche = "0xB97EF9Ef8734C71904D8002F8b6Bc66Dd9c48a6E"
+	USDCAvalanche = "0xB97EF9Ef8734C71904D8002F8b6Bc66Dd9c48a6E" // C-Chain
 	USDCLinea     = "0x176211869cA2b568f2A7D4EE941E073a821EE1ff"
 	USDCPolygon   = "0x3c499c542cef5e3811e1192ce70d8cc03d5c3359"
 	USDCOptimism  = "0x0b2c639c533813f4aa9d7837caf62653d097ff85"
+	USDCBase      = "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913"
+	USDCZkSync    = "0x1d17CBcF0D6D143135aE902365D2E5e2A16538D4"
+
+	// Bridged USDC (USDC.e / USDbC) contract addresses for networks that have one
+	USDCeArbitrum  = "0xFF970A61A04b1cA14834A43f5dE4533eBDDB5CC8"
+	USDCeOptimism  = "0x7F5c764cBc14f9669B88837ca1490cCa17c31607"
+	USDCePolygon   = "0x2791Bca1f2de4661ED88A30C99A7a9449Aa84174"
+	USDCeAvalanche = "0xA7D7079b0FEaD91F3e65f86E8915Cb59c1a4C664"
+	USDCeBase      = "0xd9aAEc86B65D86f6A7B5B1b0c42FFA531710b6CA"
+	USDCeZkSync    = "0x3355df6D4c9C3035724Fd0e3914dE96A5a83aaf4"
+)
+
+// USDC variants selectable via USDC_VARIANT
+const (
+	VariantNative  = "native"  // Circle-issued USDC
+	VariantBridged = "bridged" // Bridged USDC.e
+	VariantBoth    = "both"    // Track both native and bridged contracts
+)
+
+// Handling of Transfers above MAX_SANE_VALUE, selectable via MAX_SANE_VALUE_ACTION
+const (
+	SaneValueWarn = "warn" // Log a warning and emit the event unchanged
+	SaneValueFlag = "flag" // Log a warning and mark the event with suspect_value
+	SaneValueDrop = "drop" // Log a warning and drop the log
+)
+
+// Tracking modes selectable via TRACK_MODE
+const (
+	TrackModeUSDC    = "usdc"    // The network's USDC contracts, see USDC_VARIANT
+	TrackModeGeneric = "generic" // Arbitrary contracts from TRACK_CONTRACTS
 )
 
+// BridgedUSDC maps networks to their bridged USDC contract address
+var BridgedUSDC = map[string]string{
+	"arbitrum":    USDCeArbitrum,
+	"optimism":    USDCeOptimism,
+	"polygon":     USDCePolygon,
+	"avalanche-c": USDCeAvalanche,
+	"base":        USDCeBase,
+	"zksync":      USDCeZkSync,
+}
+
+// USDCContract is a tracked contract and the USDC variant it represents.
+// Contracts tracked in generic mode and CCTP contracts have no variant.
+type USDCContract struct {
+	Address string
+	Variant string
+}
+
+// NetworkAliases maps alternative network names to the name used in the tables
+// below. Avalanche subnets are separate chains with their own chain ID and USDC
+// deployment, so each gets its own avalanche-<subnet> entry; avalanche alone
+// keeps meaning the C-Chain, as it did before subnets were distinguished.
+var NetworkAliases = map[string]string{
+	"avalanche": "avalanche-c",
+}
+
+// ChainIDs maps supported network names to the chain ID their RPC endpoints must report
+var ChainIDs = map[string]uint64{
+	"mainnet":     1,
+	"ethereum":    1,
+	"sepolia":     11155111,
+	"arbitrum":    42161,
+	"optimism":    10,
+	"polygon":     137,
+	"avalanche-c": 43114,
+	"linea":       59144,
+	"base":        8453,
+	"zksync":      324,
+}
+
+// DefaultRPCURLs maps networks to free public RPC endpoints, used only when WEBHOOK_URL
+// is unset so the tracker can be tried without an account at an RPC provider.
+// Public endpoints are heavily rate-limited and unsuitable for production.
+var DefaultRPCURLs = map[string]string{
+	"mainnet":     "https://ethereum-rpc.publicnode.com",
+	"ethereum":    "https://ethereum-rpc.publicnode.com",
+	"sepolia":     "https://ethereum-sepolia-rpc.publicnode.com",
+	"arbitrum":    "https://arb1.arbitrum.io/rpc",
+	"optimism":    "https://mainnet.optimism.io",
+	"polygon":     "https://polygon-rpc.com",
+	"avalanche-c": "https://api.avax.network/ext/bc/C/rpc",
+	"linea":       "https://rpc.linea.build",
+	"base":        "https://mainnet.base.org",
+	"zksync":      "https://mainnet.era.zksync.io",
+	"local":       "http://127.0.0.1:8545",
+	"localhost":   "http://127.0.0.1:8545",
+	"anvil":       "http://127.0.0.1:8545",
+}
+
+// LocalNetworks are development chains such as Anvil or Hardhat. They have no
+// well-known USDC contract or chain ID, so USDC_ADDRESS_OVERRIDE is required and
+// chain ID validation is skipped.
+var LocalNetworks = map[string]bool{
+	"local":     true,
+	"localhost": true,
+	"anvil":     true,
+}
+
+// BlockIntervals maps networks to their approximate block time, used as the default polling interval.
+// Networks not listed here default to the Ethereum block time of 12 seconds.
+var BlockIntervals = map[string]time.Duration{
+	"mainnet":     12 * time.Second,
+	"ethereum":    12 * time.Second,
+	"sepolia":     12 * time.Second,
+	"arbitrum":    250 * time.Millisecond,
+	"optimism":    2 * time.Second,
+	"polygon":     2 * time.Second,
+	"avalanche-c": 2 * time.Second,
+	"linea":       2 * time.Second,
+	"base":        2 * time.Second,
+	"zksync":      1 * time.Second,
+	"local":       1 * time.Second,
+	"localhost":   1 * time.Second,
+	"anvil":       1 * time.Second,
+}
+
 // Config holds the application configuration
 type Config struct {
-	WebhookURL    string
+	WebhookURL    string   // Primary RPC endpoint (first entry of RPCURLs)
+	RPCURLs       []string // RPC endpoints in failover order
 	BlockInterval time.Duration
-	USDCAddress   string
+	PollInterval  time.Duration  // How often to poll the chain head, defaults to BlockInterval
+	TrackMode     string         // usdc or generic
+	USDCAddress   string         // Primary tracked contract (first entry of USDCContracts)
+	USDCVariant   string         // native, bridged or both; empty in generic mode
+	USDCContracts []USDCContract // All tracked contracts
 	Network       string
+	ChainID       uint64 // Expected chain ID for Network
 	Sink          []string
+	LogFormat     string // json, logfmt or console
+
+	// Number of blocks fetched concurrently; sinks still receive blocks in order
+	BlockWorkers int
+
+	// Blocks fetched concurrently while catching up from the cursor to the head, at least BlockWorkers
+	CatchUpWorkers int
+
+	// Follow the chain head via eth_subscribe("newHeads") instead of polling; requires a ws/wss URL
+	HeadSubscription bool
+
+	// Subscribe to USDC logs via eth_subscribe("logs") instead of fetching block receipts; requires a ws/wss URL
+	UseLogSubscription bool
+
+	// File recording the last block every sink has durably persisted, empty disables resuming
+	CursorFile         string
+	CheckpointInterval time.Duration
+
+	// Directory of the write-ahead log replayed into the sinks on startup, empty disables it
+	WALDir string
+
+	// Recent blocks whose hashes are kept to detect chain reorganizations, 0 disables detection
+	ReorgWindow uint64
+
+	// Maximum time sinks get to close on shutdown before the process exits anyway
+	ShutdownTimeout time.Duration
+
+	// Process blocks but replace every sink with a counting no-op (see sinks.DryRunSink)
+	DryRun bool
+
+	// Write a heartbeat for every block without tracked activity (see sinks.BlockHeartbeat)
+	EmitEmptyBlocks bool
+
+	// Track receipts of reverted transactions (status 0); their transfers did not move funds
+	IncludeFailedTx bool
+
+	// Only emit logs touching these addresses (see sinks.AddressFilterSink), empty disables filtering
+	WatchAddresses []string
+
+	// Drop Transfers of zero tokens before they reach sinks (see sinks.ZeroValueFilterSink)
+	DropZeroValue bool
+
+	// Blocks between balanceOf snapshots of WatchAddresses (see sinks.BalanceSnapshot), 0 disables them
+	BalanceInterval uint64
+
+	// Solidity ABI JSON file with additional events to decode (see erc20.LoadCustomEvents)
+	CustomEventsFile string
+
+	// Also track the network's CCTP TokenMessenger and MessageTransmitter (see package cctp)
+	TrackCCTP bool
+
+	// Fraction of Transfer and Approval logs kept (see sinks.SamplingSink), 1 disables sampling
+	SampleRate float64
+	SampleMode sinks.SampleMode
+
+	// Logs of at least this many token base units bypass sampling, nil disables the bypass
+	MinValue *big.Int
+
+	// Transfers above this many token base units are treated as decoding bugs, nil disables the check
+	MaxSaneValue       *big.Int
+	MaxSaneValueAction string // warn, flag or drop
+
+	// Event signatures (topic 0) of the logs tracked, empty tracks every event of the tracked contracts
+	TrackTopics []common.Hash
+
+	// Transfer filling the event-level from/to/value of transactions with several transfers (see sinks.PrimarySelection)
+	PrimaryEvent sinks.PrimaryStrategy
+
+	// Maximum RPC requests per second, 0 disables throttling
+	RPCRateLimit float64
+
+	// HTTP connections kept open to the RPC endpoint, 0 uses Go's defaults
+	RPCMaxConns int
+
+	// Retries of the initial RPC connection and the wait before the first one, doubled after each retry
+	RPCConnectRetries int
+	RPCConnectBackoff time.Duration
+
+	// Per-request timeout before failing over to the next of several RPC endpoints
+	RPCFailoverTimeout time.Duration
+
+	// Deduplication of already-written logs (see sinks.DedupeSink)
+	DedupeEnabled   bool
+	DedupeCacheSize int
+
+	// Flush buffering sinks after every block instead of waiting for a full batch
+	FlushEveryBlock bool
+
+	// Receipt-level fields persisted by document sinks, nil keeps every field
+	ReceiptFields sinks.ReceiptFields
+
+	// Events a batching sink may hold before Write blocks, 0 disables the limit
+	SinkMaxPending int
+
+	// Abandon sink writes taking longer than this and dead-letter their events, 0 disables
+	SinkWriteTimeout time.Duration
+
+	// JSON Lines file receiving events of abandoned sink writes, empty only logs them
+	DeadLetterFile string
+
+	// Time zone of day boundaries for daily file rotation and date-suffixed indices
+	RotationTimezone *time.Location
+
+	// Blocks each sink's ordered queue may reorder (see sinks.Manager.SetOrderWindow), 0 writes synchronously
+	SinkOrderWindow int
+
+	// Writes buffered per sink (see sinks.Manager.SetBuffer), 0 writes synchronously
+	SinkBufferSize   int
+	SinkBufferPolicy sinks.BufferPolicy // block, drop-oldest or dead-letter
+
+	// Event types each sink receives, keyed by sink name (see sinks.EventTypeFilterSink).
+	// Sinks without an entry receive every event type.
+	SinkEventTypes map[string][]erc20.Event
+
+	// Blocks below the head before a sink receives a block, keyed by sink name (see sinks.ConfirmationSink)
+	SinkConfirmations map[string]uint64
+
+	// Sinks that are skipped instead of aborting startup when they fail to initialize,
+	// keyed by sink name (see sinks.Manager.AddOptionalSink)
+	SinkInitOptional map[string]bool
+
+	// Time each sink may take to initialize, 0 disables the limit
+	SinkInitTimeout time.Duration
 }
 
 // Load reads configuration from environment variables and returns a Config instance.
 // It loads from .env file if present, otherwise uses system environment variables.
-// Required: WEBHOOK_URL must be set.
+// Variables still unset are taken from CONFIG_FILE if one is given.
+// Required: WEBHOOK_URL must be set unless the network has a public default in DefaultRPCURLs.
 // Defaults: NETWORK=sepolia, SINKS=console if not specified.
 func Load() *Config {
 	if err := godotenv.Load(); err != nil {
 		log.Printf("Warning: .env file not found, using environment variables")
 	}
 
-	webhookURL := os.Getenv("WEBHOOK_URL")
-	if webhookURL == "" {
-		log.Fatal("WEBHOOK_URL environment variable is required")
+	// Settings from a YAML/TOML file fill in whatever the environment leaves unset
+	if configFile := os.Getenv("CONFIG_FILE"); configFile != "" {
+		if err := loadConfigFile(configFile); err != nil {
+			log.Fatalf("Invalid CONFIG_FILE: %v", err)
+		}
+	}
+
+	// Register custom event ABIs before anything looks up event types
+	customEventsFile := os.Getenv("CUSTOM_EVENTS_FILE")
+	if customEventsFile != "" {
+		if err := erc20.LoadCustomEvents(customEventsFile); err != nil {
+			log.Fatalf("Invalid CUSTOM_EVENTS_FILE: %v", err)
+		}
+	}
+
+	// Log line format, JSON for log shippers unless overridden for local development
+	logFormat := strings.ToLower(os.Getenv("LOG_FORMAT"))
+	if logFormat == "" {
+		logFormat = logging.FormatJSON
+	}
+	formatter, err := logging.ParseFormat(logFormat)
+	if err != nil {
+		log.Fatalf("Invalid LOG_FORMAT: %v", err)
 	}
+	logging.SetFormatter(formatter)
 
 	// Get network from environment, default to sepolia
 	network := strings.ToLower(os.Getenv("NETWORK"))
 	if network == "" {
 		network = "sepolia"
 	}
+	if canonical, ok := NetworkAliases[network]; ok {
+		network = canonical
+	}
 
 	// Select USDC address based on network
 	var usdcAddress string
@@ -59,7 +339,7 @@ func Load() *Config {
 		usdcAddress = USDCSepolia
 	case "arbitrum":
 		usdcAddress = USDCArbitrum
-	case "avalanche":
+	case "avalanche-c":
 		usdcAddress = USDCAvalanche
 	case "linea":
 		usdcAddress = USDCLinea
@@ -67,38 +347,537 @@ func Load() *Config {
 		usdcAddress = USDCPolygon
 	case "optimism":
 		usdcAddress = USDCOptimism
+	case "base":
+		usdcAddress = USDCBase
+	case "zksync":
+		usdcAddress = USDCZkSync
+	case "local", "localhost", "anvil":
+		// No canonical deployment; USDC_ADDRESS_OVERRIDE is required below
 	default:
-		log.Fatalf("Unsupported network: %s. Supported networks: mainnet, sepolia, arbitrum, avalanche, linea, polygon, optimism", network)
+		log.Fatalf("Unsupported network: %s. Supported networks: mainnet, sepolia, arbitrum, avalanche-c, linea, polygon, optimism, base, zksync, local", network)
+	}
+
+	// Track the network's USDC by default, or any contracts in generic mode
+	trackMode := strings.ToLower(os.Getenv("TRACK_MODE"))
+	switch trackMode {
+	case "":
+		trackMode = TrackModeUSDC
+	case TrackModeUSDC, TrackModeGeneric:
+	default:
+		log.Fatalf("Invalid TRACK_MODE: %q, must be %s or %s", trackMode, TrackModeUSDC, TrackModeGeneric)
+	}
+
+	// Allow pointing at a forked or mock USDC, e.g. on a local Anvil/Hardhat node
+	if override := strings.TrimSpace(os.Getenv("USDC_ADDRESS_OVERRIDE")); override != "" {
+		if !common.IsHexAddress(override) {
+			log.Fatalf("Invalid USDC_ADDRESS_OVERRIDE: %s is not a hex address", override)
+		}
+		override = common.HexToAddress(override).Hex()
+		if usdcAddress != "" {
+			log.Printf("Warning: USDC_ADDRESS_OVERRIDE is set, tracking %s instead of the %s USDC contract %s", override, network, usdcAddress)
+		}
+		usdcAddress = override
+	} else if LocalNetworks[network] && trackMode == TrackModeUSDC {
+		log.Fatalf("Network %s has no USDC contract; set USDC_ADDRESS_OVERRIDE to the deployed token address", network)
+	}
+
+	// Fall back to the network's public RPC so the tracker can be tried without a provider account
+	rpcURLs := getEnvList("WEBHOOK_URL")
+	if len(rpcURLs) == 0 {
+		defaultURL, ok := DefaultRPCURLs[network]
+		if !ok {
+			log.Fatalf("WEBHOOK_URL environment variable is required, network %s has no public default", network)
+		}
+		log.Printf("Warning: WEBHOOK_URL is not set, using the public %s RPC %s. Public RPCs are rate-limited and unsuitable for production; set WEBHOOK_URL to your own endpoint", network, defaultURL)
+		rpcURLs = []string{defaultURL}
+	}
+	webhookURL := rpcURLs[0]
+
+	// Failover only swaps endpoints of the same kind, since subscriptions need WebSocket
+	for _, rpcURL := range rpcURLs[1:] {
+		if isWebSocketURL(rpcURL) != isWebSocketURL(webhookURL) {
+			log.Fatalf("Invalid WEBHOOK_URL: endpoints must all be HTTP(S) or all WebSocket, got %s and %s", RedactURL(webhookURL), RedactURL(rpcURL))
+		}
+	}
+
+	// Select native and/or bridged contracts, default to native
+	variant := strings.ToLower(os.Getenv("USDC_VARIANT"))
+	if variant == "" {
+		variant = VariantNative
+	}
+
+	var contracts []USDCContract
+	if trackMode == TrackModeGeneric {
+		contracts = trackContracts()
+		variant = ""
+	} else {
+		switch variant {
+		case VariantNative:
+			contracts = []USDCContract{{Address: usdcAddress, Variant: VariantNative}}
+		case VariantBridged, VariantBoth:
+			bridged, ok := BridgedUSDC[network]
+			if !ok {
+				log.Fatalf("Network %s has no bridged USDC contract; use USDC_VARIANT=native", network)
+			}
+			if variant == VariantBoth {
+				contracts = append(contracts, USDCContract{Address: usdcAddress, Variant: VariantNative})
+			}
+			contracts = append(contracts, USDCContract{Address: bridged, Variant: VariantBridged})
+		default:
+			log.Fatalf("Unsupported USDC_VARIANT: %s. Supported variants: native, bridged, both", variant)
+		}
+	}
+
+	// Also track the CCTP bridge contracts, whose burns and mints move USDC between chains
+	trackCCTP := getEnvBool("TRACK_CCTP", false)
+	if trackCCTP {
+		cctpContracts, ok := cctp.Contracts(network)
+		if !ok {
+			log.Fatalf("Network %s has no CCTP deployment; unset TRACK_CCTP", network)
+		}
+		if err := cctp.RegisterEvents(); err != nil {
+			log.Fatalf("Failed to register CCTP events: %v", err)
+		}
+		for _, address := range cctpContracts {
+			contracts = append(contracts, USDCContract{Address: address})
+		}
+	}
+
+	// Generic tokens have unknown decimals, so amounts stay in base units unless TOKEN_DECIMALS is set
+	if trackMode == TrackModeGeneric {
+		sinks.TrackedToken = sinks.Token{
+			Symbol:   strings.TrimSpace(os.Getenv("TOKEN_SYMBOL")),
+			Decimals: getEnvInt("TOKEN_DECIMALS", 0),
+		}
+	}
+
+	var trackTopics []common.Hash
+	for _, value := range getEnvList("TRACK_TOPICS") {
+		topic, ok := parseTopic(value)
+		if !ok {
+			log.Fatalf("Invalid TRACK_TOPICS: %q is neither a 32-byte topic hash nor a known event. Supported event types: %s", value, supportedEventTypes())
+		}
+		trackTopics = append(trackTopics, topic)
+	}
+
+	blockInterval, ok := BlockIntervals[network]
+	if !ok {
+		blockInterval = 12 * time.Second // Ethereum block time
+	}
+
+	// Polling faster than the nominal block time lowers latency on chains with variable block times
+	pollInterval := blockInterval
+	if value := os.Getenv("POLL_INTERVAL"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			log.Fatalf("Invalid POLL_INTERVAL: %q must be a positive Go duration such as 500ms or 2s", value)
+		}
+		pollInterval = d
+	}
+
+	receiptFields, err := sinks.ParseReceiptFields(getEnvList("RECEIPT_FIELDS"))
+	if err != nil {
+		log.Fatalf("Invalid RECEIPT_FIELDS: %v", err)
 	}
 
 	// Parse sinks from environment (comma-separated)
-	// Supported: console, sql, mongodb, kafka, filesystem, elasticsearch
-	var sinks []string
+	// Valid names are those registered with sinks.Register (see package sinks/all)
+	var sinkNames []string
 	sinksEnv := os.Getenv("SINKS")
 	if sinksEnv == "" {
 		// Default to console if no sinks specified
-		sinks = []string{"console"}
+		sinkNames = []string{"console"}
 	} else {
 		for _, sink := range strings.Split(sinksEnv, ",") {
 			trimmed := strings.TrimSpace(strings.ToLower(sink))
-			switch trimmed {
-			case "console", "sql", "mongodb", "kafka", "filesystem", "elasticsearch":
-				sinks = append(sinks, trimmed)
-			default:
-				log.Printf("Warning: Unsupported sink '%s'. Supported sinks: console, sql, mongodb, kafka, filesystem, elasticsearch", trimmed)
+			if _, ok := sinks.Lookup(trimmed); ok {
+				sinkNames = append(sinkNames, trimmed)
+			} else {
+				log.Printf("Warning: Unsupported sink '%s'. Supported sinks: %s", trimmed, strings.Join(sinks.Registered(), ", "))
 			}
 		}
 		// If no valid sinks were added, default to console
-		if len(sinks) == 0 {
-			sinks = []string{"console"}
+		if len(sinkNames) == 0 {
+			sinkNames = []string{"console"}
+		}
+	}
+
+	// The write-ahead log lives in WAL_DIR, only used with WAL_ENABLED
+	var walDir string
+	if getEnvBool("WAL_ENABLED", false) {
+		walDir = os.Getenv("WAL_DIR")
+		if walDir == "" {
+			walDir = "./data/wal"
+		}
+	}
+
+	headSubscription := getEnvBool("HEAD_SUBSCRIPTION", false)
+	if headSubscription && !isWebSocketURL(webhookURL) {
+		log.Fatalf("HEAD_SUBSCRIPTION requires a ws:// or wss:// WEBHOOK_URL, got %s", RedactURL(webhookURL))
+	}
+	useLogSubscription := getEnvBool("USE_LOG_SUBSCRIPTION", false)
+	if useLogSubscription && !isWebSocketURL(webhookURL) {
+		log.Fatalf("USE_LOG_SUBSCRIPTION requires a ws:// or wss:// WEBHOOK_URL, got %s", RedactURL(webhookURL))
+	}
+
+	// Sampling keeps a fraction of logs; large transfers can bypass it
+	sampleRate := getEnvFloat("SAMPLE_RATE", 1)
+	if sampleRate > 1 {
+		log.Fatalf("Invalid SAMPLE_RATE: %v, must be between 0.0 and 1.0", sampleRate)
+	}
+	sampleMode := sinks.SampleMode(strings.ToLower(os.Getenv("SAMPLE_MODE")))
+	switch sampleMode {
+	case "":
+		sampleMode = sinks.SampleHash
+	case sinks.SampleHash, sinks.SampleRandom:
+	default:
+		log.Fatalf("Invalid SAMPLE_MODE: %q, must be %s or %s", sampleMode, sinks.SampleHash, sinks.SampleRandom)
+	}
+	var minValue *big.Int
+	if value := os.Getenv("MIN_VALUE"); value != "" {
+		parsed, ok := parseTokenAmount(value, sinks.TrackedToken.Decimals)
+		if !ok {
+			log.Fatalf("Invalid MIN_VALUE: %q, must be a non-negative token amount such as 10000 or 0.5", value)
+		}
+		minValue = parsed
+	}
+
+	// Canary for decoding regressions; off by default so large transfers are never dropped
+	var maxSaneValue *big.Int
+	if value := os.Getenv("MAX_SANE_VALUE"); value != "" {
+		parsed, ok := parseTokenAmount(value, sinks.TrackedToken.Decimals)
+		if !ok {
+			log.Fatalf("Invalid MAX_SANE_VALUE: %q, must be a non-negative token amount such as 100000000000", value)
+		}
+		maxSaneValue = parsed
+	}
+	maxSaneValueAction := strings.ToLower(os.Getenv("MAX_SANE_VALUE_ACTION"))
+	switch maxSaneValueAction {
+	case "":
+		maxSaneValueAction = SaneValueWarn
+	case SaneValueWarn, SaneValueFlag, SaneValueDrop:
+	default:
+		log.Fatalf("Invalid MAX_SANE_VALUE_ACTION: %q, must be %s, %s or %s", maxSaneValueAction, SaneValueWarn, SaneValueFlag, SaneValueDrop)
+	}
+
+	// Transfer filling the event-level from/to/value of multi-transfer transactions
+	primaryEvent := sinks.PrimaryStrategy(strings.ToLower(os.Getenv("PRIMARY_EVENT")))
+	switch primaryEvent {
+	case "":
+		primaryEvent = sinks.PrimaryLargest
+	case sinks.PrimaryFirst, sinks.PrimaryLargest:
+	default:
+		log.Fatalf("Invalid PRIMARY_EVENT: %q, must be %s or %s", primaryEvent, sinks.PrimaryFirst, sinks.PrimaryLargest)
+	}
+	sinks.PrimarySelection = primaryEvent
+
+	// Day boundaries for daily rotation, e.g. Europe/Berlin to match local business days
+	rotationTimezone := time.UTC
+	if name := os.Getenv("ROTATION_TIMEZONE"); name != "" {
+		location, err := time.LoadLocation(name)
+		if err != nil {
+			log.Fatalf("Invalid ROTATION_TIMEZONE %q, must be an IANA time zone such as UTC or America/New_York: %v", name, err)
+		}
+		rotationTimezone = location
+	}
+
+	// Per-sink event type filters, e.g. SINKS_KAFKA_EVENT_TYPES=Approval
+	sinkEventTypes := make(map[string][]erc20.Event)
+	for _, name := range sinkNames {
+		key := "SINKS_" + strings.ToUpper(name) + "_EVENT_TYPES"
+		for _, value := range getEnvList(key) {
+			eventType, ok := erc20.ParseEvent(value)
+			if !ok {
+				log.Fatalf("Invalid %s: unknown event type %q. Supported event types: %s", key, value, supportedEventTypes())
+			}
+			sinkEventTypes[name] = append(sinkEventTypes[name], eventType)
+		}
+	}
+
+	// Per-sink buffers and ordered queues both give every sink its own goroutine
+	sinkOrderWindow := getEnvInt("SINK_ORDER_WINDOW", 0)
+	sinkBufferSize := getEnvInt("SINK_BUFFER_SIZE", 0)
+	if sinkBufferSize > 0 && sinkOrderWindow > 0 {
+		log.Fatalf("Invalid SINK_BUFFER_SIZE: cannot be combined with SINK_ORDER_WINDOW, whose queues already buffer every sink")
+	}
+	sinkBufferPolicy := sinks.BufferPolicy(strings.ToLower(os.Getenv("SINK_BUFFER_POLICY")))
+	switch sinkBufferPolicy {
+	case "":
+		sinkBufferPolicy = sinks.BufferBlock
+	case sinks.BufferBlock, sinks.BufferDropOldest, sinks.BufferDeadLetter:
+	default:
+		log.Fatalf("Invalid SINK_BUFFER_POLICY: %q, must be %s, %s or %s", sinkBufferPolicy, sinks.BufferBlock, sinks.BufferDropOldest, sinks.BufferDeadLetter)
+	}
+
+	// Per-sink confirmation depth, e.g. SINKS_S3_CONFIRMATIONS=12
+	sinkConfirmations := make(map[string]uint64)
+	for _, name := range sinkNames {
+		key := "SINKS_" + strings.ToUpper(name) + "_CONFIRMATIONS"
+		value := os.Getenv(key)
+		if value == "" {
+			continue
+		}
+		depth, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			log.Fatalf("Invalid %s: %q, must be a non-negative number of blocks", key, value)
+		}
+		sinkConfirmations[name] = depth
+	}
+
+	// Periodic balances of the watched addresses, read from the block's state
+	watchAddresses := getEnvList("WATCH_ADDRESSES")
+	balanceSnapshotInterval := uint64(getEnvInt("BALANCE_SNAPSHOT_INTERVAL", 0))
+	if balanceSnapshotInterval > 0 && len(watchAddresses) == 0 {
+		log.Fatalf("Invalid BALANCE_SNAPSHOT_INTERVAL: requires WATCH_ADDRESSES, the addresses whose balances are read")
+	}
+	if balanceSnapshotInterval > 0 && useLogSubscription {
+		log.Printf("Warning: BALANCE_SNAPSHOT_INTERVAL is ignored with USE_LOG_SUBSCRIPTION, which only sees blocks with logs")
+	}
+
+	// Reorgs are detected from block hashes; the log subscription reports them as removed logs instead
+	reorgWindow := uint64(getEnvInt("REORG_WINDOW", 0))
+	if reorgWindow > 0 && useLogSubscription {
+		log.Printf("Warning: REORG_WINDOW is ignored with USE_LOG_SUBSCRIPTION, which reports reorgs as removed logs")
+	}
+
+	// Per-sink init requirement, e.g. SINKS_KAFKA_INIT_REQUIRED=false
+	sinkInitOptional := make(map[string]bool)
+	for _, name := range sinkNames {
+		if !getEnvBool("SINKS_"+strings.ToUpper(name)+"_INIT_REQUIRED", true) {
+			sinkInitOptional[name] = true
 		}
 	}
 
 	return &Config{
-		WebhookURL:    webhookURL,
-		BlockInterval: 12 * time.Second, // Ethereum block time
-		USDCAddress:   usdcAddress,
-		Network:       network,
-		Sink:          sinks,
+		WebhookURL:         webhookURL,
+		RPCURLs:            rpcURLs,
+		BlockInterval:      blockInterval,
+		PollInterval:       pollInterval,
+		TrackMode:          trackMode,
+		USDCAddress:        contracts[0].Address,
+		USDCVariant:        variant,
+		USDCContracts:      contracts,
+		Network:            network,
+		ChainID:            ChainIDs[network],
+		Sink:               sinkNames,
+		LogFormat:          logFormat,
+		BlockWorkers:       getEnvInt("BLOCK_WORKERS", 1),
+		CatchUpWorkers:     getEnvInt("CATCH_UP_WORKERS", 1),
+		HeadSubscription:   headSubscription,
+		UseLogSubscription: useLogSubscription,
+		CursorFile:         os.Getenv("CURSOR_FILE"),
+		CheckpointInterval: time.Duration(getEnvInt("CHECKPOINT_INTERVAL", 10)) * time.Second,
+		WALDir:             walDir,
+		ReorgWindow:        reorgWindow,
+		ShutdownTimeout:    time.Duration(getEnvInt("SHUTDOWN_TIMEOUT", 30)) * time.Second,
+		DryRun:             getEnvBool("DRY_RUN", false),
+		EmitEmptyBlocks:    getEnvBool("EMIT_EMPTY_BLOCKS", false),
+		IncludeFailedTx:    getEnvBool("INCLUDE_FAILED_TX", true),
+		WatchAddresses:     watchAddresses,
+		DropZeroValue:      getEnvBool("DROP_ZERO_VALUE", false),
+		BalanceInterval:    balanceSnapshotInterval,
+		CustomEventsFile:   customEventsFile,
+		TrackCCTP:          trackCCTP,
+		SampleRate:         sampleRate,
+		SampleMode:         sampleMode,
+		PrimaryEvent:       primaryEvent,
+		MinValue:           minValue,
+		MaxSaneValue:       maxSaneValue,
+		MaxSaneValueAction: maxSaneValueAction,
+		TrackTopics:        trackTopics,
+		RPCRateLimit:       getEnvFloat("RPC_RATE_LIMIT", 0),
+		RPCMaxConns:        getEnvInt("RPC_MAX_CONNS", 0),
+		RPCConnectRetries:  getEnvInt("RPC_CONNECT_RETRIES", 0),
+		RPCConnectBackoff:  time.Duration(getEnvInt("RPC_CONNECT_BACKOFF", 2)) * time.Second,
+		RPCFailoverTimeout: time.Duration(getEnvInt("RPC_FAILOVER_TIMEOUT", 30)) * time.Second,
+		DedupeEnabled:      getEnvBool("DEDUPE_ENABLED", false),
+		DedupeCacheSize:    getEnvInt("DEDUPE_CACHE_SIZE", 10000),
+		FlushEveryBlock:    getEnvBool("SINK_FLUSH_EVERY_BLOCK", false),
+		ReceiptFields:      receiptFields,
+		SinkMaxPending:     getEnvInt("SINK_MAX_PENDING", 0),
+		SinkOrderWindow:    sinkOrderWindow,
+		SinkBufferSize:     sinkBufferSize,
+		SinkBufferPolicy:   sinkBufferPolicy,
+		RotationTimezone:   rotationTimezone,
+		SinkWriteTimeout:   time.Duration(getEnvInt("SINK_WRITE_TIMEOUT", 0)) * time.Second,
+		DeadLetterFile:     os.Getenv("DEAD_LETTER_FILE"),
+		SinkEventTypes:     sinkEventTypes,
+		SinkConfirmations:  sinkConfirmations,
+		SinkInitOptional:   sinkInitOptional,
+		SinkInitTimeout:    time.Duration(getEnvInt("SINK_INIT_TIMEOUT", 0)) * time.Second,
+	}
+}
+
+// supportedEventTypes returns the sorted names of all known ERC20 event types.
+func supportedEventTypes() string {
+	names := make([]string, 0, len(erc20.EventSignatures))
+	for event := range erc20.EventSignatures {
+		names = append(names, string(event))
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// getEnvList reads a comma-separated environment variable, skipping empty entries.
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if trimmed := strings.TrimSpace(value); trimmed != "" {
+			values = append(values, trimmed)
+		}
+	}
+	return values
+}
+
+// getEnvBool reads a boolean environment variable, returning def if unset or invalid.
+func getEnvBool(key string, def bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Warning: Invalid value for %s: %q, using default %v", key, value, def)
+		return def
+	}
+	return parsed
+}
+
+// getEnvFloat reads a non-negative float environment variable, returning def if unset or invalid.
+func getEnvFloat(key string, def float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || parsed < 0 {
+		log.Printf("Warning: Invalid value for %s: %q, using default %v", key, value, def)
+		return def
+	}
+	return parsed
+}
+
+// getEnvInt reads a positive integer environment variable, returning def if unset or invalid.
+func getEnvInt(key string, def int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed <= 0 {
+		log.Printf("Warning: Invalid value for %s: %q, using default %d", key, value, def)
+		return def
+	}
+	return parsed
+}
+
+// trackContracts parses TRACK_CONTRACTS, the contracts tracked in generic mode
+func trackContracts() []USDCContract {
+	addresses := getEnvList("TRACK_CONTRACTS")
+	if len(addresses) == 0 {
+		log.Fatalf("TRACK_MODE=%s requires TRACK_CONTRACTS, a comma-separated list of contract addresses", TrackModeGeneric)
+	}
+
+	contracts := make([]USDCContract, 0, len(addresses))
+	for _, address := range addresses {
+		if !common.IsHexAddress(address) {
+			log.Fatalf("Invalid TRACK_CONTRACTS: %s is not a hex address", address)
+		}
+		contracts = append(contracts, USDCContract{Address: common.HexToAddress(address).Hex()})
+	}
+	return contracts
+}
+
+// parseTopic parses a TRACK_TOPICS entry, either a 0x-prefixed 32-byte topic hash
+// or the name of a known event such as Transfer
+func parseTopic(value string) (common.Hash, bool) {
+	if strings.HasPrefix(value, "0x") || strings.HasPrefix(value, "0X") {
+		if len(value) != 2+2*common.HashLength {
+			return common.Hash{}, false
+		}
+		if _, err := hex.DecodeString(value[2:]); err != nil {
+			return common.Hash{}, false
+		}
+		return common.HexToHash(value), true
+	}
+
+	event, ok := erc20.ParseEvent(value)
+	if !ok {
+		return common.Hash{}, false
+	}
+	return common.HexToHash(erc20.EventSignatures[event]), true
+}
+
+// parseTokenAmount converts a whole-token amount such as "10000" or "0.5" to base
+// units, truncating digits beyond the token's decimals.
+func parseTokenAmount(value string, decimals int) (*big.Int, bool) {
+	amount, ok := new(big.Rat).SetString(value)
+	if !ok || amount.Sign() < 0 {
+		return nil, false
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	amount.Mul(amount, new(big.Rat).SetInt(scale))
+	return new(big.Int).Quo(amount.Num(), amount.Denom()), true
+}
+
+// isWebSocketURL reports whether rawURL uses the ws or wss scheme.
+func isWebSocketURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	scheme := strings.ToLower(u.Scheme)
+	return scheme == "ws" || scheme == "wss"
+}
+
+// RedactURL returns a form of the RPC URL that is safe to log.
+// The scheme, host and leading API version segments such as /v2 or /v3 are kept,
+// while credentials, the rest of the path (providers embed API keys anywhere after
+// the version, e.g. Infura's /v3/KEY or /v2/KEY/rpc), the query string and the
+// fragment are masked. For example "https://mainnet.infura.io/v3/SECRET" becomes
+// "https://mainnet.infura.io/v3/***".
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "***"
+	}
+
+	redacted := u.Scheme + "://" + u.Host
+	if u.User != nil {
+		redacted = u.Scheme + "://***@" + u.Host
+	}
+
+	if path := strings.Trim(u.Path, "/"); path != "" {
+		masking := false
+		for _, segment := range strings.Split(path, "/") {
+			if !masking && isAPIVersion(segment) {
+				redacted += "/" + segment
+				continue
+			}
+			masking = true
+			redacted += "/***"
+		}
+	}
+
+	if u.RawQuery != "" {
+		redacted += "?***"
+	}
+	if u.Fragment != "" {
+		redacted += "#***"
+	}
+
+	return redacted
+}
+
+// isAPIVersion reports whether a path segment is an API version like v2 or v3
+func isAPIVersion(segment string) bool {
+	if len(segment) < 2 || segment[0] != 'v' {
+		return false
+	}
+	for _, c := range segment[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
 	}
+	return true
 }
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// loadConfigFile reads a YAML or TOML file, chosen by extension, and exports
+// its settings as environment variables, so the rest of the configuration,
+// including every sink's NewConfig, reads them unchanged. Variables that are
+// already set are left alone, so the environment overrides the file.
+//
+// Keys are environment variable names. Nested tables are joined with
+// underscores, so elasticsearch.urls sets ELASTICSEARCH_URLS, and lists are
+// joined with commas.
+func loadConfigFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	settings := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &settings)
+	case ".toml":
+		err = toml.Unmarshal(data, &settings)
+	default:
+		return fmt.Errorf("unsupported config file %s, must end in .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	vars := make(map[string]string)
+	if err := flattenSettings("", settings, vars); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, set := os.LookupEnv(name); set {
+			continue
+		}
+		if err := os.Setenv(name, vars[name]); err != nil {
+			return fmt.Errorf("failed to set %s from config file: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// flattenSettings converts nested settings to environment variable names and values
+func flattenSettings(prefix string, settings map[string]interface{}, vars map[string]string) error {
+	for key, value := range settings {
+		name := strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+
+		switch v := value.(type) {
+		case nil:
+			continue
+		case map[string]interface{}:
+			if err := flattenSettings(name, v, vars); err != nil {
+				return err
+			}
+			continue
+		case []interface{}:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				text, ok := scalarString(item)
+				if !ok {
+					return fmt.Errorf("%s: lists may only contain scalar values", name)
+				}
+				items = append(items, text)
+			}
+			value = strings.Join(items, ",")
+		}
+
+		text, ok := scalarString(value)
+		if !ok {
+			return fmt.Errorf("%s: unsupported value %v", name, value)
+		}
+		if _, duplicate := vars[name]; duplicate {
+			return fmt.Errorf("%s is set more than once", name)
+		}
+		vars[name] = text
+	}
+	return nil
+}
+
+// scalarString formats a scalar setting the way it would be written in the environment
+func scalarString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,41 @@
+package erc20
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// balanceOfABI is the ERC20 balanceOf function
+const balanceOfABI = `[{"type":"function","name":"balanceOf","stateMutability":"view",` +
+	`"inputs":[{"name":"account","type":"address"}],"outputs":[{"name":"","type":"uint256"}]}]`
+
+// parsedBalanceOfABI is balanceOfABI parsed once; the definition is constant, so it cannot fail
+var parsedBalanceOfABI, _ = abi.JSON(strings.NewReader(balanceOfABI))
+
+// BalanceOf calls balanceOf(account) on token with the state at blockNumber and
+// returns the raw token amount. Historic blocks need a node that keeps their state,
+// e.g. an archive node for blocks older than the last 128.
+func BalanceOf(ctx context.Context, caller bind.ContractCaller, token, account common.Address, blockNumber uint64) (*big.Int, error) {
+	contract := bind.NewBoundContract(token, parsedBalanceOfABI, caller, nil, nil)
+	opts := &bind.CallOpts{
+		Context:     ctx,
+		BlockNumber: new(big.Int).SetUint64(blockNumber),
+	}
+
+	var out []interface{}
+	if err := contract.Call(opts, &out, "balanceOf", account); err != nil {
+		return nil, fmt.Errorf("balanceOf(%s) on %s at block %d failed: %w", account.Hex(), token.Hex(), blockNumber, err)
+	}
+	if len(out) == 1 {
+		if balance, ok := out[0].(*big.Int); ok {
+			return balance, nil
+		}
+	}
+	return nil, fmt.Errorf("balanceOf(%s) on %s returned %v, expected a uint256", account.Hex(), token.Hex(), out)
+}
@@ -0,0 +1,118 @@
+package erc20
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// customEvents holds the ABI definitions loaded by LoadCustomEvents and RegisterABI, keyed by signature hash
+var customEvents = make(map[common.Hash]abi.Event)
+
+// LoadCustomEvents reads a standard Solidity ABI JSON file and registers every event
+// it defines in EventSignatures, so custom events are recognized everywhere the
+// built-in ones are, and decoded by DecodeCustom. Non-event entries are ignored and
+// events identical to a built-in one are skipped. It must be called at startup,
+// before any events are processed.
+func LoadCustomEvents(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read custom events file: %w", err)
+	}
+
+	return RegisterABI(string(data), path)
+}
+
+// RegisterABI registers every event of a Solidity ABI JSON definition the same
+// way LoadCustomEvents does, for ABIs built into the tracker such as CCTP's.
+// source names the ABI in errors. It must be called at startup, before any
+// events are processed.
+func RegisterABI(definitionJSON, source string) error {
+	parsed, err := abi.JSON(strings.NewReader(definitionJSON))
+	if err != nil {
+		return fmt.Errorf("invalid ABI in %s: %w", source, err)
+	}
+	if len(parsed.Events) == 0 {
+		return fmt.Errorf("no events defined in %s", source)
+	}
+
+	for _, definition := range parsed.Events {
+		if definition.Anonymous {
+			return fmt.Errorf("event %s in %s is anonymous and cannot be identified by its signature", definition.Name, source)
+		}
+
+		name := Event(definition.Name)
+		signature := definition.ID.Hex()
+		if existing, ok := EventSignatures[name]; ok {
+			if existing == signature {
+				continue
+			}
+			return fmt.Errorf("event %s (%s) in %s conflicts with the known event %s", name, definition.Sig, source, name)
+		}
+		if existing, found := GetEventBySignature(signature); found {
+			return fmt.Errorf("event %s (%s) in %s has the same signature as the known event %s", name, definition.Sig, source, existing)
+		}
+
+		EventSignatures[name] = signature
+		customEvents[definition.ID] = definition
+	}
+
+	return nil
+}
+
+// DecodeCustom decodes the named parameters of a log of an event registered by
+// LoadCustomEvents or RegisterABI. Addresses are checksummed, integers are decimal strings and
+// byte values are 0x-prefixed hex. Indexed strings, bytes and arrays are only
+// available as their keccak256 hash. Returns false if the log is not a custom
+// event or does not match its ABI.
+func DecodeCustom(topics []common.Hash, data []byte) (map[string]interface{}, bool) {
+	if len(topics) == 0 {
+		return nil, false
+	}
+	definition, ok := customEvents[topics[0]]
+	if !ok {
+		return nil, false
+	}
+
+	values := make(map[string]interface{})
+	var indexed abi.Arguments
+	for _, input := range definition.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		}
+	}
+	if err := abi.ParseTopicsIntoMap(values, indexed, topics[1:]); err != nil {
+		return nil, false
+	}
+	if err := definition.Inputs.UnpackIntoMap(values, data); err != nil {
+		return nil, false
+	}
+
+	for name, value := range values {
+		values[name] = formatABIValue(value)
+	}
+	return values, true
+}
+
+// formatABIValue converts a decoded ABI value to the form sinks store
+func formatABIValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *big.Int:
+		return v.String()
+	case common.Address:
+		return v.Hex()
+	case common.Hash:
+		return v.Hex()
+	case [32]byte:
+		return common.Hash(v).Hex()
+	case []byte:
+		return "0x" + hex.EncodeToString(v)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,183 @@
+package erc20
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Decoded holds the parameters of a recognized log. Parameters that a malformed
+// log does not carry are left empty rather than guessed.
+type Decoded struct {
+	Event          Event
+	From           string                 // Transfer
+	To             string                 // Transfer
+	Owner          string                 // Approval
+	Spender        string                 // Approval
+	Value          *big.Int               // Transfer and Approval, nil if data is shorter than one word
+	Implementation string                 // Upgraded
+	Fields         map[string]interface{} // Events loaded by LoadCustomEvents
+}
+
+// DecodeLog decodes a log's event type and parameters. It never panics on short
+// topics or data, so it is safe for anonymous or malformed logs. Transfer and
+// Approval parameters are only decoded from the ERC20 layout of exactly three
+// topics: ERC721 emits the same signatures with the token id as a fourth topic,
+// and logs with fewer topics do not index the addresses. Returns false if the
+// event is not recognized.
+func DecodeLog(topics []common.Hash, data []byte) (Decoded, bool) {
+	if len(topics) == 0 {
+		return Decoded{}, false
+	}
+	event, found := GetEventBySignature(topics[0].Hex())
+	if !found {
+		return Decoded{}, false
+	}
+
+	decoded := Decoded{Event: event}
+	if fields, ok := DecodeCustom(topics, data); ok {
+		decoded.Fields = fields
+		return decoded, true
+	}
+
+	switch event {
+	case Upgraded:
+		decoded.Implementation, _ = DecodeImplementation(topics, data)
+	case Transfer, Approval:
+		if len(topics) != 3 {
+			break
+		}
+		if event == Transfer {
+			decoded.From = DecodeAddress(topics[1])
+			decoded.To = DecodeAddress(topics[2])
+		} else {
+			decoded.Owner = DecodeAddress(topics[1])
+			decoded.Spender = DecodeAddress(topics[2])
+		}
+		decoded.Value, _ = DecodeValue(data)
+	}
+
+	return decoded, true
+}
+
+// DecodeAddress decodes an indexed address parameter (from/to, owner/spender) from its
+// 32-byte topic and returns it in EIP-55 checksummed 20-byte form, the format every
+// sink uses for addresses.
+func DecodeAddress(topic common.Hash) string {
+	return TopicToAddress(topic).Hex()
+}
+
+// TopicToAddress converts an indexed address parameter to an address. Indexed
+// addresses are left-padded to 32 bytes, so the address is the low 20 bytes; the
+// top 12 bytes are dropped even if a malformed log sets them.
+func TopicToAddress(topic common.Hash) common.Address {
+	return common.BytesToAddress(topic[common.HashLength-common.AddressLength:])
+}
+
+// DecodeImplementation decodes the new implementation address from an Upgraded log.
+// EIP-1967 proxies index the address, while older proxies such as USDC's FiatTokenProxy
+// emit it in the data field, so both layouts are accepted.
+// Returns false if neither holds an address.
+func DecodeImplementation(topics []common.Hash, data []byte) (string, bool) {
+	if len(topics) >= 2 {
+		return DecodeAddress(topics[1]), true
+	}
+	if len(data) >= 32 {
+		return TopicToAddress(common.BytesToHash(data[:32])).Hex(), true
+	}
+	return "", false
+}
+
+// MaxUint256 is the largest uint256 value, conventionally used for "infinite" approvals.
+var MaxUint256 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+// DecodeValue decodes the uint256 amount from the data field of a Transfer or Approval log.
+// Returns false if the data is shorter than one 32-byte word.
+func DecodeValue(data []byte) (*big.Int, bool) {
+	if len(data) < 32 {
+		return nil, false
+	}
+	return new(big.Int).SetBytes(data[:32]), true
+}
+
+// InfiniteApprovalValue is the normalized value string sinks store for infinite approvals
+// instead of the 78-digit max-uint256 amount.
+const InfiniteApprovalValue = "unlimited"
+
+// IsInfiniteApproval reports whether value is the max-uint256 "infinite approval" amount.
+func IsInfiniteApproval(value *big.Int) bool {
+	return value != nil && value.Cmp(MaxUint256) == 0
+}
+
+// FormatAmount formats a raw token amount for humans using the token's decimals, with
+// thousands separators, at least two fractional digits and the symbol if not empty, e.g.
+// 1234567890000 with 6 decimals and "USDC" becomes "1,234,567.89 USDC". Amounts below
+// one cent keep every significant digit ("0.000001") and large amounts are never
+// shortened to scientific notation.
+func FormatAmount(value *big.Int, decimals int, symbol string) string {
+	amount := formatAmount(value, decimals)
+	if symbol == "" {
+		return amount
+	}
+	return amount + " " + symbol
+}
+
+// formatAmount formats a raw token amount without a symbol
+func formatAmount(value *big.Int, decimals int) string {
+	if value == nil {
+		return "0.00"
+	}
+	if decimals < 0 {
+		decimals = 0
+	}
+
+	digits := new(big.Int).Abs(value).String()
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+
+	whole := digits[:len(digits)-decimals]
+	fraction := strings.TrimRight(digits[len(digits)-decimals:], "0")
+	for len(fraction) < 2 {
+		fraction += "0"
+	}
+
+	var b strings.Builder
+	if value.Sign() < 0 {
+		b.WriteByte('-')
+	}
+	for i, c := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	b.WriteByte('.')
+	b.WriteString(fraction)
+
+	return b.String()
+}
+
+// FormatDecimal formats a raw token amount as an exact decimal number without
+// separators, for machine-readable output, e.g. 1250000000 with 6 decimals
+// becomes "1250.000000".
+func FormatDecimal(value *big.Int, decimals int) string {
+	if value == nil {
+		return ""
+	}
+	if decimals <= 0 {
+		return value.String()
+	}
+
+	digits := new(big.Int).Abs(value).String()
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+
+	sign := ""
+	if value.Sign() < 0 {
+		sign = "-"
+	}
+	return sign + digits[:len(digits)-decimals] + "." + digits[len(digits)-decimals:]
+}
@@ -0,0 +1,288 @@
+package erc20
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	transferTopic = common.HexToHash(EventSignatures[Transfer])
+	approvalTopic = common.HexToHash(EventSignatures[Approval])
+	upgradedTopic = common.HexToHash(EventSignatures[Upgraded])
+
+	alice = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	bob   = common.HexToAddress("0x2222222222222222222222222222222222222222")
+)
+
+// word returns value as a 32-byte ABI word
+func word(value int64) []byte {
+	return common.BigToHash(big.NewInt(value)).Bytes()
+}
+
+func addressTopic(address common.Address) common.Hash {
+	return common.BytesToHash(address.Bytes())
+}
+
+func TestDecodeLogShortTopicsAndData(t *testing.T) {
+	tests := []struct {
+		name   string
+		topics []common.Hash
+		data   []byte
+		ok     bool
+		want   Decoded
+	}{
+		{
+			name: "no topics",
+			data: word(1),
+		},
+		{
+			name:   "unknown signature",
+			topics: []common.Hash{common.HexToHash("0x01"), addressTopic(alice), addressTopic(bob)},
+			data:   word(1),
+		},
+		{
+			name:   "transfer",
+			topics: []common.Hash{transferTopic, addressTopic(alice), addressTopic(bob)},
+			data:   word(1_000_000),
+			ok:     true,
+			want:   Decoded{Event: Transfer, From: alice.Hex(), To: bob.Hex(), Value: big.NewInt(1_000_000)},
+		},
+		{
+			name:   "transfer without indexed addresses",
+			topics: []common.Hash{transferTopic},
+			data:   word(1_000_000),
+			ok:     true,
+			want:   Decoded{Event: Transfer},
+		},
+		{
+			name:   "transfer with one indexed address",
+			topics: []common.Hash{transferTopic, addressTopic(alice)},
+			data:   word(1_000_000),
+			ok:     true,
+			want:   Decoded{Event: Transfer},
+		},
+		{
+			name:   "erc721 transfer with token id topic",
+			topics: []common.Hash{transferTopic, addressTopic(alice), addressTopic(bob), common.HexToHash("0x07")},
+			ok:     true,
+			want:   Decoded{Event: Transfer},
+		},
+		{
+			name:   "transfer without data",
+			topics: []common.Hash{transferTopic, addressTopic(alice), addressTopic(bob)},
+			ok:     true,
+			want:   Decoded{Event: Transfer, From: alice.Hex(), To: bob.Hex()},
+		},
+		{
+			name:   "transfer with short data",
+			topics: []common.Hash{transferTopic, addressTopic(alice), addressTopic(bob)},
+			data:   word(1_000_000)[:31],
+			ok:     true,
+			want:   Decoded{Event: Transfer, From: alice.Hex(), To: bob.Hex()},
+		},
+		{
+			name:   "transfer with trailing data",
+			topics: []common.Hash{transferTopic, addressTopic(alice), addressTopic(bob)},
+			data:   append(word(5), 0xff),
+			ok:     true,
+			want:   Decoded{Event: Transfer, From: alice.Hex(), To: bob.Hex(), Value: big.NewInt(5)},
+		},
+		{
+			name:   "approval with short data",
+			topics: []common.Hash{approvalTopic, addressTopic(alice), addressTopic(bob)},
+			data:   []byte{0x01},
+			ok:     true,
+			want:   Decoded{Event: Approval, Owner: alice.Hex(), Spender: bob.Hex()},
+		},
+		{
+			name:   "upgraded without topic or data",
+			topics: []common.Hash{upgradedTopic},
+			ok:     true,
+			want:   Decoded{Event: Upgraded},
+		},
+		{
+			name:   "upgraded with short data",
+			topics: []common.Hash{upgradedTopic},
+			data:   alice.Bytes(),
+			ok:     true,
+			want:   Decoded{Event: Upgraded},
+		},
+		{
+			name:   "upgraded with address in data",
+			topics: []common.Hash{upgradedTopic},
+			data:   addressTopic(alice).Bytes(),
+			ok:     true,
+			want:   Decoded{Event: Upgraded, Implementation: alice.Hex()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := DecodeLog(tt.topics, tt.data)
+			if ok != tt.ok {
+				t.Fatalf("DecodeLog ok = %v, want %v", ok, tt.ok)
+			}
+			if got.Event != tt.want.Event || got.From != tt.want.From || got.To != tt.want.To ||
+				got.Owner != tt.want.Owner || got.Spender != tt.want.Spender ||
+				got.Implementation != tt.want.Implementation {
+				t.Errorf("DecodeLog = %+v, want %+v", got, tt.want)
+			}
+			if (got.Value == nil) != (tt.want.Value == nil) ||
+				(got.Value != nil && got.Value.Cmp(tt.want.Value) != 0) {
+				t.Errorf("DecodeLog value = %v, want %v", got.Value, tt.want.Value)
+			}
+		})
+	}
+}
+
+func FuzzDecodeLog(f *testing.F) {
+	topics := func(hashes ...common.Hash) []byte {
+		var b []byte
+		for _, hash := range hashes {
+			b = append(b, hash.Bytes()...)
+		}
+		return b
+	}
+	f.Add([]byte{}, []byte{})
+	f.Add(topics(transferTopic), word(1))
+	f.Add(topics(transferTopic, addressTopic(alice), addressTopic(bob)), word(1))
+	f.Add(topics(transferTopic, addressTopic(alice), addressTopic(bob)), []byte{0x01})
+	f.Add(topics(approvalTopic, addressTopic(alice), addressTopic(bob))[:70], word(1))
+	f.Add(topics(upgradedTopic), alice.Bytes())
+
+	f.Fuzz(func(t *testing.T, topicBytes, data []byte) {
+		// Topics are whole 32-byte words; a trailing partial word is dropped
+		var logTopics []common.Hash
+		for len(topicBytes) >= common.HashLength {
+			logTopics = append(logTopics, common.BytesToHash(topicBytes[:common.HashLength]))
+			topicBytes = topicBytes[common.HashLength:]
+		}
+
+		decoded, ok := DecodeLog(logTopics, data)
+		if !ok {
+			return
+		}
+		if decoded.Fields != nil {
+			return
+		}
+		switch decoded.Event {
+		case Transfer, Approval:
+			if len(logTopics) != 3 && (decoded.From != "" || decoded.Owner != "" || decoded.Value != nil) {
+				t.Errorf("decoded parameters of a %s with %d topics: %+v", decoded.Event, len(logTopics), decoded)
+			}
+			if len(data) < 32 && decoded.Value != nil {
+				t.Errorf("decoded value %v from %d bytes of data", decoded.Value, len(data))
+			}
+			if decoded.Value != nil && decoded.Value.Cmp(MaxUint256) > 0 {
+				t.Errorf("decoded value %v exceeds uint256", decoded.Value)
+			}
+		}
+	})
+}
+
+func TestDecodeAddressChecksums(t *testing.T) {
+	tests := []struct {
+		topic string
+		want  string
+	}{
+		{
+			topic: "0x000000000000000000000000a0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+			want:  "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
+		},
+		{
+			topic: "0x000000000000000000000000dac17f958d2ee523a2206206994597c13d831ec7",
+			want:  "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+		},
+		{
+			topic: "0x0000000000000000000000000000000000000000000000000000000000000000",
+			want:  "0x0000000000000000000000000000000000000000",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := DecodeAddress(common.HexToHash(tt.topic)); got != tt.want {
+			t.Errorf("DecodeAddress(%s) = %s, want %s", tt.topic, got, tt.want)
+		}
+	}
+}
+
+func TestTopicToAddressDropsHighBytes(t *testing.T) {
+	tests := []struct {
+		name  string
+		topic string
+		want  common.Address
+	}{
+		{
+			name:  "left-padded",
+			topic: "0x000000000000000000000000a0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+			want:  common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"),
+		},
+		{
+			name:  "all high bytes set",
+			topic: "0xffffffffffffffffffffffffa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+			want:  common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"),
+		},
+		{
+			name:  "single high byte set",
+			topic: "0x0000000000000000000000011111111111111111111111111111111111111111",
+			want:  alice,
+		},
+		{
+			name:  "high bytes only",
+			topic: "0xdeadbeefdeadbeefdeadbeef0000000000000000000000000000000000000000",
+			want:  common.Address{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TopicToAddress(common.HexToHash(tt.topic)); got != tt.want {
+				t.Errorf("TopicToAddress(%s) = %s, want %s", tt.topic, got.Hex(), tt.want.Hex())
+			}
+		})
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	maxUint256 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+	tests := []struct {
+		name     string
+		value    *big.Int
+		decimals int
+		symbol   string
+		want     string
+	}{
+		{name: "nil", value: nil, decimals: 6, symbol: "USDC", want: "0.00 USDC"},
+		{name: "zero", value: big.NewInt(0), decimals: 6, symbol: "USDC", want: "0.00 USDC"},
+		{name: "smallest unit", value: big.NewInt(1), decimals: 6, symbol: "USDC", want: "0.000001 USDC"},
+		{name: "sub-cent", value: big.NewInt(4_500), decimals: 6, symbol: "USDC", want: "0.0045 USDC"},
+		{name: "one cent", value: big.NewInt(10_000), decimals: 6, symbol: "USDC", want: "0.01 USDC"},
+		{name: "below one", value: big.NewInt(999_999), decimals: 6, symbol: "USDC", want: "0.999999 USDC"},
+		{name: "one", value: big.NewInt(1_000_000), decimals: 6, symbol: "USDC", want: "1.00 USDC"},
+		{name: "no separator below a thousand", value: big.NewInt(999_000_000), decimals: 6, symbol: "USDC", want: "999.00 USDC"},
+		{name: "first separator", value: big.NewInt(1_000_000_000), decimals: 6, symbol: "USDC", want: "1,000.00 USDC"},
+		{name: "millions", value: big.NewInt(1_234_567_890_000), decimals: 6, symbol: "USDC", want: "1,234,567.89 USDC"},
+		{name: "negative", value: big.NewInt(-1_234_500_000), decimals: 6, symbol: "USDC", want: "-1,234.50 USDC"},
+		{name: "no symbol", value: big.NewInt(1_500_000), decimals: 6, want: "1.50"},
+		{name: "no decimals", value: big.NewInt(1_234), decimals: 0, symbol: "TKN", want: "1,234.00 TKN"},
+		{name: "18 decimals", value: big.NewInt(1), decimals: 18, symbol: "DAI", want: "0.000000000000000001 DAI"},
+		{
+			name:     "max uint256",
+			value:    maxUint256,
+			decimals: 6,
+			symbol:   "USDC",
+			want:     "115,792,089,237,316,195,423,570,985,008,687,907,853,269,984,665,640,564,039,457,584,007,913,129.639935 USDC",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatAmount(tt.value, tt.decimals, tt.symbol); got != tt.want {
+				t.Errorf("FormatAmount(%v, %d, %q) = %q, want %q", tt.value, tt.decimals, tt.symbol, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 // Package erc20 provides ERC20 token event definitions and utilities
 package erc20
 
+import "strings"
+
 // Event represents an ERC20 event type
 type Event string
 
@@ -8,6 +10,9 @@ const (
 	// Standard ERC20 events
 	Transfer Event = "Transfer"
 	Approval Event = "Approval"
+
+	// Proxy events, emitted by upgradeable token contracts such as USDC
+	Upgraded Event = "Upgraded"
 )
 
 // EventSignatures maps ERC20 events to their keccak256 signature hashes.
@@ -15,6 +20,7 @@ const (
 var EventSignatures = map[Event]string{
 	Transfer: "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef", // Transfer(address,address,uint256)
 	Approval: "0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925", // Approval(address,address,uint256)
+	Upgraded: "0xbc7cd75a20ee27fd9adebab32041f755214dbc6bffa90cc0225b39da2e5c2d3b", // Upgraded(address)
 }
 
 // GetEventBySignature looks up an event type by its signature hash.
@@ -26,4 +32,15 @@ func GetEventBySignature(signature string) (Event, bool) {
 		}
 	}
 	return "", false
-}
\ No newline at end of file
+}
+
+// ParseEvent looks up an event type by name, ignoring case.
+// Returns the Event and true if found, or empty string and false if not found.
+func ParseEvent(name string) (Event, bool) {
+	for event := range EventSignatures {
+		if strings.EqualFold(string(event), name) {
+			return event, true
+		}
+	}
+	return "", false
+}
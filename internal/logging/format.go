@@ -0,0 +1,173 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formatter renders a log entry as a single line, without the trailing newline
+type Formatter interface {
+	Format(entry LogEntry) ([]byte, error)
+}
+
+// Log formats selectable via LOG_FORMAT
+const (
+	FormatJSON    = "json"    // One JSON object per line, for log shippers
+	FormatLogfmt  = "logfmt"  // key=value pairs
+	FormatConsole = "console" // Colorized, human-friendly lines for local development
+)
+
+// formatter renders every entry. It is set once at startup, before other goroutines log.
+var formatter Formatter = JSONFormatter{}
+
+// SetFormatter replaces the formatter used by every logger
+func SetFormatter(f Formatter) {
+	formatter = f
+}
+
+// ParseFormat returns the formatter for a LOG_FORMAT value
+func ParseFormat(name string) (Formatter, error) {
+	switch strings.ToLower(name) {
+	case FormatJSON:
+		return JSONFormatter{}, nil
+	case FormatLogfmt:
+		return LogfmtFormatter{}, nil
+	case FormatConsole:
+		return ConsoleFormatter{NoColor: os.Getenv("NO_COLOR") != ""}, nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q, must be %s, %s or %s", name, FormatJSON, FormatLogfmt, FormatConsole)
+	}
+}
+
+// JSONFormatter renders entries as JSON objects, the production default
+type JSONFormatter struct{}
+
+// Format implements Formatter
+func (JSONFormatter) Format(entry LogEntry) ([]byte, error) {
+	return json.Marshal(entry)
+}
+
+// LogfmtFormatter renders entries as logfmt, e.g.
+// time=2024-01-01T00:00:00Z level=INFO component=tracker msg="Sink write completed" block_number=42
+type LogfmtFormatter struct{}
+
+// Format implements Formatter
+func (LogfmtFormatter) Format(entry LogEntry) ([]byte, error) {
+	var b strings.Builder
+	b.WriteString("time=" + entry.Timestamp)
+	b.WriteString(" level=" + string(entry.Level))
+	b.WriteString(" component=" + logfmtValue(entry.Component))
+	b.WriteString(" msg=" + logfmtValue(entry.Message))
+	for _, key := range sortedKeys(entry.Fields) {
+		b.WriteString(" " + key + "=" + logfmtValue(fieldString(entry.Fields[key])))
+	}
+	return []byte(b.String()), nil
+}
+
+// ANSI colors used by ConsoleFormatter
+const (
+	colorReset  = "\033[0m"
+	colorDim    = "\033[2m"
+	colorRed    = "\033[31m"
+	colorYellow = "\033[33m"
+	colorBlue   = "\033[34m"
+	colorCyan   = "\033[36m"
+)
+
+// levelColors maps levels to the color of their label
+var levelColors = map[LogLevel]string{
+	DEBUG: colorDim,
+	INFO:  colorBlue,
+	WARN:  colorYellow,
+	ERROR: colorRed,
+}
+
+// ConsoleFormatter renders colorized lines for reading in a terminal, e.g.
+// 12:00:00.000 INFO  [tracker] Sink write completed block_number=42
+type ConsoleFormatter struct {
+	NoColor bool // Omit ANSI codes, set from the conventional NO_COLOR variable
+}
+
+// Format implements Formatter
+func (f ConsoleFormatter) Format(entry LogEntry) ([]byte, error) {
+	var b strings.Builder
+
+	clock := entry.Timestamp
+	if t, err := time.Parse(time.RFC3339Nano, entry.Timestamp); err == nil {
+		clock = t.Local().Format("15:04:05.000")
+	}
+	b.WriteString(f.paint(colorDim, clock) + " ")
+	b.WriteString(f.paint(levelColors[entry.Level], fmt.Sprintf("%-5s", entry.Level)) + " ")
+	b.WriteString(f.paint(colorCyan, "["+entry.Component+"]") + " ")
+	b.WriteString(entry.Message)
+
+	for _, key := range sortedKeys(entry.Fields) {
+		value := logfmtValue(fieldString(entry.Fields[key]))
+		if key == "error" {
+			b.WriteString(" " + f.paint(colorRed, key+"="+value))
+			continue
+		}
+		b.WriteString(" " + f.paint(colorDim, key+"=") + value)
+	}
+	return []byte(b.String()), nil
+}
+
+// paint wraps text in an ANSI color unless NoColor is set
+func (f ConsoleFormatter) paint(color, text string) string {
+	if f.NoColor {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// sortedKeys returns the keys of fields in alphabetical order, for stable output
+func sortedKeys(fields map[string]interface{}) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// fieldString renders a field value. Strings, numbers and booleans are printed
+// as-is; maps, slices and other composite values as JSON.
+func fieldString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
+
+// logfmtValue quotes a value if it is empty or contains spaces, quotes, equals
+// signs or control characters
+func logfmtValue(value string) string {
+	if value == "" {
+		return `""`
+	}
+	for _, r := range value {
+		if r <= ' ' || r == '"' || r == '=' || r == 0x7f {
+			return strconv.Quote(value)
+		}
+	}
+	return value
+}
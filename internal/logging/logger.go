@@ -1,7 +1,6 @@
 package logging
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
 	"os"
@@ -59,13 +58,13 @@ func (l *Logger) log(level LogLevel, message string, fields map[string]interface
 		Fields:    fields,
 	}
 
-	jsonBytes, err := json.Marshal(entry)
+	line, err := formatter.Format(entry)
 	if err != nil {
-		log.Printf("Failed to marshal log entry: %v", err)
+		log.Printf("Failed to format log entry: %v", err)
 		return
 	}
 
-	fmt.Fprintln(os.Stdout, string(jsonBytes))
+	fmt.Fprintln(os.Stdout, string(line))
 }
 
 func (l *Logger) Debug(message string, fields ...map[string]interface{}) {
@@ -157,6 +156,40 @@ func (l *Logger) LogConnection(network string, chainID string, usdcAddress strin
 	})
 }
 
+func (l *Logger) LogRateLimit(message string, stats map[string]interface{}) {
+	fields := make(map[string]interface{}, len(stats)+1)
+	for k, v := range stats {
+		fields[k] = v
+	}
+	fields["event_type"] = "rpc_rate_limit"
+
+	l.Info(message, fields)
+}
+
+func (l *Logger) LogSinkStats(sinkName string, stats map[string]interface{}) {
+	fields := make(map[string]interface{}, len(stats)+2)
+	for k, v := range stats {
+		fields[k] = v
+	}
+	fields["sink_name"] = sinkName
+	fields["event_type"] = "sink_stats"
+
+	l.Info("Sink statistics", fields)
+}
+
+// LogContractUpgrade raises a high-priority alert for a proxy implementation change
+func (l *Logger) LogContractUpgrade(contract string, implementation string, txHash string, blockNumber uint64) {
+	l.Warn("Tracked contract upgraded to a new implementation", map[string]interface{}{
+		"contract":       contract,
+		"implementation": implementation,
+		"tx_hash":        txHash,
+		"block_number":   blockNumber,
+		"event_type":     "contract_upgrade",
+		"priority":       "high",
+		"alert":          true,
+	})
+}
+
 func (l *Logger) LogError(message string, err error, fields ...map[string]interface{}) {
 	l.Error(message, err, fields...)
 }
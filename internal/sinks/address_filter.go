@@ -0,0 +1,107 @@
+package sinks
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"usdc-event-tracker/internal/erc20"
+)
+
+// AddressFilterSink wraps another sink and only forwards Transfer and Approval logs
+// whose decoded from/to (or owner/spender) address is on a watchlist. Upgraded logs
+// concern the contract itself rather than any account and are always forwarded.
+// Events left without any matching logs are dropped.
+type AddressFilterSink struct {
+	sink      Sink
+	watchlist map[common.Address]bool
+}
+
+// NewAddressFilterSink wraps sink with a watchlist of hex addresses.
+// Addresses are matched case-insensitively.
+func NewAddressFilterSink(sink Sink, addresses []string) *AddressFilterSink {
+	watchlist := make(map[common.Address]bool, len(addresses))
+	for _, address := range addresses {
+		watchlist[common.HexToAddress(address)] = true
+	}
+	return &AddressFilterSink{
+		sink:      sink,
+		watchlist: watchlist,
+	}
+}
+
+// Name returns the name of the wrapped sink.
+func (a *AddressFilterSink) Name() string {
+	return a.sink.Name()
+}
+
+// Initialize prepares the wrapped sink for use.
+func (a *AddressFilterSink) Initialize(ctx context.Context) error {
+	return a.sink.Initialize(ctx)
+}
+
+// Write forwards only logs touching a watched address.
+func (a *AddressFilterSink) Write(ctx context.Context, events []Event) error {
+	filtered := make([]Event, 0, len(events))
+
+	for _, event := range events {
+		matched := event
+		matched.Logs = matched.Logs[:0:0]
+		for _, log := range event.Logs {
+			if a.matches(log.Topics) {
+				matched.Logs = append(matched.Logs, log)
+			}
+		}
+
+		if len(matched.Logs) > 0 {
+			filtered = append(filtered, matched)
+		}
+	}
+
+	return a.sink.Write(ctx, filtered)
+}
+
+// Close cleans up the wrapped sink.
+func (a *AddressFilterSink) Close() error {
+	return a.sink.Close()
+}
+
+// Flush flushes the wrapped sink if it buffers events.
+func (a *AddressFilterSink) Flush(ctx context.Context) error {
+	return flush(ctx, a.sink)
+}
+
+// WriteHeartbeat forwards a block heartbeat to the wrapped sink if it stores heartbeats.
+func (a *AddressFilterSink) WriteHeartbeat(ctx context.Context, heartbeat BlockHeartbeat) error {
+	return writeHeartbeat(ctx, a.sink, heartbeat)
+}
+
+// WriteBalances forwards balance snapshots to the wrapped sink if it stores them.
+func (a *AddressFilterSink) WriteBalances(ctx context.Context, snapshots []BalanceSnapshot) error {
+	return writeBalances(ctx, a.sink, snapshots)
+}
+
+// Stats returns the wrapped sink's metrics.
+func (a *AddressFilterSink) Stats() map[string]interface{} {
+	return stats(a.sink)
+}
+
+// matches reports whether a Transfer or Approval log involves a watched address,
+// or the log is a contract upgrade.
+func (a *AddressFilterSink) matches(topics []common.Hash) bool {
+	if len(topics) == 0 {
+		return false
+	}
+
+	event, found := erc20.GetEventBySignature(topics[0].Hex())
+	if found && event == erc20.Upgraded {
+		return true
+	}
+	// Only the ERC20 layout indexes both addresses, see erc20.DecodeLog
+	if !found || (event != erc20.Transfer && event != erc20.Approval) || len(topics) != 3 {
+		return false
+	}
+
+	// topics[1] and topics[2] are from/to for Transfer and owner/spender for Approval
+	return a.watchlist[erc20.TopicToAddress(topics[1])] ||
+		a.watchlist[erc20.TopicToAddress(topics[2])]
+}
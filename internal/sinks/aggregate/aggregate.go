@@ -0,0 +1,233 @@
+// Package aggregate implements a sink that reduces each block's events to a single
+// summary document and writes it to an inner sink
+package aggregate
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"usdc-event-tracker/internal/erc20"
+	"usdc-event-tracker/internal/logging"
+	"usdc-event-tracker/internal/sinks"
+)
+
+// Config holds aggregate sink configuration
+type Config struct {
+	Inner string // Registered name of the sink receiving the summaries
+}
+
+// Sink implements the sinks.Sink interface by rolling events up per block.
+// The inner sink must implement sinks.SummaryWriter.
+type Sink struct {
+	inner   sinks.Sink
+	summary sinks.SummaryWriter
+	logger  *logging.Logger
+
+	// Metrics
+	mu        sync.Mutex
+	events    int64
+	summaries int64
+}
+
+func init() {
+	sinks.Register("aggregate", func(opts sinks.Options) (sinks.Sink, error) {
+		config := NewConfig()
+		if config.Inner == "aggregate" {
+			return nil, fmt.Errorf("AGGREGATE_SINK cannot be aggregate")
+		}
+		inner, err := sinks.Create(config.Inner, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create inner sink: %w", err)
+		}
+		return New(inner)
+	})
+}
+
+// NewConfig creates a new aggregate sink configuration from environment variables
+func NewConfig() Config {
+	config := Config{
+		Inner: "console",
+	}
+
+	if inner := os.Getenv("AGGREGATE_SINK"); inner != "" {
+		config.Inner = inner
+	}
+
+	return config
+}
+
+// New creates an aggregate sink writing summaries to inner.
+// Returns an error if inner cannot store summaries.
+func New(inner sinks.Sink) (*Sink, error) {
+	summary, ok := inner.(sinks.SummaryWriter)
+	if !ok {
+		return nil, fmt.Errorf("sink %s cannot store block summaries", inner.Name())
+	}
+
+	return &Sink{
+		inner:   inner,
+		summary: summary,
+		logger:  logging.GetLogger("aggregate-sink"),
+	}, nil
+}
+
+// Name returns the sink name
+func (s *Sink) Name() string {
+	return "aggregate"
+}
+
+// Initialize prepares the inner sink for use
+func (s *Sink) Initialize(ctx context.Context) error {
+	if err := s.inner.Initialize(ctx); err != nil {
+		return err
+	}
+
+	s.logger.Info("Aggregate sink initialized", map[string]interface{}{
+		"inner_sink": s.inner.Name(),
+	})
+	return nil
+}
+
+// Write summarizes events per block and writes the summaries to the inner sink
+func (s *Sink) Write(ctx context.Context, events []sinks.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	var summaries []sinks.BlockSummary
+	start := 0
+	for i := 1; i <= len(events); i++ {
+		if i == len(events) || events[i].BlockNumber != events[start].BlockNumber {
+			summaries = append(summaries, Summarize(events[start:i]))
+			start = i
+		}
+	}
+
+	if err := s.summary.WriteSummaries(ctx, summaries); err != nil {
+		return fmt.Errorf("failed to write block summaries to %s: %w", s.inner.Name(), err)
+	}
+
+	s.mu.Lock()
+	s.events += int64(len(events))
+	s.summaries += int64(len(summaries))
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Flush flushes the inner sink if it buffers summaries
+func (s *Sink) Flush(ctx context.Context) error {
+	if f, ok := s.inner.(sinks.Flusher); ok {
+		return f.Flush(ctx)
+	}
+	return nil
+}
+
+// Stats implements sinks.StatReporter
+func (s *Sink) Stats() map[string]interface{} {
+	collected := make(map[string]interface{})
+	if r, ok := s.inner.(sinks.StatReporter); ok {
+		for key, value := range r.Stats() {
+			collected[key] = value
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	collected["aggregated_events"] = s.events
+	collected["block_summaries"] = s.summaries
+	return collected
+}
+
+// WriteHeartbeat forwards a block heartbeat to the inner sink if it stores heartbeats
+func (s *Sink) WriteHeartbeat(ctx context.Context, heartbeat sinks.BlockHeartbeat) error {
+	if w, ok := s.inner.(sinks.HeartbeatWriter); ok {
+		return w.WriteHeartbeat(ctx, heartbeat)
+	}
+	return nil
+}
+
+// WriteBalances forwards balance snapshots to the inner sink if it stores them
+func (s *Sink) WriteBalances(ctx context.Context, snapshots []sinks.BalanceSnapshot) error {
+	if w, ok := s.inner.(sinks.BalanceWriter); ok {
+		return w.WriteBalances(ctx, snapshots)
+	}
+	return nil
+}
+
+// Close cleans up the inner sink
+func (s *Sink) Close() error {
+	s.mu.Lock()
+	s.logger.Info("Closing aggregate sink", map[string]interface{}{
+		"aggregated_events": s.events,
+		"block_summaries":   s.summaries,
+	})
+	s.mu.Unlock()
+
+	return s.inner.Close()
+}
+
+// Summarize rolls up the events of a single block
+func Summarize(events []sinks.Event) sinks.BlockSummary {
+	summary := sinks.BlockSummary{
+		BlockNumber: events[0].BlockNumber,
+		Timestamp:   events[0].Timestamp().UTC().Format(time.RFC3339Nano),
+	}
+
+	senders := make(map[string]struct{})
+	receivers := make(map[string]struct{})
+	total := new(big.Int)
+	var smallest, largest *big.Int
+	var valued int64
+
+	for _, event := range events {
+		for _, log := range event.Logs {
+			decoded, found := event.Decode(log)
+			if !found {
+				continue
+			}
+
+			switch decoded.Event {
+			case erc20.Approval:
+				summary.ApprovalCount++
+			case erc20.Transfer:
+				summary.TransferCount++
+				senders[decoded.From] = struct{}{}
+				receivers[decoded.To] = struct{}{}
+				if decoded.Value == nil {
+					continue
+				}
+				valued++
+				total.Add(total, decoded.Value)
+				if smallest == nil || decoded.Value.Cmp(smallest) < 0 {
+					smallest = decoded.Value
+				}
+				if largest == nil || decoded.Value.Cmp(largest) > 0 {
+					largest = decoded.Value
+				}
+			}
+		}
+	}
+
+	summary.UniqueSenders = len(senders)
+	summary.UniqueReceivers = len(receivers)
+	summary.TotalValue = total.String()
+	summary.TotalUSDC = sinks.TrackedToken.Float(total)
+
+	if valued > 0 {
+		avg := new(big.Int).Quo(total, big.NewInt(valued))
+		summary.MinValue = smallest.String()
+		summary.MaxValue = largest.String()
+		summary.AvgValue = avg.String()
+		summary.MinUSDC = sinks.TrackedToken.Float(smallest)
+		summary.MaxUSDC = sinks.TrackedToken.Float(largest)
+		summary.AvgUSDC = sinks.TrackedToken.Float(avg)
+	}
+
+	return summary
+}
@@ -0,0 +1,18 @@
+// Package all registers every built-in sink. Import it for its side effects:
+//
+//	import _ "usdc-event-tracker/internal/sinks/all"
+package all
+
+import (
+	_ "usdc-event-tracker/internal/sinks/aggregate"
+	_ "usdc-event-tracker/internal/sinks/console"
+	_ "usdc-event-tracker/internal/sinks/elasticsearch"
+	_ "usdc-event-tracker/internal/sinks/fs"
+	_ "usdc-event-tracker/internal/sinks/grpc"
+	_ "usdc-event-tracker/internal/sinks/kafka"
+	_ "usdc-event-tracker/internal/sinks/mongodb"
+	_ "usdc-event-tracker/internal/sinks/netflow"
+	_ "usdc-event-tracker/internal/sinks/parquet"
+	_ "usdc-event-tracker/internal/sinks/s3"
+	_ "usdc-event-tracker/internal/sinks/sql"
+)
@@ -0,0 +1,30 @@
+package sinks
+
+import "context"
+
+// BalanceSnapshot is the token balance of a watched address at a block, read with
+// balanceOf every BALANCE_SNAPSHOT_INTERVAL blocks. Unlike events it is state, not
+// activity, so it can be reconciled against the sum of tracked transfers.
+type BalanceSnapshot struct {
+	BlockNumber uint64 `json:"block_number"`
+	Timestamp   string `json:"timestamp"` // Block time in RFC 3339, the ingestion time if unknown
+	Contract    string `json:"contract"`  // Token contract, checksummed
+	Variant     string `json:"variant,omitempty"`
+	Address     string `json:"address"` // Watched address, checksummed
+	Balance     string `json:"balance"` // Raw token amount as a decimal string
+}
+
+// BalanceWriter is implemented by sinks that store balance snapshots. Sinks that
+// do not implement it never see snapshots.
+type BalanceWriter interface {
+	// WriteBalances stores the snapshots taken at one block, called in ascending block order
+	WriteBalances(ctx context.Context, snapshots []BalanceSnapshot) error
+}
+
+// writeBalances hands snapshots to sink if it stores balance snapshots
+func writeBalances(ctx context.Context, sink Sink, snapshots []BalanceSnapshot) error {
+	if w, ok := sink.(BalanceWriter); ok {
+		return w.WriteBalances(ctx, snapshots)
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+package sinks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"usdc-event-tracker/internal/logging"
+)
+
+// BufferPolicy selects what a sink buffer does when it is full
+type BufferPolicy string
+
+const (
+	BufferBlock      BufferPolicy = "block"       // Wait for room, so a stalled sink eventually slows the tracker down
+	BufferDropOldest BufferPolicy = "drop-oldest" // Discard the oldest buffered write to make room
+	BufferDeadLetter BufferPolicy = "dead-letter" // Append the new events to the dead-letter file instead of buffering them
+)
+
+// bufferItem is one Write call's events, a block heartbeat when heartbeat is set,
+// or a block's balance snapshots when balances is set
+type bufferItem struct {
+	ctx       context.Context
+	events    []Event
+	heartbeat *BlockHeartbeat
+	balances  []BalanceSnapshot
+}
+
+// flushRequest asks the delivery goroutine to flush the sink once everything
+// buffered before it was written
+type flushRequest struct {
+	ctx     context.Context
+	flushed chan error
+}
+
+// sinkBuffer decouples a single sink from the tracker: writes are buffered in a
+// bounded channel and delivered in arrival order by a dedicated goroutine, so a
+// slow sink only lags behind, within size writes, instead of holding up the
+// others. What happens to writes that do not fit is decided by the policy.
+type sinkBuffer struct {
+	sink            Sink
+	policy          BufferPolicy
+	deadLetter      *DeadLetterFile
+	flushEveryWrite bool
+	logger          *logging.Logger
+
+	in      chan bufferItem
+	flushes chan flushRequest
+	done    chan struct{}
+
+	// Metrics
+	mu           sync.Mutex
+	dropped      int64
+	deadLettered int64
+	writeErrors  int64
+
+	// First failed event write, reported by every later flush
+	failed error
+}
+
+// newSinkBuffer starts the delivery goroutine for sink. deadLetter may be nil.
+func newSinkBuffer(sink Sink, size int, policy BufferPolicy, deadLetter *DeadLetterFile, flushEveryWrite bool) *sinkBuffer {
+	b := &sinkBuffer{
+		sink:            sink,
+		policy:          policy,
+		deadLetter:      deadLetter,
+		flushEveryWrite: flushEveryWrite,
+		logger:          logging.GetLogger("sink-manager"),
+		in:              make(chan bufferItem, size),
+		flushes:         make(chan flushRequest),
+		done:            make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// enqueue buffers events, applying the overflow policy if the buffer is full
+func (b *sinkBuffer) enqueue(ctx context.Context, events []Event) error {
+	item := bufferItem{ctx: ctx, events: events}
+	for {
+		select {
+		case b.in <- item:
+			return nil
+		default:
+		}
+
+		switch b.policy {
+		case BufferDropOldest:
+			select {
+			case oldest := <-b.in:
+				if oldest.heartbeat == nil && oldest.balances == nil {
+					b.overflow(oldest.events, "dropped oldest buffered write")
+				}
+			default:
+				// The delivery goroutine made room in the meantime
+			}
+		case BufferDeadLetter:
+			b.overflow(events, "buffer full")
+			return nil
+		default:
+			select {
+			case b.in <- item:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// enqueueHeartbeat buffers a block heartbeat. Heartbeats are expendable, so one
+// that does not fit is dropped regardless of the overflow policy.
+func (b *sinkBuffer) enqueueHeartbeat(ctx context.Context, heartbeat BlockHeartbeat) {
+	select {
+	case b.in <- bufferItem{ctx: ctx, heartbeat: &heartbeat}:
+	default:
+	}
+}
+
+// enqueueBalances buffers a block's balance snapshots, waiting for room regardless
+// of the overflow policy. Snapshots are rare and have no dead-letter format.
+func (b *sinkBuffer) enqueueBalances(ctx context.Context, snapshots []BalanceSnapshot) error {
+	select {
+	case b.in <- bufferItem{ctx: ctx, balances: snapshots}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// flush writes every buffered write, then flushes the sink
+func (b *sinkBuffer) flush(ctx context.Context) error {
+	flushed := make(chan error, 1)
+	select {
+	case b.flushes <- flushRequest{ctx: ctx, flushed: flushed}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-flushed:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close writes every buffered write and stops the delivery goroutine
+func (b *sinkBuffer) close() {
+	close(b.in)
+	<-b.done
+}
+
+// stats returns the buffer's metrics
+func (b *sinkBuffer) stats() map[string]interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return map[string]interface{}{
+		"buffer_pending_writes":       len(b.in),
+		"buffer_dropped_events":       b.dropped,
+		"buffer_dead_lettered_events": b.deadLettered,
+		"buffer_write_errors":         b.writeErrors,
+	}
+}
+
+// run delivers buffered writes until the buffer is closed
+func (b *sinkBuffer) run() {
+	defer close(b.done)
+
+	for {
+		select {
+		case item, ok := <-b.in:
+			if !ok {
+				return
+			}
+			b.write(item)
+		case req := <-b.flushes:
+			if !b.drain() {
+				req.flushed <- nil
+				return
+			}
+			req.flushed <- errors.Join(b.failure(), flush(context.WithoutCancel(req.ctx), b.sink))
+		}
+	}
+}
+
+// drain writes every currently buffered write. It returns false if the buffer
+// was closed.
+func (b *sinkBuffer) drain() bool {
+	for {
+		select {
+		case item, ok := <-b.in:
+			if !ok {
+				return false
+			}
+			b.write(item)
+		default:
+			return true
+		}
+	}
+}
+
+// write delivers a buffered write to the sink. Errors are counted but do not
+// stop delivery of later writes. A failed event write is also recorded, so
+// flushes fail from then on and its blocks are not checkpointed.
+func (b *sinkBuffer) write(item bufferItem) {
+	// Buffered writes may outlive the caller's context, e.g. when draining on shutdown
+	ctx := context.WithoutCancel(item.ctx)
+
+	if item.heartbeat != nil || item.balances != nil {
+		var err error
+		if item.heartbeat != nil {
+			err = writeHeartbeat(ctx, b.sink, *item.heartbeat)
+		} else {
+			err = writeBalances(ctx, b.sink, item.balances)
+		}
+		if err != nil {
+			b.mu.Lock()
+			b.writeErrors++
+			b.mu.Unlock()
+		}
+		return
+	}
+
+	err := safeWrite(ctx, b.sink, item.events)
+	if err == nil && b.flushEveryWrite {
+		err = flush(ctx, b.sink)
+	}
+	if err != nil {
+		b.logger.Error("Sink write failed", err, map[string]interface{}{
+			"sink_name":   b.sink.Name(),
+			"event_count": len(item.events),
+		})
+		b.mu.Lock()
+		b.writeErrors++
+		if b.failed == nil {
+			b.failed = fmt.Errorf("%s: %w", b.sink.Name(), err)
+		}
+		b.mu.Unlock()
+	}
+}
+
+// failure returns the first failed event write, if any
+func (b *sinkBuffer) failure() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failed
+}
+
+// overflow logs events that did not fit in the buffer and, under the
+// dead-letter policy, hands them to the dead-letter file
+func (b *sinkBuffer) overflow(events []Event, reason string) {
+	fields := map[string]interface{}{
+		"sink_name":   b.sink.Name(),
+		"reason":      reason,
+		"policy":      string(b.policy),
+		"buffer_size": cap(b.in),
+		"event_count": len(events),
+	}
+	if len(events) > 0 {
+		fields["first_block"] = events[0].BlockNumber
+		fields["last_block"] = events[len(events)-1].BlockNumber
+	}
+
+	if b.policy != BufferDeadLetter {
+		b.mu.Lock()
+		b.dropped += int64(len(events))
+		b.mu.Unlock()
+		b.logger.Warn("Sink buffer full, events dropped", fields)
+		return
+	}
+
+	b.mu.Lock()
+	b.deadLettered += int64(len(events))
+	b.mu.Unlock()
+	b.logger.Warn("Sink buffer full, events dead-lettered", fields)
+
+	if err := b.deadLetter.Add(b.sink.Name(), reason, events); err != nil {
+		b.logger.Error("Failed to write dead-lettered events", err, map[string]interface{}{
+			"sink_name":   b.sink.Name(),
+			"event_count": len(events),
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package sinks
+
+// Chain wraps base with decorators such as NewDedupeSink or NewAddressFilterSink.
+// Decorators are listed outermost first, in the order events flow through them,
+// so Chain(base, filter, dedupe) returns filter(dedupe(base)).
+//
+// Cheap filters belong in front so later decorators see fewer events. The
+// recommended order is confirmation → event type filter → address filter →
+// sampling → dedupe → base: confirmation holds events back before anything
+// else looks at them, and dedupe sits next to the sink so it only remembers
+// logs the sink actually received.
+func Chain(base Sink, decorators ...func(Sink) Sink) Sink {
+	sink := base
+	for i := len(decorators) - 1; i >= 0; i-- {
+		sink = decorators[i](sink)
+	}
+	return sink
+}
@@ -0,0 +1,116 @@
+package sinks
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"usdc-event-tracker/internal/erc20"
+)
+
+// erc20Log returns a log of event with two indexed addresses and a value, as
+// emitted by Transfer and Approval
+func erc20Log(event erc20.Event, txHash common.Hash, index uint, from, to common.Address, value int64) *types.Log {
+	return &types.Log{
+		Topics: []common.Hash{
+			common.HexToHash(erc20.EventSignatures[event]),
+			common.BytesToHash(from.Bytes()),
+			common.BytesToHash(to.Bytes()),
+		},
+		Data:   common.BigToHash(big.NewInt(value)).Bytes(),
+		TxHash: txHash,
+		Index:  index,
+	}
+}
+
+// logEvent returns an event carrying logs, decoded like the tracker does
+func logEvent(blockNumber uint64, txHash common.Hash, logs ...*types.Log) Event {
+	return Event{
+		BlockNumber: blockNumber,
+		Receipt:     &types.Receipt{TxHash: txHash, BlockNumber: new(big.Int).SetUint64(blockNumber)},
+		Logs:        logs,
+		Decoded:     DecodeLogs(logs),
+	}
+}
+
+// recordingSink records the order in which decorators saw a write
+type recordingSink struct {
+	Sink
+	name  string
+	trace *[]string
+}
+
+func (r *recordingSink) Write(ctx context.Context, events []Event) error {
+	*r.trace = append(*r.trace, r.name)
+	return r.Sink.Write(ctx, events)
+}
+
+func TestChainAppliesDecoratorsOutermostFirst(t *testing.T) {
+	var trace []string
+	record := func(name string) func(Sink) Sink {
+		return func(s Sink) Sink { return &recordingSink{Sink: s, name: name, trace: &trace} }
+	}
+
+	sink := Chain(NewMemorySink(), record("first"), record("second"), record("third"))
+	if err := sink.Write(context.Background(), nil); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	want := []string{"first", "second", "third"}
+	if len(trace) != len(want) {
+		t.Fatalf("trace = %v, want %v", trace, want)
+	}
+	for i := range want {
+		if trace[i] != want[i] {
+			t.Fatalf("trace = %v, want %v", trace, want)
+		}
+	}
+}
+
+func TestChainWithThreeDecorators(t *testing.T) {
+	alice := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	bob := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	carol := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	tx1 := common.HexToHash("0x01")
+	tx2 := common.HexToHash("0x02")
+
+	memory := NewMemorySink()
+	sink := Chain(memory,
+		func(s Sink) Sink { return NewEventTypeFilterSink(s, []erc20.Event{erc20.Transfer}) },
+		func(s Sink) Sink { return NewAddressFilterSink(s, []string{alice.Hex()}) },
+		func(s Sink) Sink { return NewDedupeSink(s, 100) },
+	)
+	if _, ok := sink.(*EventTypeFilterSink); !ok {
+		t.Fatalf("Chain returned %T, want the first decorator outermost", sink)
+	}
+
+	ctx := context.Background()
+	transfer := erc20Log(erc20.Transfer, tx1, 0, alice, bob, 100)
+	approval := erc20Log(erc20.Approval, tx1, 1, alice, bob, 200)
+	unwatched := erc20Log(erc20.Transfer, tx2, 2, bob, carol, 300)
+	if err := sink.Write(ctx, []Event{
+		logEvent(10, tx1, transfer, approval),
+		logEvent(10, tx2, unwatched),
+	}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	// The same block again, as after a restart
+	if err := sink.Write(ctx, []Event{logEvent(10, tx1, transfer, approval)}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	events := memory.Events()
+	if len(events) != 1 || len(events[0].Logs) != 1 || events[0].Logs[0] != transfer {
+		t.Fatalf("sink got %d events, want only the watched Transfer once", len(events))
+	}
+
+	collected := stats(sink)
+	if collected["dedupe_dropped_logs"] != int64(1) {
+		t.Errorf("dedupe_dropped_logs = %v, want 1", collected["dedupe_dropped_logs"])
+	}
+	if err := sink.Close(); err != nil || !memory.Closed() {
+		t.Errorf("Close = %v, closed = %v; want the base sink closed", err, memory.Closed())
+	}
+}
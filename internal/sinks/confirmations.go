@@ -0,0 +1,149 @@
+package sinks
+
+import (
+	"context"
+	"sync"
+
+	"usdc-event-tracker/internal/logging"
+)
+
+// ConfirmationSink wraps another sink and holds events back until their block is
+// at least depth blocks below the chain head, so immutable archives only receive
+// blocks unlikely to be reorganized. Held events are released in block order when
+// a later Write or a Flush finds them deep enough.
+//
+// The head is the larger of what the head function reports and the highest block
+// written so far. Events still held on Close are dropped; with a cursor, the
+// tracker keeps its checkpoint far enough behind that they are delivered again
+// after a restart.
+type ConfirmationSink struct {
+	sink   Sink
+	depth  uint64
+	head   func() uint64
+	logger *logging.Logger
+
+	mu       sync.Mutex
+	held     []Event
+	highest  uint64
+	released int64
+}
+
+// NewConfirmationSink wraps sink so it only receives blocks at least depth blocks
+// deep. head reports the latest known chain head and may be nil.
+func NewConfirmationSink(sink Sink, depth uint64, head func() uint64) *ConfirmationSink {
+	return &ConfirmationSink{
+		sink:   sink,
+		depth:  depth,
+		head:   head,
+		logger: logging.GetLogger("sink-manager"),
+	}
+}
+
+// Name returns the name of the wrapped sink.
+func (c *ConfirmationSink) Name() string {
+	return c.sink.Name()
+}
+
+// Initialize prepares the wrapped sink for use.
+func (c *ConfirmationSink) Initialize(ctx context.Context) error {
+	return c.sink.Initialize(ctx)
+}
+
+// Write holds events and forwards every held event that is now confirmed.
+func (c *ConfirmationSink) Write(ctx context.Context, events []Event) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, event := range events {
+		c.highest = max(c.highest, event.BlockNumber)
+	}
+	c.held = append(c.held, events...)
+	return c.release(ctx)
+}
+
+// Flush forwards confirmed events, then flushes the wrapped sink if it buffers events.
+func (c *ConfirmationSink) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	err := c.release(ctx)
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return flush(ctx, c.sink)
+}
+
+// WriteHeartbeat forwards a block heartbeat to the wrapped sink right away.
+// Heartbeats carry no events, so there is nothing to confirm.
+func (c *ConfirmationSink) WriteHeartbeat(ctx context.Context, heartbeat BlockHeartbeat) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return writeHeartbeat(ctx, c.sink, heartbeat)
+}
+
+// WriteBalances forwards balance snapshots to the wrapped sink right away, ahead
+// of the block's held events. A snapshot of a block later reorganized away is
+// not retracted.
+func (c *ConfirmationSink) WriteBalances(ctx context.Context, snapshots []BalanceSnapshot) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return writeBalances(ctx, c.sink, snapshots)
+}
+
+// Close drops unconfirmed events and cleans up the wrapped sink.
+func (c *ConfirmationSink) Close() error {
+	c.mu.Lock()
+	if len(c.held) > 0 {
+		c.logger.Warn("Dropping unconfirmed events on close", map[string]interface{}{
+			"sink_name":     c.sink.Name(),
+			"event_count":   len(c.held),
+			"first_block":   c.held[0].BlockNumber,
+			"confirmations": c.depth,
+		})
+		c.held = nil
+	}
+	c.mu.Unlock()
+
+	return c.sink.Close()
+}
+
+// Stats returns the wrapped sink's metrics plus the number of held events.
+func (c *ConfirmationSink) Stats() map[string]interface{} {
+	collected := make(map[string]interface{})
+	for key, value := range stats(c.sink) {
+		collected[key] = value
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	collected["unconfirmed_events"] = len(c.held)
+	collected["confirmed_events"] = c.released
+	return collected
+}
+
+// release writes the held events whose block is deep enough. Events are held in
+// block order, so they are confirmed from the front. The caller must hold mu.
+func (c *ConfirmationSink) release(ctx context.Context) error {
+	head := c.highest
+	if c.head != nil {
+		head = max(head, c.head())
+	}
+	if head < c.depth {
+		return nil
+	}
+
+	confirmed := 0
+	for confirmed < len(c.held) && c.held[confirmed].BlockNumber <= head-c.depth {
+		confirmed++
+	}
+	if confirmed == 0 {
+		return nil
+	}
+
+	if err := c.sink.Write(ctx, c.held[:confirmed]); err != nil {
+		return err
+	}
+	c.released += int64(confirmed)
+	c.held = append(c.held[:0:0], c.held[confirmed:]...)
+	return nil
+}
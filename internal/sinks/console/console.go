@@ -4,22 +4,97 @@ package console
 import (
 	"context"
 	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 	
 	"github.com/ethereum/go-ethereum/core/types"
 	"usdc-event-tracker/internal/erc20"
 	"usdc-event-tracker/internal/sinks"
 )
 
+// Config holds console sink configuration
+type Config struct {
+	USDCAddress     string        // USDC contract address being tracked
+	SummaryInterval time.Duration // Print a rollup of activity this often, 0 disables
+	Compact         bool          // Print only the rollup, not every event
+	Plain           bool          // Print without emoji, for CI logs and non-UTF-8 terminals
+}
+
 // ConsoleSink implements the Sink interface for console output.
 // It formats and displays USDC events in a human-readable format.
 type ConsoleSink struct {
-	usdcAddress string
+	config Config
+
+	// Activity since the last summary; Write is called once per block
+	mu            sync.Mutex
+	blocks        int64
+	transfers     int64
+	approvals     int64
+	transferTotal *big.Int
+	windowStart   time.Time
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func init() {
+	sinks.Register("console", func(opts sinks.Options) (sinks.Sink, error) {
+		config := NewConfig()
+		config.USDCAddress = opts.USDCAddress
+		return New(config), nil
+	})
+}
+
+// NewConfig creates a console sink configuration from environment variables
+func NewConfig() Config {
+	config := Config{}
+
+	if interval := os.Getenv("CONSOLE_SUMMARY_INTERVAL"); interval != "" {
+		if d, err := time.ParseDuration(interval); err == nil && d > 0 {
+			config.SummaryInterval = d
+		}
+	}
+
+	if compact := os.Getenv("CONSOLE_COMPACT"); compact != "" {
+		config.Compact = strings.ToLower(compact) == "true"
+	}
+
+	// Plain output by default when stdout is not a terminal or NO_COLOR is set,
+	// CONSOLE_NO_EMOJI overrides both
+	config.Plain = os.Getenv("NO_COLOR") != "" || !isTerminal(os.Stdout)
+	if noEmoji := os.Getenv("CONSOLE_NO_EMOJI"); noEmoji != "" {
+		config.Plain = strings.ToLower(noEmoji) == "true"
+	}
+
+	// Compact output without a rollup would print nothing
+	if config.Compact && config.SummaryInterval == 0 {
+		config.SummaryInterval = 30 * time.Second
+	}
+
+	return config
+}
+
+// isTerminal reports whether f is a character device such as a TTY, rather
+// than a pipe or file
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
 }
 
-// New creates a new console sink configured for the specified USDC address.
-func New(usdcAddress string) *ConsoleSink {
+// New creates a new console sink with the given configuration.
+func New(config Config) *ConsoleSink {
 	return &ConsoleSink{
-		usdcAddress: usdcAddress,
+		config:        config,
+		transferTotal: new(big.Int),
+		windowStart:   time.Now(),
+		done:          make(chan struct{}),
 	}
 }
 
@@ -30,20 +105,32 @@ func (c *ConsoleSink) Name() string {
 
 // Initialize prepares the console sink for use.
 // For console output, this simply prints an initialization message.
-func (c *ConsoleSink) Initialize() error {
-	fmt.Println("📊 Console sink initialized")
+func (c *ConsoleSink) Initialize(ctx context.Context) error {
+	fmt.Println(c.icon("📊", "Console sink initialized"))
+
+	if c.config.SummaryInterval > 0 {
+		c.wg.Add(1)
+		go c.summaryLoop()
+	}
 	return nil
 }
 
 // Write formats and displays events to the console.
 // Each event is displayed with transaction details and USDC-specific information.
 func (c *ConsoleSink) Write(ctx context.Context, events []sinks.Event) error {
+	if c.config.SummaryInterval > 0 {
+		c.record(events)
+	}
+	if c.config.Compact {
+		return nil
+	}
+
 	if len(events) == 0 {
-		fmt.Printf("   No USDC transactions found\n\n")
+		fmt.Printf("   No %s transactions found\n\n", sinks.TrackedToken.Name())
 		return nil
 	}
 
-	fmt.Printf("\n   💰 USDC Transactions (%d found):\n", len(events))
+	fmt.Printf("\n   %s (%d found):\n", c.icon("💰", sinks.TrackedToken.Name()+" Transactions"), len(events))
 	for i, event := range events {
 		c.displayTransaction(i+1, event)
 	}
@@ -52,11 +139,117 @@ func (c *ConsoleSink) Write(ctx context.Context, events []sinks.Event) error {
 	return nil
 }
 
-// Close performs cleanup. This is a no-op for console output.
+// WriteSummaries prints one line per block summary, used as the aggregate sink's inner sink.
+func (c *ConsoleSink) WriteSummaries(ctx context.Context, summaries []sinks.BlockSummary) error {
+	for _, summary := range summaries {
+		fmt.Printf("%s: %d transfers totaling %.2f %s (min %.2f, avg %.2f, max %.2f), %d senders, %d receivers, %d approvals\n",
+			c.icon("📦", fmt.Sprintf("Block #%d", summary.BlockNumber)), summary.TransferCount, summary.TotalUSDC, sinks.TrackedToken.Name(), summary.MinUSDC, summary.AvgUSDC, summary.MaxUSDC,
+			summary.UniqueSenders, summary.UniqueReceivers, summary.ApprovalCount)
+	}
+	return nil
+}
+
+// WriteHeartbeat prints one line for a processed block without tracked activity,
+// written with EMIT_EMPTY_BLOCKS. The block also counts towards the rollup.
+func (c *ConsoleSink) WriteHeartbeat(ctx context.Context, heartbeat sinks.BlockHeartbeat) error {
+	if c.config.SummaryInterval > 0 {
+		c.record(nil)
+	}
+	if c.config.Compact {
+		return nil
+	}
+
+	fmt.Printf("%s: %d transactions, no %s activity\n",
+		c.icon("💓", fmt.Sprintf("Block #%d", heartbeat.BlockNumber)), heartbeat.TxCount, sinks.TrackedToken.Name())
+	return nil
+}
+
+// WriteBalances prints the balance snapshots taken at a block, one line per
+// watched address, with amounts formatted like transfers.
+func (c *ConsoleSink) WriteBalances(ctx context.Context, snapshots []sinks.BalanceSnapshot) error {
+	if c.config.Compact {
+		return nil
+	}
+
+	fmt.Printf("%s\n", c.icon("💰", fmt.Sprintf("Balances at block #%d", snapshots[0].BlockNumber)))
+	for _, snapshot := range snapshots {
+		balance, ok := new(big.Int).SetString(snapshot.Balance, 10)
+		if !ok {
+			continue
+		}
+		fmt.Printf("       %s: %s\n", snapshot.Address, sinks.TrackedToken.Format(balance))
+	}
+	return nil
+}
+
+// Close stops the summary ticker and prints a final rollup if enabled.
 func (c *ConsoleSink) Close() error {
+	if c.config.SummaryInterval > 0 {
+		close(c.done)
+		c.wg.Wait()
+		c.printSummary()
+	}
 	return nil
 }
 
+// record adds a block's events to the current summary window
+func (c *ConsoleSink) record(events []sinks.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.blocks++
+	for _, event := range events {
+		for _, log := range event.Logs {
+			decoded, found := event.Decode(log)
+			if !found {
+				continue
+			}
+			switch decoded.Event {
+			case erc20.Transfer:
+				c.transfers++
+				if decoded.Value != nil {
+					c.transferTotal.Add(c.transferTotal, decoded.Value)
+				}
+			case erc20.Approval:
+				c.approvals++
+			}
+		}
+	}
+}
+
+// summaryLoop prints a rollup every SummaryInterval until Close
+func (c *ConsoleSink) summaryLoop() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.config.SummaryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.printSummary()
+		}
+	}
+}
+
+// printSummary prints the activity since the previous summary and resets the window
+func (c *ConsoleSink) printSummary() {
+	c.mu.Lock()
+	elapsed := time.Since(c.windowStart).Round(time.Second)
+	blocks, transfers, approvals := c.blocks, c.transfers, c.approvals
+	total := c.transferTotal
+
+	c.blocks, c.transfers, c.approvals = 0, 0, 0
+	c.transferTotal = new(big.Int)
+	c.windowStart = time.Now()
+	c.mu.Unlock()
+
+	fmt.Printf("%s: %d blocks, %d %s transfers totaling %s, %d approvals\n",
+		c.icon("📈", "Last "+elapsed.String()), blocks, transfers, sinks.TrackedToken.Name(), sinks.TrackedToken.Format(total), approvals)
+}
+
 // displayTransaction formats and displays a single transaction
 func (c *ConsoleSink) displayTransaction(index int, event sinks.Event) {
 	fmt.Printf("   [%d] Transaction Details:\n", index)
@@ -64,45 +257,88 @@ func (c *ConsoleSink) displayTransaction(index int, event sinks.Event) {
 	fmt.Printf("       Hash: %s\n", event.Receipt.TxHash.Hex())
 	fmt.Printf("       Status: %s\n", c.getStatusText(event.Receipt.Status))
 	fmt.Printf("       Gas Used: %d\n", event.Receipt.GasUsed)
+	if fee := sinks.TxFee(event.Receipt); fee != nil {
+		fmt.Printf("       Tx Fee: %s wei\n", fee)
+	}
+	if event.Variant != "" {
+		fmt.Printf("       Variant: %s\n", event.Variant)
+	}
 	
 	// Display USDC-specific events
 	for _, log := range event.Logs {
-		c.displayUSDCEvent(log)
+		c.displayUSDCEvent(event, log)
 	}
 }
 
 // getStatusText returns a formatted status string
 func (c *ConsoleSink) getStatusText(status uint64) string {
 	if status == 1 {
-		return "✅ Success"
+		return c.icon("✅", "Success")
+	}
+	return c.icon("❌", "Failed")
+}
+
+// icon prefixes text with an emoji, or returns text alone for plain output
+func (c *ConsoleSink) icon(emoji, text string) string {
+	if c.config.Plain {
+		return text
 	}
-	return "❌ Failed"
+	return emoji + " " + text
 }
 
-// displayUSDCEvent formats and displays a USDC event
-func (c *ConsoleSink) displayUSDCEvent(log *types.Log) {
+// formatAllowance formats an approved allowance, showing max-uint256 approvals as unlimited
+func (c *ConsoleSink) formatAllowance(value *big.Int) string {
+	if erc20.IsInfiniteApproval(value) {
+		return erc20.InfiniteApprovalValue
+	}
+	return sinks.TrackedToken.Format(value)
+}
+
+// displayUSDCEvent formats and displays a USDC event, one of event's logs
+func (c *ConsoleSink) displayUSDCEvent(event sinks.Event, log *types.Log) {
 	if len(log.Topics) == 0 {
+		fmt.Printf("       Event: Anonymous (no topics)\n")
 		return
 	}
 
-	event, found := erc20.GetEventBySignature(log.Topics[0].Hex())
+	decoded, found := event.Decode(log)
 	if !found {
 		fmt.Printf("       Event: Unknown (Topic: %s)\n", log.Topics[0].Hex()[:10]+"...")
 		return
 	}
 
-	fmt.Printf("       Event: %s\n", event)
+	fmt.Printf("       Event: %s\n", decoded.Event)
 	
-	switch event {
+	switch decoded.Event {
 	case erc20.Transfer:
-		if len(log.Topics) >= 3 {
-			fmt.Printf("         From: %s\n", log.Topics[1].Hex())
-			fmt.Printf("         To: %s\n", log.Topics[2].Hex())
+		if decoded.From != "" {
+			fmt.Printf("         From: %s\n", decoded.From)
+			fmt.Printf("         To: %s\n", decoded.To)
+		}
+		if decoded.Value != nil {
+			fmt.Printf("         Value: %s\n", sinks.TrackedToken.Format(decoded.Value))
 		}
 	case erc20.Approval:
-		if len(log.Topics) >= 3 {
-			fmt.Printf("         Owner: %s\n", log.Topics[1].Hex())
-			fmt.Printf("         Spender: %s\n", log.Topics[2].Hex())
+		if decoded.Owner != "" {
+			fmt.Printf("         Owner: %s\n", decoded.Owner)
+			fmt.Printf("         Spender: %s\n", decoded.Spender)
+		}
+		if decoded.Value != nil {
+			fmt.Printf("         Allowance: %s\n", c.formatAllowance(decoded.Value))
+		}
+	case erc20.Upgraded:
+		fmt.Printf("         %s\n", c.icon("🚨", "Contract "+log.Address.Hex()+" upgraded"))
+		if decoded.Implementation != "" {
+			fmt.Printf("         New Implementation: %s\n", decoded.Implementation)
+		}
+	default:
+		names := make([]string, 0, len(decoded.Fields))
+		for name := range decoded.Fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("         %s: %v\n", name, decoded.Fields[name])
 		}
 	}
 }
\ No newline at end of file
@@ -0,0 +1,29 @@
+package sinks
+
+import (
+	"github.com/ethereum/go-ethereum/core/types"
+	"usdc-event-tracker/internal/erc20"
+)
+
+// DecodeLogs decodes every recognized log once for Event.Decoded, so sinks share
+// the result instead of each decoding the same logs again
+func DecodeLogs(logs []*types.Log) map[*types.Log]erc20.Decoded {
+	decoded := make(map[*types.Log]erc20.Decoded, len(logs))
+	for _, log := range logs {
+		if d, found := erc20.DecodeLog(log.Topics, log.Data); found {
+			decoded[log] = d
+		}
+	}
+	return decoded
+}
+
+// Decode returns the event type and parameters of log, one of e.Logs, with the
+// semantics of erc20.DecodeLog. They are taken from e.Decoded if the event was
+// built with it, and decoded on the spot otherwise.
+func (e Event) Decode(log *types.Log) (erc20.Decoded, bool) {
+	if e.Decoded != nil {
+		decoded, found := e.Decoded[log]
+		return decoded, found
+	}
+	return erc20.DecodeLog(log.Topics, log.Data)
+}
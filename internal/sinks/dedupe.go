@@ -0,0 +1,172 @@
+package sinks
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+)
+
+// DedupeSink wraps another sink and drops logs that have already been written to it.
+// Seen logs are tracked by (txHash, logIndex) in a fixed-size LRU cache, so re-processing
+// a block after a restart or catch-up does not produce duplicates in sinks without
+// unique constraints (console, filesystem, kafka).
+type DedupeSink struct {
+	sink Sink
+
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	seen     map[string]*list.Element
+
+	dropped int64
+}
+
+// NewDedupeSink wraps sink with an LRU of the given size.
+// A non-positive size falls back to 10000 entries.
+func NewDedupeSink(sink Sink, size int) *DedupeSink {
+	if size <= 0 {
+		size = 10000
+	}
+	return &DedupeSink{
+		sink:     sink,
+		capacity: size,
+		order:    list.New(),
+		seen:     make(map[string]*list.Element, size),
+	}
+}
+
+// Name returns the name of the wrapped sink.
+func (d *DedupeSink) Name() string {
+	return d.sink.Name()
+}
+
+// Initialize prepares the wrapped sink for use.
+func (d *DedupeSink) Initialize(ctx context.Context) error {
+	return d.sink.Initialize(ctx)
+}
+
+// Write forwards only logs that have not been seen before.
+// Events left without any unseen logs are dropped entirely. Keys are recorded
+// only after the wrapped sink accepts the write, so failed writes can be retried.
+func (d *DedupeSink) Write(ctx context.Context, events []Event) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	filtered := make([]Event, 0, len(events))
+	keys := make([]string, 0, len(events))
+
+	for _, event := range events {
+		if len(event.Logs) == 0 {
+			key := dedupeKey(event, -1)
+			if d.contains(key) {
+				d.dropped++
+				continue
+			}
+			filtered = append(filtered, event)
+			keys = append(keys, key)
+			continue
+		}
+
+		fresh := event
+		fresh.Logs = fresh.Logs[:0:0]
+		for _, log := range event.Logs {
+			key := dedupeKey(event, int64(log.Index))
+			if d.contains(key) {
+				d.dropped++
+				continue
+			}
+			fresh.Logs = append(fresh.Logs, log)
+			keys = append(keys, key)
+		}
+
+		if len(fresh.Logs) > 0 {
+			filtered = append(filtered, fresh)
+		}
+	}
+
+	if err := d.sink.Write(ctx, filtered); err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		d.add(key)
+	}
+
+	return nil
+}
+
+// Close cleans up the wrapped sink.
+func (d *DedupeSink) Close() error {
+	return d.sink.Close()
+}
+
+// Flush flushes the wrapped sink if it buffers events.
+func (d *DedupeSink) Flush(ctx context.Context) error {
+	return flush(ctx, d.sink)
+}
+
+// WriteHeartbeat forwards a block heartbeat to the wrapped sink if it stores heartbeats.
+func (d *DedupeSink) WriteHeartbeat(ctx context.Context, heartbeat BlockHeartbeat) error {
+	return writeHeartbeat(ctx, d.sink, heartbeat)
+}
+
+// WriteBalances forwards balance snapshots to the wrapped sink if it stores them.
+func (d *DedupeSink) WriteBalances(ctx context.Context, snapshots []BalanceSnapshot) error {
+	return writeBalances(ctx, d.sink, snapshots)
+}
+
+// Stats returns the wrapped sink's metrics plus the deduplication counters.
+func (d *DedupeSink) Stats() map[string]interface{} {
+	result := make(map[string]interface{})
+	for k, v := range stats(d.sink) {
+		result[k] = v
+	}
+
+	d.mu.Lock()
+	result["dedupe_dropped_logs"] = d.dropped
+	result["dedupe_cache_entries"] = len(d.seen)
+	d.mu.Unlock()
+
+	return result
+}
+
+// Dropped returns the number of logs dropped as duplicates.
+func (d *DedupeSink) Dropped() int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.dropped
+}
+
+// contains reports whether key was seen and marks it as recently used.
+func (d *DedupeSink) contains(key string) bool {
+	elem, ok := d.seen[key]
+	if ok {
+		d.order.MoveToFront(elem)
+	}
+	return ok
+}
+
+// add records key, evicting the least recently used entry when full.
+func (d *DedupeSink) add(key string) {
+	if elem, ok := d.seen[key]; ok {
+		d.order.MoveToFront(elem)
+		return
+	}
+
+	d.seen[key] = d.order.PushFront(key)
+
+	if d.order.Len() > d.capacity {
+		oldest := d.order.Back()
+		d.order.Remove(oldest)
+		delete(d.seen, oldest.Value.(string))
+	}
+}
+
+// dedupeKey builds the cache key for a log; logIndex -1 identifies an event without logs.
+func dedupeKey(event Event, logIndex int64) string {
+	if event.Receipt == nil {
+		return fmt.Sprintf("block:%d:%d", event.BlockNumber, logIndex)
+	}
+	return fmt.Sprintf("%s:%d", event.Receipt.TxHash.Hex(), logIndex)
+}
@@ -0,0 +1,97 @@
+package sinks
+
+import (
+	"context"
+	"sync"
+
+	"usdc-event-tracker/internal/logging"
+)
+
+// DryRunSink stands in for a configured sink when running with DRY_RUN=true.
+// It never connects to or writes to the real destination; it only counts and
+// logs the events that would have been written.
+type DryRunSink struct {
+	name   string
+	logger *logging.Logger
+
+	mu          sync.Mutex
+	totalEvents int64
+	totalLogs   int64
+	totalWrites int64
+}
+
+// NewDryRunSink creates a no-op stand-in for the named sink.
+func NewDryRunSink(name string) *DryRunSink {
+	return &DryRunSink{
+		name:   name,
+		logger: logging.GetLogger("dry-run-sink"),
+	}
+}
+
+// Name returns the name of the sink being stood in for.
+func (d *DryRunSink) Name() string {
+	return d.name
+}
+
+// Initialize logs that the sink is running in dry-run mode.
+func (d *DryRunSink) Initialize(ctx context.Context) error {
+	d.logger.Info("Sink running in dry-run mode, nothing will be written", map[string]interface{}{
+		"sink_name": d.name,
+	})
+	return nil
+}
+
+// Write counts the events that would have been written.
+func (d *DryRunSink) Write(ctx context.Context, events []Event) error {
+	logCount := 0
+	for _, event := range events {
+		logCount += len(event.Logs)
+	}
+
+	d.mu.Lock()
+	d.totalEvents += int64(len(events))
+	d.totalLogs += int64(logCount)
+	d.totalWrites++
+	totalEvents := d.totalEvents
+	d.mu.Unlock()
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	d.logger.Info("Dry run: events would have been written", map[string]interface{}{
+		"sink_name":    d.name,
+		"event_count":  len(events),
+		"log_count":    logCount,
+		"total_events": totalEvents,
+	})
+
+	return nil
+}
+
+// Stats returns the counts of what would have been written.
+func (d *DryRunSink) Stats() map[string]interface{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return map[string]interface{}{
+		"dry_run":      true,
+		"total_events": d.totalEvents,
+		"total_logs":   d.totalLogs,
+		"total_writes": d.totalWrites,
+	}
+}
+
+// Close logs a summary of everything that would have been written.
+func (d *DryRunSink) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.logger.Info("Dry run summary", map[string]interface{}{
+		"sink_name":    d.name,
+		"total_events": d.totalEvents,
+		"total_logs":   d.totalLogs,
+		"total_writes": d.totalWrites,
+	})
+	return nil
+}
@@ -3,16 +3,19 @@ package elasticsearch
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/elastic/go-elasticsearch/v8/esapi"
-	"github.com/ethereum/go-ethereum/common"
 	"usdc-event-tracker/internal/logging"
 	"usdc-event-tracker/internal/sinks"
 )
@@ -22,10 +25,24 @@ type Config struct {
 	URLs               []string
 	Username           string
 	Password           string
+	CACert             string // PEM file of the CA that signed the cluster's certificate, empty uses system roots
+	ClientCert         string // PEM client certificate for mutual TLS, requires ClientKey
+	ClientKey          string // PEM private key of ClientCert
 	IndexPrefix        string
 	BatchSize          int
+	MaxBulkBytes       int // Bulk request body size cap, larger batches are split into several requests
 	FlushInterval      time.Duration
-	UseTimestampSuffix bool // Add daily index suffix like "-2024.01.15"
+	Refresh            string              // Bulk refresh policy: "false", "true" or "wait_for"
+	UseTimestampSuffix bool                // Add daily index suffix like "-2024.01.15"
+	Location           *time.Location      // Time zone of the daily index suffix, nil means UTC
+	ReceiptFields      sinks.ReceiptFields // Receipt-level fields to index, nil indexes all
+
+	// Index lifecycle management. When enabled, documents are written through
+	// a rollover alias named IndexPrefix instead of date-suffixed indices.
+	UseILM          bool
+	ILMPolicyName   string
+	RolloverMaxSize string // Maximum primary shard size before rollover, e.g. "50gb"
+	RolloverMaxAge  string // Maximum index age before rollover, e.g. "30d"
 }
 
 // Sink implements the sinks.Sink interface for Elasticsearch
@@ -33,39 +50,34 @@ type Sink struct {
 	config Config
 	client *elasticsearch.Client
 	logger *logging.Logger
+
+	// Metrics
+	mu           sync.Mutex
+	totalDocs    int64
+	totalBulks   int64
+	failedBulks  int64
+	lastBulkTime time.Duration
 }
 
-// USDCEventDocument represents a USDC event document for Elasticsearch
+// USDCEventDocument represents a USDC event document for Elasticsearch.
+// It is the shared sinks.EventJSON format plus Elasticsearch-specific fields.
 type USDCEventDocument struct {
-	Timestamp     string                 `json:"@timestamp"`
-	BlockNumber   uint64                 `json:"block_number"`
-	TxHash        string                 `json:"tx_hash"`
-	TxIndex       uint                   `json:"tx_index"`
-	Status        uint64                 `json:"status"`
-	GasUsed       uint64                 `json:"gas_used"`
-	FromAddress   string                 `json:"from_address"`
-	ToAddress     string                 `json:"to_address"`
-	ContractAddr  string                 `json:"contract_address"`
-	Network       string                 `json:"network"`
-	Events        []USDCLogEvent         `json:"events"`
-	Metadata      map[string]interface{} `json:"metadata"`
+	sinks.EventJSON
+	Timestamp    string                 `json:"@timestamp"` // Same as EventJSON.Timestamp, under the name Kibana expects
+	FromAddress  string                 `json:"from_address"`
+	ToAddress    string                 `json:"to_address"`
+	ContractAddr string                 `json:"contract_address"`
+	Network      string                 `json:"network"`
+	Metadata     map[string]interface{} `json:"metadata"`
 }
 
-// USDCLogEvent represents a decoded USDC log event
-type USDCLogEvent struct {
-	Type         string   `json:"type"`          // Transfer, Approval, etc.
-	Address      string   `json:"address"`       // Contract address
-	Topics       []string `json:"topics"`        // Raw topics
-	Data         string   `json:"data"`          // Raw data
-	BlockNumber  uint64   `json:"block_number"`  // Block number
-	TxHash       string   `json:"tx_hash"`       // Transaction hash
-	TxIndex      uint     `json:"tx_index"`      // Transaction index
-	LogIndex     uint     `json:"log_index"`     // Log index
-	FromAddr     string   `json:"from_addr,omitempty"`     // Decoded from address
-	ToAddr       string   `json:"to_addr,omitempty"`       // Decoded to address
-	Value        string   `json:"value,omitempty"`         // Decoded value
-	Owner        string   `json:"owner,omitempty"`         // For Approval events
-	Spender      string   `json:"spender,omitempty"`       // For Approval events
+func init() {
+	sinks.Register("elasticsearch", func(opts sinks.Options) (sinks.Sink, error) {
+		config := NewConfig()
+		config.ReceiptFields = opts.ReceiptFields
+		config.Location = opts.Location
+		return New(config), nil
+	})
 }
 
 // NewConfig creates a new Elasticsearch configuration from environment variables
@@ -74,8 +86,12 @@ func NewConfig() Config {
 		URLs:               []string{"http://localhost:9200"},
 		IndexPrefix:        "usdc-events",
 		BatchSize:          100,
+		MaxBulkBytes:       10 * 1024 * 1024,
 		FlushInterval:      5 * time.Second,
+		Refresh:            "false",
 		UseTimestampSuffix: true,
+		RolloverMaxSize:    "50gb",
+		RolloverMaxAge:     "30d",
 	}
 
 	// Parse URLs from environment
@@ -90,6 +106,11 @@ func NewConfig() Config {
 	config.Username = os.Getenv("ELASTICSEARCH_USERNAME")
 	config.Password = os.Getenv("ELASTICSEARCH_PASSWORD")
 
+	// TLS, including client certificates for clusters requiring mutual TLS
+	config.CACert = os.Getenv("ELASTICSEARCH_CA_CERT")
+	config.ClientCert = os.Getenv("ELASTICSEARCH_CLIENT_CERT")
+	config.ClientKey = os.Getenv("ELASTICSEARCH_CLIENT_KEY")
+
 	// Index configuration
 	if prefix := os.Getenv("ELASTICSEARCH_INDEX_PREFIX"); prefix != "" {
 		config.IndexPrefix = prefix
@@ -101,12 +122,36 @@ func NewConfig() Config {
 			config.BatchSize = size
 		}
 	}
+	if maxBytes := os.Getenv("ELASTICSEARCH_MAX_BULK_BYTES"); maxBytes != "" {
+		if size, err := strconv.Atoi(maxBytes); err == nil && size > 0 {
+			config.MaxBulkBytes = size
+		}
+	}
+
+	// Refresh policy, "false" favors throughput, "true"/"wait_for" make documents searchable on return
+	switch refresh := strings.ToLower(os.Getenv("ELASTICSEARCH_REFRESH")); refresh {
+	case "true", "false", "wait_for":
+		config.Refresh = refresh
+	}
 
 	// Timestamp suffix configuration
 	if suffix := os.Getenv("ELASTICSEARCH_USE_TIMESTAMP_SUFFIX"); suffix != "" {
 		config.UseTimestampSuffix = strings.ToLower(suffix) == "true"
 	}
 
+	// ILM configuration
+	config.UseILM = strings.ToLower(os.Getenv("ELASTICSEARCH_USE_ILM")) == "true"
+	config.ILMPolicyName = config.IndexPrefix + "-policy"
+	if policy := os.Getenv("ELASTICSEARCH_ILM_POLICY"); policy != "" {
+		config.ILMPolicyName = policy
+	}
+	if maxSize := os.Getenv("ELASTICSEARCH_ROLLOVER_MAX_SIZE"); maxSize != "" {
+		config.RolloverMaxSize = maxSize
+	}
+	if maxAge := os.Getenv("ELASTICSEARCH_ROLLOVER_MAX_AGE"); maxAge != "" {
+		config.RolloverMaxAge = maxAge
+	}
+
 	return config
 }
 
@@ -124,13 +169,22 @@ func (s *Sink) Name() string {
 }
 
 // Initialize sets up the Elasticsearch client and creates index templates
-func (s *Sink) Initialize() error {
+func (s *Sink) Initialize(ctx context.Context) error {
+	transport, err := s.newTransport()
+	if err != nil {
+		s.logger.Error("Invalid Elasticsearch TLS configuration", err)
+		return err
+	}
+
 	// Create Elasticsearch client
 	cfg := elasticsearch.Config{
 		Addresses: s.config.URLs,
 		Username:  s.config.Username,
 		Password:  s.config.Password,
 	}
+	if transport != nil {
+		cfg.Transport = transport
+	}
 
 	client, err := elasticsearch.NewClient(cfg)
 	if err != nil {
@@ -141,7 +195,7 @@ func (s *Sink) Initialize() error {
 	s.client = client
 
 	// Test connection
-	res, err := s.client.Info()
+	res, err := s.client.Info(s.client.Info.WithContext(ctx))
 	if err != nil {
 		s.logger.Error("Failed to connect to Elasticsearch", err)
 		return fmt.Errorf("failed to connect to Elasticsearch: %w", err)
@@ -156,19 +210,74 @@ func (s *Sink) Initialize() error {
 	}
 
 	s.logger.Info("Connected to Elasticsearch", map[string]interface{}{
-		"urls":         s.config.URLs,
-		"index_prefix": s.config.IndexPrefix,
-		"batch_size":   s.config.BatchSize,
+		"urls":           s.config.URLs,
+		"index_prefix":   s.config.IndexPrefix,
+		"batch_size":     s.config.BatchSize,
+		"max_bulk_bytes": s.config.MaxBulkBytes,
+		"use_ilm":        s.config.UseILM,
+		"refresh":        s.config.Refresh,
+		"client_cert":    s.config.ClientCert != "",
 	})
 
+	// The policy must exist before the template references it
+	if s.config.UseILM {
+		if err := s.createILMPolicy(ctx); err != nil {
+			return fmt.Errorf("failed to create ILM policy: %w", err)
+		}
+	}
+
 	// Create index template for USDC events
-	if err := s.createIndexTemplate(); err != nil {
+	if err := s.createIndexTemplate(ctx); err != nil {
 		return fmt.Errorf("failed to create index template: %w", err)
 	}
 
+	if s.config.UseILM {
+		if err := s.bootstrapRolloverAlias(ctx); err != nil {
+			return fmt.Errorf("failed to bootstrap rollover alias: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// newTransport builds an HTTP transport trusting CACert and presenting the client
+// certificate, or returns nil to use the client's default transport when no TLS
+// files are configured
+func (s *Sink) newTransport() (*http.Transport, error) {
+	if s.config.CACert == "" && s.config.ClientCert == "" && s.config.ClientKey == "" {
+		return nil, nil
+	}
+	if (s.config.ClientCert == "") != (s.config.ClientKey == "") {
+		return nil, fmt.Errorf("ELASTICSEARCH_CLIENT_CERT and ELASTICSEARCH_CLIENT_KEY must be set together")
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if s.config.CACert != "" {
+		pem, err := os.ReadFile(s.config.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in %s", s.config.CACert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if s.config.ClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(s.config.ClientCert, s.config.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
 // Write sends events to Elasticsearch
 func (s *Sink) Write(ctx context.Context, events []sinks.Event) error {
 	if len(events) == 0 {
@@ -181,7 +290,9 @@ func (s *Sink) Write(ctx context.Context, events []sinks.Event) error {
 	docs := s.convertEventsToDocuments(events)
 	
 	// Bulk index documents
-	if err := s.bulkIndex(ctx, docs); err != nil {
+	err := s.bulkIndex(ctx, docs)
+	s.recordBulk(len(docs), time.Since(start), err)
+	if err != nil {
 		s.logger.Error("Failed to bulk index documents", err, map[string]interface{}{
 			"event_count": len(events),
 			"doc_count":   len(docs),
@@ -198,6 +309,165 @@ func (s *Sink) Write(ctx context.Context, events []sinks.Event) error {
 	return nil
 }
 
+// WriteSummaries indexes block summaries into their own index, <IndexPrefix>_block_summaries,
+// which is outside the event index pattern. Used as the aggregate sink's inner sink.
+func (s *Sink) WriteSummaries(ctx context.Context, summaries []sinks.BlockSummary) error {
+	if len(summaries) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	index := s.config.IndexPrefix + "_block_summaries"
+
+	var buf bytes.Buffer
+	for _, summary := range summaries {
+		// Block number as document ID, so re-processing a block overwrites its summary
+		meta := map[string]interface{}{
+			"index": map[string]interface{}{
+				"_index": index,
+				"_id":    strconv.FormatUint(summary.BlockNumber, 10),
+			},
+		}
+		metaBytes, _ := json.Marshal(meta)
+		buf.Write(metaBytes)
+		buf.WriteByte('\n')
+
+		docBytes, err := json.Marshal(summary)
+		if err != nil {
+			return fmt.Errorf("failed to marshal block summary: %w", err)
+		}
+		buf.Write(docBytes)
+		buf.WriteByte('\n')
+	}
+
+	req := esapi.BulkRequest{
+		Body:    bytes.NewReader(buf.Bytes()),
+		Refresh: s.config.Refresh,
+	}
+
+	res, err := req.Do(ctx, s.client)
+	if err == nil {
+		defer res.Body.Close()
+		if res.IsError() {
+			err = fmt.Errorf("bulk request error: %s", res.Status())
+		}
+	}
+	s.recordBulk(len(summaries), time.Since(start), err)
+	if err != nil {
+		return fmt.Errorf("failed to index block summaries: %w", err)
+	}
+
+	return nil
+}
+
+// WriteHeartbeat indexes a block heartbeat into <IndexPrefix>_heartbeats, which is
+// outside the event index pattern. The block number is the document ID, so
+// re-processing a block overwrites its heartbeat.
+func (s *Sink) WriteHeartbeat(ctx context.Context, heartbeat sinks.BlockHeartbeat) error {
+	body, err := json.Marshal(heartbeat)
+	if err != nil {
+		return fmt.Errorf("failed to marshal heartbeat: %w", err)
+	}
+
+	req := esapi.IndexRequest{
+		Index:      s.config.IndexPrefix + "_heartbeats",
+		DocumentID: strconv.FormatUint(heartbeat.BlockNumber, 10),
+		Body:       bytes.NewReader(body),
+		Refresh:    s.config.Refresh,
+	}
+
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return fmt.Errorf("heartbeat request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("heartbeat request error: %s", res.Status())
+	}
+
+	return nil
+}
+
+// WriteBalances indexes balance snapshots into <IndexPrefix>_balances, which is
+// outside the event index pattern. Block, contract and address make up the document
+// ID, so re-processing a block overwrites its snapshots.
+func (s *Sink) WriteBalances(ctx context.Context, snapshots []sinks.BalanceSnapshot) error {
+	index := s.config.IndexPrefix + "_balances"
+
+	var buf bytes.Buffer
+	for _, snapshot := range snapshots {
+		meta := map[string]interface{}{
+			"index": map[string]interface{}{
+				"_index": index,
+				"_id":    fmt.Sprintf("%d:%s:%s", snapshot.BlockNumber, snapshot.Contract, snapshot.Address),
+			},
+		}
+		metaBytes, _ := json.Marshal(meta)
+		buf.Write(metaBytes)
+		buf.WriteByte('\n')
+
+		docBytes, err := json.Marshal(snapshot)
+		if err != nil {
+			return fmt.Errorf("failed to marshal balance snapshot: %w", err)
+		}
+		buf.Write(docBytes)
+		buf.WriteByte('\n')
+	}
+
+	if err := s.sendBulk(ctx, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to index balance snapshots: %w", err)
+	}
+	return nil
+}
+
+// Flush refreshes the sink's indices so indexed documents become searchable
+// without waiting for the refresh interval
+func (s *Sink) Flush(ctx context.Context) error {
+	req := esapi.IndicesRefreshRequest{
+		Index: []string{s.config.IndexPrefix + "*"},
+	}
+
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return fmt.Errorf("refresh request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("refresh request error: %s", res.Status())
+	}
+
+	return nil
+}
+
+// recordBulk updates the bulk request metrics
+func (s *Sink) recordBulk(docCount int, duration time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.totalBulks++
+	s.lastBulkTime = duration
+	if err != nil {
+		s.failedBulks++
+		return
+	}
+	s.totalDocs += int64(docCount)
+}
+
+// Stats implements sinks.StatReporter
+func (s *Sink) Stats() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return map[string]interface{}{
+		"total_docs":            s.totalDocs,
+		"total_bulk_requests":   s.totalBulks,
+		"failed_bulk_requests":  s.failedBulks,
+		"last_bulk_duration_ms": s.lastBulkTime.Milliseconds(),
+	}
+}
+
 // Close cleans up the Elasticsearch sink
 func (s *Sink) Close() error {
 	s.logger.Info("Closing Elasticsearch sink")
@@ -209,45 +479,21 @@ func (s *Sink) convertEventsToDocuments(events []sinks.Event) []USDCEventDocumen
 	docs := make([]USDCEventDocument, 0, len(events))
 	
 	for _, event := range events {
-		// Convert logs to events
-		logEvents := make([]USDCLogEvent, 0, len(event.Logs))
-		for _, log := range event.Logs {
-			logEvent := USDCLogEvent{
-				Type:        s.decodeEventType(log.Topics),
-				Address:     log.Address.Hex(),
-				Topics:      s.topicsToStrings(log.Topics),
-				Data:        common.Bytes2Hex(log.Data),
-				BlockNumber: log.BlockNumber,
-				TxHash:      log.TxHash.Hex(),
-				TxIndex:     log.TxIndex,
-				LogIndex:    log.Index,
-			}
-			
-			// Decode specific event data
-			s.decodeEventData(&logEvent, log.Topics, log.Data)
-			
-			logEvents = append(logEvents, logEvent)
-		}
-		
+		eventJSON := sinks.NewEventJSON(event, s.config.ReceiptFields)
 		doc := USDCEventDocument{
-			Timestamp:    time.Now().UTC().Format(time.RFC3339Nano),
-			BlockNumber:  event.BlockNumber,
-			TxHash:       event.Receipt.TxHash.Hex(),
-			TxIndex:      event.Receipt.TransactionIndex,
-			Status:       event.Receipt.Status,
-			GasUsed:      event.Receipt.GasUsed,
-			FromAddress:  "", // Will be filled if available
-			ToAddress:    "", // Will be filled if available
+			EventJSON:    eventJSON,
+			Timestamp:    eventJSON.Timestamp,
+			FromAddress:  eventJSON.From,
+			ToAddress:    eventJSON.To,
 			ContractAddr: "", // Will be filled if available
 			Network:      s.getNetworkFromConfig(),
-			Events:       logEvents,
 			Metadata: map[string]interface{}{
-				"cumulative_gas_used": event.Receipt.CumulativeGasUsed,
-				"effective_gas_price": event.Receipt.EffectiveGasPrice.String(),
-				"logs_count":          len(event.Receipt.Logs),
-				"usdc_logs_count":     len(event.Logs),
+				"usdc_logs_count": len(event.Logs),
 			},
 		}
+		if eventJSON.TxFeeWei != "" {
+			doc.Metadata["tx_fee_wei"] = eventJSON.TxFeeWei
+		}
 		
 		docs = append(docs, doc)
 	}
@@ -255,40 +501,55 @@ func (s *Sink) convertEventsToDocuments(events []sinks.Event) []USDCEventDocumen
 	return docs
 }
 
-// bulkIndex performs bulk indexing of documents
+// bulkIndex performs bulk indexing of documents, splitting them over several
+// bulk requests when the body would grow past MaxBulkBytes
 func (s *Sink) bulkIndex(ctx context.Context, docs []USDCEventDocument) error {
 	if len(docs) == 0 {
 		return nil
 	}
 
 	var buf bytes.Buffer
-	indexName := s.getIndexName()
 
 	for _, doc := range docs {
 		// Bulk API format: { "index": { "_index": "indexname" } }
 		meta := map[string]interface{}{
 			"index": map[string]interface{}{
-				"_index": indexName,
+				"_index": s.getIndexName(doc.Timestamp),
 			},
 		}
 		
 		metaBytes, _ := json.Marshal(meta)
-		buf.Write(metaBytes)
-		buf.WriteByte('\n')
-		
+
 		// Document data
 		docBytes, err := json.Marshal(doc)
 		if err != nil {
 			return fmt.Errorf("failed to marshal document: %w", err)
 		}
+
+		// Send what is buffered before this document pushes the body over the cap.
+		// A single document larger than the cap still goes out on its own.
+		size := len(metaBytes) + len(docBytes) + 2
+		if s.config.MaxBulkBytes > 0 && buf.Len() > 0 && buf.Len()+size > s.config.MaxBulkBytes {
+			if err := s.sendBulk(ctx, buf.Bytes()); err != nil {
+				return err
+			}
+			buf.Reset()
+		}
+
+		buf.Write(metaBytes)
+		buf.WriteByte('\n')
 		buf.Write(docBytes)
 		buf.WriteByte('\n')
 	}
 
-	// Perform bulk request
+	return s.sendBulk(ctx, buf.Bytes())
+}
+
+// sendBulk performs a single bulk request with an NDJSON body
+func (s *Sink) sendBulk(ctx context.Context, body []byte) error {
 	req := esapi.BulkRequest{
-		Body:    strings.NewReader(buf.String()),
-		Refresh: "false",
+		Body:    bytes.NewReader(body),
+		Refresh: s.config.Refresh,
 	}
 
 	res, err := req.Do(ctx, s.client)
@@ -305,43 +566,64 @@ func (s *Sink) bulkIndex(ctx context.Context, docs []USDCEventDocument) error {
 }
 
 // createIndexTemplate creates an index template for USDC events
-func (s *Sink) createIndexTemplate() error {
+func (s *Sink) createIndexTemplate(ctx context.Context) error {
+	settings := map[string]interface{}{
+		"number_of_shards":   1,
+		"number_of_replicas": 0,
+		"refresh_interval":   "5s",
+	}
+	if s.config.UseILM {
+		settings["index.lifecycle.name"] = s.config.ILMPolicyName
+		settings["index.lifecycle.rollover_alias"] = s.config.IndexPrefix
+	}
+
 	template := map[string]interface{}{
 		"index_patterns": []string{s.config.IndexPrefix + "-*"},
 		"template": map[string]interface{}{
-			"settings": map[string]interface{}{
-				"number_of_shards":   1,
-				"number_of_replicas": 0,
-				"refresh_interval":   "5s",
-			},
+			"settings": settings,
 			"mappings": map[string]interface{}{
 				"properties": map[string]interface{}{
-					"@timestamp":       map[string]interface{}{"type": "date"},
-					"block_number":     map[string]interface{}{"type": "long"},
-					"tx_hash":          map[string]interface{}{"type": "keyword"},
-					"tx_index":         map[string]interface{}{"type": "integer"},
-					"status":           map[string]interface{}{"type": "integer"},
-					"gas_used":         map[string]interface{}{"type": "long"},
-					"from_address":     map[string]interface{}{"type": "keyword"},
-					"to_address":       map[string]interface{}{"type": "keyword"},
-					"contract_address": map[string]interface{}{"type": "keyword"},
-					"network":          map[string]interface{}{"type": "keyword"},
-					"events": map[string]interface{}{
+					"@timestamp":          map[string]interface{}{"type": "date"},
+					"timestamp":           map[string]interface{}{"type": "date"},
+					"ingested_at":         map[string]interface{}{"type": "date"},
+					"block_number":        map[string]interface{}{"type": "long"},
+					"tx_hash":             map[string]interface{}{"type": "keyword"},
+					"tx_index":            map[string]interface{}{"type": "integer"},
+					"status":              map[string]interface{}{"type": "integer"},
+					"gas_used":            map[string]interface{}{"type": "long"},
+					"cumulative_gas_used": map[string]interface{}{"type": "long"},
+					"effective_gas_price": map[string]interface{}{"type": "keyword"},
+					"tx_fee_wei":          map[string]interface{}{"type": "keyword"},
+					"blob_gas_used":       map[string]interface{}{"type": "long"},
+					"blob_gas_price":      map[string]interface{}{"type": "keyword"},
+					"tx_type":             map[string]interface{}{"type": "keyword"},
+					"fee_model":           map[string]interface{}{"type": "keyword"},
+					"logs_count":          map[string]interface{}{"type": "integer"},
+					"from":                map[string]interface{}{"type": "keyword"},
+					"to":                  map[string]interface{}{"type": "keyword"},
+					"value":               map[string]interface{}{"type": "keyword"},
+					"from_address":        map[string]interface{}{"type": "keyword"},
+					"to_address":          map[string]interface{}{"type": "keyword"},
+					"contract_address":    map[string]interface{}{"type": "keyword"},
+					"network":             map[string]interface{}{"type": "keyword"},
+					"usdc_variant":        map[string]interface{}{"type": "keyword"},
+					"logs": map[string]interface{}{
 						"type": "nested",
 						"properties": map[string]interface{}{
-							"type":         map[string]interface{}{"type": "keyword"},
-							"address":      map[string]interface{}{"type": "keyword"},
-							"topics":       map[string]interface{}{"type": "keyword"},
-							"data":         map[string]interface{}{"type": "text", "index": false},
-							"block_number": map[string]interface{}{"type": "long"},
-							"tx_hash":      map[string]interface{}{"type": "keyword"},
-							"tx_index":     map[string]interface{}{"type": "integer"},
-							"log_index":    map[string]interface{}{"type": "integer"},
-							"from_addr":    map[string]interface{}{"type": "keyword"},
-							"to_addr":      map[string]interface{}{"type": "keyword"},
-							"value":        map[string]interface{}{"type": "keyword"},
-							"owner":        map[string]interface{}{"type": "keyword"},
-							"spender":      map[string]interface{}{"type": "keyword"},
+							"type":              map[string]interface{}{"type": "keyword"},
+							"address":           map[string]interface{}{"type": "keyword"},
+							"topics":            map[string]interface{}{"type": "keyword"},
+							"data":              map[string]interface{}{"type": "text", "index": false},
+							"log_index":         map[string]interface{}{"type": "integer"},
+							"from":              map[string]interface{}{"type": "keyword"},
+							"to":                map[string]interface{}{"type": "keyword"},
+							"owner":             map[string]interface{}{"type": "keyword"},
+							"spender":           map[string]interface{}{"type": "keyword"},
+							"value":             map[string]interface{}{"type": "keyword"},
+							"infinite_approval": map[string]interface{}{"type": "boolean"},
+							"implementation":    map[string]interface{}{"type": "keyword"},
+							// Custom event parameters vary per event, flattened avoids mapping conflicts
+							"fields": map[string]interface{}{"type": "flattened"},
 						},
 					},
 				},
@@ -359,7 +641,7 @@ func (s *Sink) createIndexTemplate() error {
 		Body: bytes.NewReader(templateBytes),
 	}
 
-	res, err := req.Do(context.Background(), s.client)
+	res, err := req.Do(ctx, s.client)
 	if err != nil {
 		return fmt.Errorf("failed to create index template: %w", err)
 	}
@@ -377,70 +659,136 @@ func (s *Sink) createIndexTemplate() error {
 	return nil
 }
 
-// getIndexName returns the index name for the current time
-func (s *Sink) getIndexName() string {
-	if s.config.UseTimestampSuffix {
-		suffix := time.Now().UTC().Format("2006.01.02")
-		return fmt.Sprintf("%s-%s", s.config.IndexPrefix, suffix)
+// createILMPolicy creates or updates the lifecycle policy that rolls indices
+// over by size and age
+func (s *Sink) createILMPolicy(ctx context.Context) error {
+	rollover := map[string]interface{}{}
+	if s.config.RolloverMaxSize != "" {
+		rollover["max_primary_shard_size"] = s.config.RolloverMaxSize
+	}
+	if s.config.RolloverMaxAge != "" {
+		rollover["max_age"] = s.config.RolloverMaxAge
 	}
-	return s.config.IndexPrefix
-}
 
-// Helper methods for event decoding
-func (s *Sink) decodeEventType(topics []common.Hash) string {
-	if len(topics) == 0 {
-		return "unknown"
+	policy := map[string]interface{}{
+		"policy": map[string]interface{}{
+			"phases": map[string]interface{}{
+				"hot": map[string]interface{}{
+					"actions": map[string]interface{}{
+						"rollover": rollover,
+					},
+				},
+			},
+		},
 	}
-	
-	// Transfer event signature: 0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef
-	transferSig := common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
-	// Approval event signature: 0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925
-	approvalSig := common.HexToHash("0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925")
-	
-	switch topics[0] {
-	case transferSig:
-		return "Transfer"
-	case approvalSig:
-		return "Approval"
-	default:
-		return "unknown"
+
+	policyBytes, err := json.Marshal(policy)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ILM policy: %w", err)
+	}
+
+	req := esapi.ILMPutLifecycleRequest{
+		Policy: s.config.ILMPolicyName,
+		Body:   bytes.NewReader(policyBytes),
+	}
+
+	res, err := req.Do(ctx, s.client)
+	if err != nil {
+		return fmt.Errorf("failed to create ILM policy: %w", err)
 	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("ILM policy creation error: %s", res.Status())
+	}
+
+	s.logger.Info("Created Elasticsearch ILM policy", map[string]interface{}{
+		"policy_name": s.config.ILMPolicyName,
+		"max_size":    s.config.RolloverMaxSize,
+		"max_age":     s.config.RolloverMaxAge,
+	})
+
+	return nil
 }
 
-func (s *Sink) topicsToStrings(topics []common.Hash) []string {
-	result := make([]string, len(topics))
-	for i, topic := range topics {
-		result[i] = topic.Hex()
+// bootstrapRolloverAlias creates the first backing index with the write alias
+// unless the alias already exists
+func (s *Sink) bootstrapRolloverAlias(ctx context.Context) error {
+	alias := s.config.IndexPrefix
+
+	existsReq := esapi.IndicesExistsAliasRequest{
+		Name: []string{alias},
+	}
+	res, err := existsReq.Do(ctx, s.client)
+	if err != nil {
+		return fmt.Errorf("failed to check rollover alias: %w", err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode == 200 {
+		s.logger.Info("Using existing Elasticsearch rollover alias", map[string]interface{}{
+			"alias": alias,
+		})
+		return nil
+	}
+
+	body := map[string]interface{}{
+		"aliases": map[string]interface{}{
+			alias: map[string]interface{}{
+				"is_write_index": true,
+			},
+		},
 	}
-	return result
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal index body: %w", err)
+	}
+
+	// Rollover increments the trailing number, so it must be zero-padded
+	index := alias + "-000001"
+	createReq := esapi.IndicesCreateRequest{
+		Index: index,
+		Body:  bytes.NewReader(bodyBytes),
+	}
+
+	res, err = createReq.Do(ctx, s.client)
+	if err != nil {
+		return fmt.Errorf("failed to create index %s: %w", index, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("index %s creation error: %s", index, res.Status())
+	}
+
+	s.logger.Info("Created Elasticsearch rollover alias", map[string]interface{}{
+		"alias":       alias,
+		"write_index": index,
+	})
+
+	return nil
 }
 
-func (s *Sink) decodeEventData(event *USDCLogEvent, topics []common.Hash, data []byte) {
-	// Basic decoding for Transfer and Approval events
-	// In a production system, you'd use proper ABI decoding
-	
-	if len(topics) >= 3 {
-		switch event.Type {
-		case "Transfer":
-			if len(topics) >= 3 {
-				event.FromAddr = common.BytesToAddress(topics[1].Bytes()).Hex()
-				event.ToAddr = common.BytesToAddress(topics[2].Bytes()).Hex()
-			}
-			if len(data) >= 32 {
-				// Value is in the data field for Transfer events
-				event.Value = common.BytesToHash(data[:32]).Hex()
-			}
-		case "Approval":
-			if len(topics) >= 3 {
-				event.Owner = common.BytesToAddress(topics[1].Bytes()).Hex()
-				event.Spender = common.BytesToAddress(topics[2].Bytes()).Hex()
-			}
-			if len(data) >= 32 {
-				// Value is in the data field for Approval events
-				event.Value = common.BytesToHash(data[:32]).Hex()
-			}
+// getIndexName returns the index name for a document's @timestamp,
+// so that historical blocks land in the daily index of their block time.
+// With ILM enabled all documents go through the rollover alias.
+func (s *Sink) getIndexName(timestamp string) string {
+	if s.config.UseILM {
+		return s.config.IndexPrefix
+	}
+	if s.config.UseTimestampSuffix {
+		t, err := time.Parse(time.RFC3339Nano, timestamp)
+		if err != nil {
+			t = time.Now()
 		}
+		location := s.config.Location
+		if location == nil {
+			location = time.UTC
+		}
+		suffix := t.In(location).Format("2006.01.02")
+		return fmt.Sprintf("%s-%s", s.config.IndexPrefix, suffix)
 	}
+	return s.config.IndexPrefix
 }
 
 func (s *Sink) getNetworkFromConfig() string {
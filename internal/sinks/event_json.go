@@ -0,0 +1,173 @@
+package sinks
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"usdc-event-tracker/internal/erc20"
+)
+
+// SchemaVersion identifies the shape of emitted documents and messages, so
+// consumers can tell which fields to expect. Increment it whenever fields are
+// added, removed, renamed or change meaning in EventJSON, LogJSON or the
+// sink-specific documents built on them.
+const SchemaVersion = 2
+
+// EventJSON is the JSON wire format shared by every sink that emits JSON
+// (elasticsearch, s3, kafka, filesystem and the sql raw_data column), so that
+// consumers reading from several sinks see the same snake_case field names.
+// Receipt-level fields are omitted when excluded by ReceiptFields or not reported.
+type EventJSON struct {
+	SchemaVersion     int       `json:"schema_version"`
+	Timestamp         string    `json:"timestamp"`
+	IngestedAt        string    `json:"ingested_at"`
+	BlockNumber       uint64    `json:"block_number"`
+	TxHash            string    `json:"tx_hash"`
+	TxIndex           *uint     `json:"tx_index,omitempty"`
+	Status            *uint64   `json:"status,omitempty"`
+	GasUsed           *uint64   `json:"gas_used,omitempty"`
+	CumulativeGasUsed *uint64   `json:"cumulative_gas_used,omitempty"`
+	EffectiveGasPrice string    `json:"effective_gas_price,omitempty"`
+	TxFeeWei          string    `json:"tx_fee_wei,omitempty"` // gas_used * effective_gas_price
+	BlobGasUsed       uint64    `json:"blob_gas_used,omitempty"`
+	BlobGasPrice      string    `json:"blob_gas_price,omitempty"`
+	TxType            string    `json:"tx_type,omitempty"`
+	FeeModel          string    `json:"fee_model,omitempty"`
+	LogsCount         *int      `json:"logs_count,omitempty"`
+	Variant           string    `json:"usdc_variant,omitempty"`
+	From              string    `json:"from,omitempty"`  // Of the primary Transfer, see PrimarySelection
+	To                string    `json:"to,omitempty"`    // Of the primary Transfer
+	Value             string    `json:"value,omitempty"` // Of the primary Transfer
+	SuspectValue      bool      `json:"suspect_value,omitempty"`
+	Logs              []LogJSON `json:"logs"`
+}
+
+// LogJSON is a USDC log inside an EventJSON. Transfer and Approval logs carry
+// their decoded, checksummed addresses and the raw value as a decimal string;
+// Upgraded logs carry the new implementation address and custom events their
+// named parameters in fields.
+type LogJSON struct {
+	Type             string                 `json:"type"`
+	Address          string                 `json:"address"`
+	Topics           []string               `json:"topics"`
+	Data             string                 `json:"data"`
+	LogIndex         uint                   `json:"log_index"`
+	From             string                 `json:"from,omitempty"`
+	To               string                 `json:"to,omitempty"`
+	Owner            string                 `json:"owner,omitempty"`
+	Spender          string                 `json:"spender,omitempty"`
+	Value            string                 `json:"value,omitempty"`
+	InfiniteApproval bool                   `json:"infinite_approval,omitempty"`
+	Implementation   string                 `json:"implementation,omitempty"`
+	Fields           map[string]interface{} `json:"fields,omitempty"` // Parameters of a CUSTOM_EVENTS_FILE event
+}
+
+// NewEventJSON converts an event to the shared wire format, keeping only the
+// receipt-level fields allowed by fields.
+func NewEventJSON(event Event, fields ReceiptFields) EventJSON {
+	logs := make([]LogJSON, 0, len(event.Logs))
+	for _, log := range event.Logs {
+		logs = append(logs, NewLogJSON(event, log))
+	}
+
+	doc := EventJSON{
+		SchemaVersion: SchemaVersion,
+		Timestamp:     event.Timestamp().UTC().Format(time.RFC3339Nano),
+		IngestedAt:    event.IngestedAt.UTC().Format(time.RFC3339Nano),
+		BlockNumber:   event.BlockNumber,
+		TxHash:        event.Receipt.TxHash.Hex(),
+		Variant:       event.Variant,
+		SuspectValue:  event.SuspectValue,
+		Logs:          logs,
+	}
+
+	if primary, ok := primaryTransfer(logs); ok {
+		doc.From = primary.From
+		doc.To = primary.To
+		doc.Value = primary.Value
+	}
+
+	receipt := event.Receipt
+	if fields.Has(FieldTxIndex) {
+		txIndex := receipt.TransactionIndex
+		doc.TxIndex = &txIndex
+	}
+	if fields.Has(FieldStatus) {
+		status := receipt.Status
+		doc.Status = &status
+	}
+	if fields.Has(FieldGasUsed) {
+		gasUsed := receipt.GasUsed
+		doc.GasUsed = &gasUsed
+	}
+	if fields.Has(FieldCumulativeGasUsed) {
+		cumulative := receipt.CumulativeGasUsed
+		doc.CumulativeGasUsed = &cumulative
+	}
+
+	// Gas prices can be missing on legacy chains, so they are only set when reported
+	pricing := ReceiptGasPricing(receipt)
+	if fields.Has(FieldEffectiveGasPrice) {
+		doc.EffectiveGasPrice = BigString(pricing.EffectiveGasPrice)
+		doc.TxFeeWei = BigString(pricing.TxFee)
+		doc.BlobGasUsed = pricing.BlobGasUsed
+		doc.BlobGasPrice = BigString(pricing.BlobGasPrice)
+	}
+	if fields.Has(FieldTxType) {
+		doc.TxType = pricing.TxType
+		doc.FeeModel = pricing.FeeModel
+	}
+	if fields.Has(FieldLogsCount) {
+		logsCount := len(receipt.Logs)
+		doc.LogsCount = &logsCount
+	}
+
+	return doc
+}
+
+// MarshalEvent encodes an event in the shared wire format.
+func MarshalEvent(event Event, fields ReceiptFields) ([]byte, error) {
+	return json.Marshal(NewEventJSON(event, fields))
+}
+
+// NewLogJSON converts log, one of event's logs, to the shared wire format with
+// the parameters from Event.Decode. Malformed logs keep their raw topics and data
+// with the parameters they lack left empty.
+func NewLogJSON(event Event, log *types.Log) LogJSON {
+	topics := make([]string, len(log.Topics))
+	for i, topic := range log.Topics {
+		topics[i] = topic.Hex()
+	}
+
+	doc := LogJSON{
+		Type:     "Unknown",
+		Address:  log.Address.Hex(),
+		Topics:   topics,
+		Data:     common.Bytes2Hex(log.Data),
+		LogIndex: log.Index,
+	}
+
+	decoded, found := event.Decode(log)
+	if !found {
+		return doc
+	}
+	doc.Type = string(decoded.Event)
+	doc.From = decoded.From
+	doc.To = decoded.To
+	doc.Owner = decoded.Owner
+	doc.Spender = decoded.Spender
+	doc.Implementation = decoded.Implementation
+	doc.Fields = decoded.Fields
+
+	if decoded.Value != nil {
+		doc.Value = decoded.Value.String()
+		if decoded.Event == erc20.Approval && erc20.IsInfiniteApproval(decoded.Value) {
+			doc.Value = erc20.InfiniteApprovalValue
+			doc.InfiniteApproval = true
+		}
+	}
+
+	return doc
+}
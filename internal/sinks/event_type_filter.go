@@ -0,0 +1,86 @@
+package sinks
+
+import (
+	"context"
+
+	"usdc-event-tracker/internal/erc20"
+)
+
+// EventTypeFilterSink wraps another sink and only forwards logs whose event type,
+// decoded from topics[0], is in an allowed set. Events left without any matching
+// logs are dropped.
+type EventTypeFilterSink struct {
+	sink    Sink
+	allowed map[erc20.Event]bool
+}
+
+// NewEventTypeFilterSink wraps sink so it only receives the given event types.
+func NewEventTypeFilterSink(sink Sink, eventTypes []erc20.Event) *EventTypeFilterSink {
+	allowed := make(map[erc20.Event]bool, len(eventTypes))
+	for _, eventType := range eventTypes {
+		allowed[eventType] = true
+	}
+	return &EventTypeFilterSink{
+		sink:    sink,
+		allowed: allowed,
+	}
+}
+
+// Name returns the name of the wrapped sink.
+func (e *EventTypeFilterSink) Name() string {
+	return e.sink.Name()
+}
+
+// Initialize prepares the wrapped sink for use.
+func (e *EventTypeFilterSink) Initialize(ctx context.Context) error {
+	return e.sink.Initialize(ctx)
+}
+
+// Write forwards only logs of an allowed event type.
+func (e *EventTypeFilterSink) Write(ctx context.Context, events []Event) error {
+	filtered := make([]Event, 0, len(events))
+
+	for _, event := range events {
+		matched := event
+		matched.Logs = matched.Logs[:0:0]
+		for _, log := range event.Logs {
+			if len(log.Topics) == 0 {
+				continue
+			}
+			if eventType, found := erc20.GetEventBySignature(log.Topics[0].Hex()); found && e.allowed[eventType] {
+				matched.Logs = append(matched.Logs, log)
+			}
+		}
+
+		if len(matched.Logs) > 0 {
+			filtered = append(filtered, matched)
+		}
+	}
+
+	return e.sink.Write(ctx, filtered)
+}
+
+// Close cleans up the wrapped sink.
+func (e *EventTypeFilterSink) Close() error {
+	return e.sink.Close()
+}
+
+// Flush flushes the wrapped sink if it buffers events.
+func (e *EventTypeFilterSink) Flush(ctx context.Context) error {
+	return flush(ctx, e.sink)
+}
+
+// WriteHeartbeat forwards a block heartbeat to the wrapped sink if it stores heartbeats.
+func (e *EventTypeFilterSink) WriteHeartbeat(ctx context.Context, heartbeat BlockHeartbeat) error {
+	return writeHeartbeat(ctx, e.sink, heartbeat)
+}
+
+// WriteBalances forwards balance snapshots to the wrapped sink if it stores them.
+func (e *EventTypeFilterSink) WriteBalances(ctx context.Context, snapshots []BalanceSnapshot) error {
+	return writeBalances(ctx, e.sink, snapshots)
+}
+
+// Stats returns the wrapped sink's metrics.
+func (e *EventTypeFilterSink) Stats() map[string]interface{} {
+	return stats(e.sink)
+}
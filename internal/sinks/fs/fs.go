@@ -1,17 +1,26 @@
-// Package fs implements a production-ready filesystem sink for blockchain events
+// Package fs implements a filesystem sink writing events as JSON, JSON Lines, CSV or text files
 package fs
 
 import (
 	"bufio"
+	"compress/gzip"
 	"context"
 	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"math/big"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
-	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"usdc-event-tracker/internal/erc20"
+	"usdc-event-tracker/internal/logging"
 	"usdc-event-tracker/internal/sinks"
 )
 
@@ -19,12 +28,28 @@ import (
 type FileFormat string
 
 const (
-	FormatJSON  FileFormat = "json"  // Pretty-printed JSON
+	FormatJSON  FileFormat = "json"  // Pretty-printed JSON array of events, one per file
 	FormatJSONL FileFormat = "jsonl" // JSON Lines (one JSON object per line)
 	FormatCSV   FileFormat = "csv"   // Comma-separated values
 	FormatText  FileFormat = "text"  // Human-readable text
 )
 
+// csvHeader is the column order of CSV files, one row per log. Approval rows store
+// the owner in from and the spender in to; Upgraded rows store the new
+// implementation in to. value is the raw amount in base units and value_usdc the
+// same amount as an exact decimal, e.g. 1250.000000.
+var csvHeader = []string{
+	"block_number",
+	"block_timestamp",
+	"tx_hash",
+	"log_index",
+	"event_type",
+	"from",
+	"to",
+	"value",
+	"value_usdc",
+}
+
 // RotationStrategy defines how files are rotated
 type RotationStrategy string
 
@@ -38,276 +63,859 @@ const (
 // Config holds filesystem sink configuration
 type Config struct {
 	OutputDir        string           // Directory to write files to
-	Format          FileFormat       // Output format
-	FilePrefix      string           // Prefix for output files
+	Format           FileFormat       // Output format
+	FilePrefix       string           // Prefix for output files
 	RotationStrategy RotationStrategy // How to rotate files
-	MaxFileSize     int64            // Max file size in bytes (for size rotation)
-	MaxEvents       int              // Max events per file (for event rotation)
+	MaxFileSize      int64            // Max uncompressed file size in bytes (for size rotation)
+	MaxEvents        int              // Max events per file (for event rotation)
 	RotationInterval time.Duration    // Interval for time-based rotation
-	Compress        bool             // Whether to compress files
-	BufferSize      int              // Write buffer size
-	CreateIndex     bool             // Whether to create index files
+	Compress         bool             // Whether to gzip files
+	BufferSize       int              // Write buffer size
+	CreateIndex      bool             // Whether to write index/<prefix>.jsonl
+	Location         *time.Location   // Time zone of daily rotation boundaries, nil means UTC
+
+	ReceiptFields sinks.ReceiptFields // Receipt-level fields written for JSON formats, nil writes all
+
+	// Block-range export: only blocks in [ExportFromBlock, ExportToBlock] are written and
+	// every rotated file is listed in metadata/manifest.json. ExportToBlock 0 disables it.
+	ExportFromBlock uint64
+	ExportToBlock   uint64
 }
 
-// FilesystemSink writes events to files with production features
+// FilesystemSink writes events to files in OutputDir/current, opened on the first
+// write, and moves each file to OutputDir/archive/<year>/<month> once it is rotated
+// or the sink is closed
 type FilesystemSink struct {
 	config Config
-	
-	// File management
-	currentFile     *os.File
-	writer          io.Writer
-	bufferedWriter  *bufio.Writer
-	csvWriter       *csv.Writer
-	
+	logger *logging.Logger
+
+	// File management, nil while no file is open
+	currentFile    *os.File
+	gzipWriter     *gzip.Writer
+	bufferedWriter *bufio.Writer
+	writer         *countingWriter // Uncompressed bytes written to the current file
+	csvWriter      *csv.Writer
+	jsonEvents     int // Events in the current file's JSON array
+
 	// Rotation tracking
-	currentSize     int64
-	eventCount      int
-	rotationTime    time.Time
-	fileStartTime   time.Time
-	
+	eventCount     int
+	fileFirstBlock uint64
+	fileLastBlock  uint64
+	rotationTime   time.Time // Zero unless rotating by time or daily
+	fileStartTime  time.Time
+
 	// Metadata
-	totalEvents     int64
-	totalFiles      int
-	
+	totalEvents int64
+	totalFiles  int
+
 	// Thread safety
-	mu              sync.Mutex
-	
-	// Index management
-	indexFile       *os.File
-	indexWriter     *bufio.Writer
-	
+	mu sync.Mutex
+
+	// Index management, nil unless CreateIndex
+	indexFile   *os.File
+	indexWriter *bufio.Writer
+
+	// Export manifest, nil unless ExportToBlock is set
+	manifest *Manifest
+
 	// Shutdown
-	done            chan struct{}
-	wg              sync.WaitGroup
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func init() {
+	sinks.Register("filesystem", func(opts sinks.Options) (sinks.Sink, error) {
+		config := NewConfig()
+		config.ReceiptFields = opts.ReceiptFields
+		config.Location = opts.Location
+		return New(config), nil
+	})
+}
+
+// NewConfig creates a new filesystem sink configuration from environment variables
+func NewConfig() Config {
+	config := Config{
+		OutputDir:   os.Getenv("FS_OUTPUT_DIR"),
+		FilePrefix:  os.Getenv("FS_FILE_PREFIX"),
+		Compress:    strings.ToLower(os.Getenv("FS_COMPRESS")) == "true",
+		CreateIndex: strings.ToLower(os.Getenv("FS_CREATE_INDEX")) == "true",
+	}
+
+	switch os.Getenv("FS_FORMAT") {
+	case "csv":
+		config.Format = FormatCSV
+	case "text":
+		config.Format = FormatText
+	case "jsonl":
+		config.Format = FormatJSONL
+	default:
+		config.Format = FormatJSON
+	}
+
+	switch strategy := RotationStrategy(strings.ToLower(os.Getenv("FS_ROTATION"))); strategy {
+	case RotateBySize, RotateByTime, RotateByEvents, RotateDaily:
+		config.RotationStrategy = strategy
+	}
+
+	if size := os.Getenv("FS_MAX_FILE_SIZE"); size != "" {
+		if n, err := strconv.ParseInt(size, 10, 64); err == nil && n > 0 {
+			config.MaxFileSize = n
+		}
+	}
+
+	if events := os.Getenv("FS_MAX_EVENTS"); events != "" {
+		if n, err := strconv.Atoi(events); err == nil && n > 0 {
+			config.MaxEvents = n
+		}
+	}
+
+	if interval := os.Getenv("FS_ROTATION_INTERVAL"); interval != "" {
+		if d, err := time.ParseDuration(interval); err == nil && d > 0 {
+			config.RotationInterval = d
+		}
+	}
+
+	if from := os.Getenv("FS_EXPORT_FROM_BLOCK"); from != "" {
+		if n, err := strconv.ParseUint(from, 10, 64); err == nil {
+			config.ExportFromBlock = n
+		}
+	}
+
+	if to := os.Getenv("FS_EXPORT_TO_BLOCK"); to != "" {
+		if n, err := strconv.ParseUint(to, 10, 64); err == nil {
+			config.ExportToBlock = n
+		}
+	}
+
+	return config
 }
 
 // New creates a new filesystem sink with the given configuration
 func New(config Config) *FilesystemSink {
-	// TODO: Implement
-	// - Set defaults for empty config values
-	// - Initialize the FilesystemSink struct
-	// - Set initial rotation time
-	return nil
+	if config.OutputDir == "" {
+		config.OutputDir = "./usdc-events"
+	}
+	if config.Format == "" {
+		config.Format = FormatJSON
+	}
+	if config.FilePrefix == "" {
+		config.FilePrefix = "usdc-events"
+	}
+	if config.RotationStrategy == "" {
+		config.RotationStrategy = RotateDaily
+	}
+	if config.MaxFileSize <= 0 {
+		config.MaxFileSize = 100 * 1024 * 1024
+	}
+	if config.MaxEvents <= 0 {
+		config.MaxEvents = 10000
+	}
+	if config.RotationInterval <= 0 {
+		config.RotationInterval = time.Hour
+	}
+	if config.BufferSize <= 0 {
+		config.BufferSize = 64 * 1024
+	}
+	if config.Location == nil {
+		config.Location = time.UTC
+	}
+
+	f := &FilesystemSink{
+		config: config,
+		logger: logging.GetLogger("filesystem-sink"),
+		done:   make(chan struct{}),
+	}
+	if f.exportEnabled() {
+		f.manifest = f.newManifest()
+	}
+	return f
 }
 
 // Name returns "filesystem" as the sink identifier
 func (f *FilesystemSink) Name() string {
-	// TODO: Implement
-	return ""
-}
-
-// Initialize prepares the filesystem sink
-func (f *FilesystemSink) Initialize() error {
-	// TODO: Implement
-	// - Create directory structure
-	// - Write initial metadata
-	// - Open first file
-	// - Start rotation worker if needed
-	// - Print initialization info
+	return "filesystem"
+}
+
+// Initialize creates the directory structure, the index and the export manifest,
+// and starts the rotation worker for time-based rotation
+func (f *FilesystemSink) Initialize(ctx context.Context) error {
+	if err := f.createDirectoryStructure(); err != nil {
+		f.logger.Error("Failed to create output directories", err, map[string]interface{}{
+			"output_dir": f.config.OutputDir,
+		})
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.writeMetadata(); err != nil {
+		return err
+	}
+
+	if f.config.CreateIndex {
+		path := filepath.Join(f.config.OutputDir, "index", f.config.FilePrefix+".jsonl")
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open index %s: %w", path, err)
+		}
+		f.indexFile = file
+		f.indexWriter = bufio.NewWriter(file)
+	}
+
+	if f.exportEnabled() {
+		if err := f.writeManifest(); err != nil {
+			return err
+		}
+	}
+
+	if f.config.RotationStrategy == RotateByTime || f.config.RotationStrategy == RotateDaily {
+		f.wg.Add(1)
+		go f.rotationWorker()
+	}
+
+	fields := map[string]interface{}{
+		"output_dir": f.config.OutputDir,
+		"format":     f.config.Format,
+		"rotation":   f.config.RotationStrategy,
+		"compress":   f.config.Compress,
+		"index":      f.config.CreateIndex,
+	}
+	if f.exportEnabled() {
+		fields["export_from_block"] = f.config.ExportFromBlock
+		fields["export_to_block"] = f.config.ExportToBlock
+	}
+	f.logger.Info("Filesystem sink initialized", fields)
+
 	return nil
 }
 
-// Write saves events to the filesystem
+// Write appends events to the current file, opening or rotating it first if needed
 func (f *FilesystemSink) Write(ctx context.Context, events []sinks.Event) error {
-	// TODO: Implement
-	// - Check if rotation is needed
-	// - Write events based on format
-	// - Update index if enabled
-	// - Flush buffers
-	// - Update metrics
+	kept := make([]sinks.Event, 0, len(events))
+	for _, event := range events {
+		if f.inExportRange(event.BlockNumber) {
+			kept = append(kept, event)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.currentFile == nil {
+		if err := f.openNewFile(); err != nil {
+			return err
+		}
+	} else if f.shouldRotate() {
+		if err := f.rotateFile(); err != nil {
+			return err
+		}
+	}
+
+	// One event at a time, so the index can point at each event's offset
+	for _, event := range kept {
+		offset := f.writer.n
+		if err := f.writeEvents([]sinks.Event{event}); err != nil {
+			return err
+		}
+		f.updateIndex(event, offset)
+
+		if f.eventCount == 0 || event.BlockNumber < f.fileFirstBlock {
+			f.fileFirstBlock = event.BlockNumber
+		}
+		if event.BlockNumber > f.fileLastBlock {
+			f.fileLastBlock = event.BlockNumber
+		}
+		f.eventCount++
+		f.totalEvents++
+	}
+
+	if err := f.bufferedWriter.Flush(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", f.currentFile.Name(), err)
+	}
+	if f.indexWriter != nil {
+		if err := f.indexWriter.Flush(); err != nil {
+			return fmt.Errorf("failed to write index: %w", err)
+		}
+	}
+
 	return nil
 }
 
-// Close cleanly shuts down the filesystem sink
-func (f *FilesystemSink) Close() error {
-	// TODO: Implement
-	// - Signal shutdown
-	// - Wait for background workers
-	// - Close current file
-	// - Write final metadata
-	// - Close index
-	// - Print statistics
+// writeEvents writes events in the configured format
+func (f *FilesystemSink) writeEvents(events []sinks.Event) error {
+	switch f.config.Format {
+	case FormatJSONL:
+		return f.writeJSONL(events)
+	case FormatCSV:
+		return f.writeCSV(events)
+	case FormatText:
+		return f.writeText(events)
+	default:
+		return f.writeJSON(events)
+	}
+}
+
+// Flush makes everything written so far durable. A json file only becomes a
+// complete JSON document once it is rotated or the sink is closed.
+func (f *FilesystemSink) Flush(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.currentFile == nil {
+		return nil
+	}
+	if err := f.bufferedWriter.Flush(); err != nil {
+		return fmt.Errorf("failed to flush %s: %w", f.currentFile.Name(), err)
+	}
+	if f.gzipWriter != nil {
+		if err := f.gzipWriter.Flush(); err != nil {
+			return fmt.Errorf("failed to flush %s: %w", f.currentFile.Name(), err)
+		}
+	}
+	if err := f.currentFile.Sync(); err != nil {
+		return fmt.Errorf("failed to sync %s: %w", f.currentFile.Name(), err)
+	}
 	return nil
 }
 
-// createDirectoryStructure creates the output directory and subdirectories
+// Stats implements sinks.StatReporter
+func (f *FilesystemSink) Stats() map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var currentBytes int64
+	if f.writer != nil {
+		currentBytes = f.writer.n
+	}
+
+	return map[string]interface{}{
+		"total_events":   f.totalEvents,
+		"total_files":    f.totalFiles,
+		"current_events": f.eventCount,
+		"current_bytes":  currentBytes,
+	}
+}
+
+// Close archives the current file, finalizes the export manifest and stops the
+// rotation worker
+func (f *FilesystemSink) Close() error {
+	close(f.done)
+	f.wg.Wait()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var errs []error
+	if err := f.closeCurrentFile(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := f.writeMetadata(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := f.finalizeManifest(); err != nil {
+		errs = append(errs, err)
+	}
+	if f.indexFile != nil {
+		if err := f.indexWriter.Flush(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to write index: %w", err))
+		}
+		if err := f.indexFile.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close index: %w", err))
+		}
+		f.indexFile = nil
+		f.indexWriter = nil
+	}
+
+	f.logger.Info("Closing filesystem sink", map[string]interface{}{
+		"total_events": f.totalEvents,
+		"total_files":  f.totalFiles,
+	})
+
+	return errors.Join(errs...)
+}
+
+// createDirectoryStructure creates the output directory and its current, archive,
+// metadata and index subdirectories
 func (f *FilesystemSink) createDirectoryStructure() error {
-	// TODO: Implement
-	// - Create main output directory
-	// - Create subdirectories: current, archive, metadata, index
+	for _, dir := range []string{"current", "archive", "metadata", "index"} {
+		path := filepath.Join(f.config.OutputDir, dir)
+		if err := os.MkdirAll(path, 0o755); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", path, err)
+		}
+	}
 	return nil
 }
 
-// openNewFile creates and opens a new file for writing
+// openNewFile creates a file in the current directory and sets up the writer chain:
+// bytes are counted, buffered, optionally gzipped and written to the file.
+// Callers must hold f.mu.
 func (f *FilesystemSink) openNewFile() error {
-	// TODO: Implement
-	// - Generate filename with timestamp
-	// - Create file in current directory
-	// - Setup writer chain (compression, buffering)
-	// - Setup format-specific writers (CSV)
-	// - Create index file if needed
+	now := time.Now()
+	path := filepath.Join(f.config.OutputDir, "current", f.generateFilename(now))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+
+	var out io.Writer = file
+	f.gzipWriter = nil
+	if f.config.Compress {
+		f.gzipWriter = gzip.NewWriter(file)
+		out = f.gzipWriter
+	}
+	f.currentFile = file
+	f.bufferedWriter = bufio.NewWriterSize(out, f.config.BufferSize)
+	f.writer = &countingWriter{w: f.bufferedWriter}
+	f.csvWriter = nil
+
+	f.eventCount = 0
+	f.fileFirstBlock = 0
+	f.fileLastBlock = 0
+	f.fileStartTime = now
+	f.totalFiles++
+	f.updateRotationTime()
+
+	switch f.config.Format {
+	case FormatCSV:
+		f.csvWriter = csv.NewWriter(f.writer)
+		if err := f.writeCSVHeader(); err != nil {
+			return err
+		}
+	case FormatJSON:
+		if err := f.openJSONArray(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-// closeCurrentFile properly closes the current file
+// closeCurrentFile finishes the current file, moves it to the archive and records
+// it in the export manifest. It does nothing if no file is open. Callers must hold f.mu.
 func (f *FilesystemSink) closeCurrentFile() error {
-	// TODO: Implement
-	// - Flush all writers
-	// - Close compression writer if enabled
-	// - Close file
-	// - Archive the file
-	return nil
+	if f.currentFile == nil {
+		return nil
+	}
+
+	file := f.currentFile
+	f.currentFile = nil
+	path := file.Name()
+
+	err := f.finishFile(file)
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to finish %s: %w", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", path, err)
+	}
+
+	archived, err := f.archiveFile(path)
+	if err != nil {
+		return err
+	}
+
+	f.logger.Info("Wrote event file", map[string]interface{}{
+		"path":        archived,
+		"event_count": f.eventCount,
+		"bytes":       f.writer.n,
+		"from_block":  f.fileFirstBlock,
+		"to_block":    f.fileLastBlock,
+	})
+
+	return f.recordManifestFile(archived, f.fileFirstBlock, f.fileLastBlock, f.eventCount)
+}
+
+// finishFile closes the JSON array, flushes every writer and syncs file
+func (f *FilesystemSink) finishFile(file *os.File) error {
+	if f.config.Format == FormatJSON {
+		if err := f.closeJSONArray(); err != nil {
+			return err
+		}
+	}
+	if err := f.bufferedWriter.Flush(); err != nil {
+		return err
+	}
+	if f.gzipWriter != nil {
+		if err := f.gzipWriter.Close(); err != nil {
+			return err
+		}
+	}
+	return file.Sync()
 }
 
-// rotateFile closes the current file and opens a new one
+// rotateFile closes the current file and opens a new one. Callers must hold f.mu.
 func (f *FilesystemSink) rotateFile() error {
-	// TODO: Implement
-	// - Close current file
-	// - Open new file
-	// - Update rotation time
-	return nil
+	if err := f.closeCurrentFile(); err != nil {
+		return err
+	}
+	return f.openNewFile()
 }
 
-// shouldRotate checks if the current file should be rotated
+// shouldRotate checks if the current file should be rotated. Callers must hold f.mu.
 func (f *FilesystemSink) shouldRotate() bool {
-	// TODO: Implement
-	// - Check based on rotation strategy
-	// - Size, events, time, or daily
+	switch f.config.RotationStrategy {
+	case RotateBySize:
+		return f.writer.n >= f.config.MaxFileSize
+	case RotateByEvents:
+		return f.eventCount >= f.config.MaxEvents
+	case RotateByTime, RotateDaily:
+		return !time.Now().Before(f.rotationTime)
+	}
 	return false
 }
 
-// updateRotationTime sets the next rotation time
+// updateRotationTime sets the time the file opened now is rotated at: after
+// RotationInterval, or at the next midnight in Location for daily rotation
 func (f *FilesystemSink) updateRotationTime() {
-	// TODO: Implement
-	// - Calculate next rotation time based on strategy
+	switch f.config.RotationStrategy {
+	case RotateByTime:
+		f.rotationTime = f.fileStartTime.Add(f.config.RotationInterval)
+	case RotateDaily:
+		f.rotationTime = nextMidnight(f.fileStartTime, f.config.Location)
+	default:
+		f.rotationTime = time.Time{}
+	}
+}
+
+// nextMidnight returns the start of the day after t in loc
+func nextMidnight(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
 }
 
-// rotationWorker handles time-based rotation in the background
+// rotationWorker archives the current file once its rotation time has passed, so
+// files are closed on time even when no events arrive
 func (f *FilesystemSink) rotationWorker() {
-	// TODO: Implement
-	// - Run in goroutine
-	// - Check periodically if rotation is needed
-	// - Handle shutdown signal
+	defer f.wg.Done()
+
+	ticker := time.NewTicker(time.Minute)
+	if f.config.RotationStrategy == RotateByTime && f.config.RotationInterval < time.Minute {
+		ticker.Reset(f.config.RotationInterval)
+	}
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.done:
+			return
+		case <-ticker.C:
+			f.mu.Lock()
+			if f.currentFile != nil && f.shouldRotate() {
+				if err := f.closeCurrentFile(); err != nil {
+					f.logger.Error("Failed to rotate file", err)
+				}
+			}
+			f.mu.Unlock()
+		}
+	}
 }
 
-// generateFilename creates a filename based on configuration
+// generateFilename creates a filename from the prefix, the time in Location and
+// the file counter, e.g. usdc-events-20240115-093000-000042.json.gz
 func (f *FilesystemSink) generateFilename(timestamp time.Time) string {
-	// TODO: Implement
-	// - Build filename with prefix, timestamp, and counter
-	// - Add appropriate extension
-	// - Add .gz if compression is enabled
-	return ""
+	name := fmt.Sprintf("%s-%s-%06d.%s",
+		f.config.FilePrefix,
+		timestamp.In(f.config.Location).Format("20060102-150405"),
+		f.totalFiles+1,
+		f.getFileExtension())
+	if f.config.Compress {
+		name += ".gz"
+	}
+	return name
 }
 
-// getFileExtension returns the appropriate file extension
+// getFileExtension returns the file extension of the configured format
 func (f *FilesystemSink) getFileExtension() string {
-	// TODO: Implement
-	// - Return extension based on format
-	return ""
+	switch f.config.Format {
+	case FormatJSONL:
+		return "jsonl"
+	case FormatCSV:
+		return "csv"
+	case FormatText:
+		return "txt"
+	default:
+		return "json"
+	}
 }
 
-// archiveFile moves a file from current to archive directory
-func (f *FilesystemSink) archiveFile(filename string) error {
-	// TODO: Implement
-	// - Create year/month subdirectory in archive
-	// - Move file from current to archive
-	return nil
+// archiveFile moves a file from the current directory to archive/<year>/<month>,
+// by the time the file was opened in Location, and returns its new path
+func (f *FilesystemSink) archiveFile(filename string) (string, error) {
+	opened := f.fileStartTime.In(f.config.Location)
+	dir := filepath.Join(f.config.OutputDir, "archive",
+		fmt.Sprintf("%04d", opened.Year()), fmt.Sprintf("%02d", opened.Month()))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create archive directory %s: %w", dir, err)
+	}
+
+	archived := filepath.Join(dir, filepath.Base(filename))
+	if err := os.Rename(filename, archived); err != nil {
+		return "", fmt.Errorf("failed to archive %s: %w", filename, err)
+	}
+	return archived, nil
 }
 
-// writeJSON writes events in pretty-printed JSON format
+// writeJSON appends events to the current file's JSON array, pretty-printed and
+// separated by commas, so each file is a single valid JSON document once closed
 func (f *FilesystemSink) writeJSON(events []sinks.Event) error {
-	// TODO: Implement
-	// - Use JSON encoder with indentation
-	// - Convert each event to JSON
-	// - Track file size
+	for _, event := range events {
+		data, err := json.MarshalIndent(f.eventToJSON(event), "  ", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal event: %w", err)
+		}
+
+		separator := "  "
+		if f.jsonEvents > 0 {
+			separator = ",\n  "
+		}
+		if _, err := io.WriteString(f.writer, separator+string(data)); err != nil {
+			return fmt.Errorf("failed to write JSON event: %w", err)
+		}
+		f.jsonEvents++
+	}
+	return nil
+}
+
+// openJSONArray starts the JSON array of a newly opened file
+func (f *FilesystemSink) openJSONArray() error {
+	f.jsonEvents = 0
+	if _, err := io.WriteString(f.writer, "[\n"); err != nil {
+		return fmt.Errorf("failed to open JSON array: %w", err)
+	}
+	return nil
+}
+
+// closeJSONArray ends the current file's JSON array before it is rotated or the
+// sink is closed. A file that received no events is left as an empty array.
+func (f *FilesystemSink) closeJSONArray() error {
+	closing := "]\n"
+	if f.jsonEvents > 0 {
+		closing = "\n]\n"
+	}
+	if _, err := io.WriteString(f.writer, closing); err != nil {
+		return fmt.Errorf("failed to close JSON array: %w", err)
+	}
 	return nil
 }
 
-// writeJSONL writes events in JSON Lines format (one JSON per line)
+// writeJSONL writes events in JSON Lines format, one compact JSON object per line
 func (f *FilesystemSink) writeJSONL(events []sinks.Event) error {
-	// TODO: Implement
-	// - Write one JSON object per line
-	// - No pretty printing
-	// - Track file size
+	for _, event := range events {
+		data, err := json.Marshal(f.eventToJSON(event))
+		if err != nil {
+			return fmt.Errorf("failed to marshal event: %w", err)
+		}
+		if _, err := f.writer.Write(append(data, '\n')); err != nil {
+			return fmt.Errorf("failed to write JSON line: %w", err)
+		}
+	}
 	return nil
 }
 
-// writeCSV writes events in CSV format
+// writeCSV writes events in CSV format, one row per log entry. The CSV writer
+// quotes fields containing commas, quotes or newlines.
 func (f *FilesystemSink) writeCSV(events []sinks.Event) error {
-	// TODO: Implement
-	// - Write events as CSV rows
-	// - One row per log entry
-	// - Use CSV writer
-	return nil
+	for _, event := range events {
+		for _, log := range event.Logs {
+			if err := f.csvWriter.Write(f.eventToCSV(event, log)); err != nil {
+				return fmt.Errorf("failed to write CSV row: %w", err)
+			}
+		}
+	}
+
+	// Pushes the rows through the counting writer, so size rotation sees them
+	f.csvWriter.Flush()
+	return f.csvWriter.Error()
 }
 
-// writeCSVHeader writes the CSV header row
-func (f *FilesystemSink) writeCSVHeader() {
-	// TODO: Implement
-	// - Write column headers
+// writeCSVHeader writes the CSV header row. Called once per file, before any rows.
+func (f *FilesystemSink) writeCSVHeader() error {
+	if err := f.csvWriter.Write(csvHeader); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	return nil
 }
 
-// writeText writes events in human-readable text format
+// writeText writes events in human-readable text format, one block of lines per event
 func (f *FilesystemSink) writeText(events []sinks.Event) error {
-	// TODO: Implement
-	// - Format events as readable text
-	// - Include block, tx, and event details
-	// - Track file size
+	for _, event := range events {
+		if _, err := io.WriteString(f.writer, f.eventToText(event)); err != nil {
+			return fmt.Errorf("failed to write text event: %w", err)
+		}
+	}
 	return nil
 }
 
-// eventToJSON converts an event to JSON format
-func (f *FilesystemSink) eventToJSON(event sinks.Event) map[string]interface{} {
-	// TODO: Implement
-	// - Convert event to JSON structure
-	// - Include all relevant fields
-	// - Decode known event types
-	return nil
+// eventToJSON converts an event to the shared sinks.EventJSON format
+func (f *FilesystemSink) eventToJSON(event sinks.Event) sinks.EventJSON {
+	return sinks.NewEventJSON(event, f.config.ReceiptFields)
 }
 
-// eventToCSV converts an event to CSV format
+// eventToCSV converts a log to a CSV row in csvHeader order. Columns that do not
+// apply to the event type are left empty.
 func (f *FilesystemSink) eventToCSV(event sinks.Event, log *types.Log) []string {
-	// TODO: Implement
-	// - Convert event and log to CSV row
-	// - Extract relevant fields
-	// - Decode event-specific data
-	return nil
+	row := []string{
+		strconv.FormatUint(event.BlockNumber, 10),
+		event.Timestamp().UTC().Format(time.RFC3339),
+		event.Receipt.TxHash.Hex(),
+		strconv.FormatUint(uint64(log.Index), 10),
+		"Unknown",
+		"", // from
+		"", // to
+		"", // value
+		"", // value_usdc
+	}
+
+	decoded, found := event.Decode(log)
+	if !found {
+		return row
+	}
+	row[4] = string(decoded.Event)
+
+	switch decoded.Event {
+	case erc20.Transfer:
+		row[5], row[6] = decoded.From, decoded.To
+	case erc20.Approval:
+		row[5], row[6] = decoded.Owner, decoded.Spender
+	case erc20.Upgraded:
+		row[6] = decoded.Implementation
+	}
+
+	if decoded.Value != nil {
+		row[7] = decoded.Value.String()
+		row[8] = erc20.FormatDecimal(decoded.Value, sinks.TrackedToken.Decimals)
+	}
+
+	return row
 }
 
-// eventToText converts an event to text format
+// eventToText converts an event to text format: a header line with the block, time,
+// transaction and status, then one indented line per log with amounts formatted by
+// erc20.FormatAmount, e.g. "  Transfer 0xA... -> 0xB...: 1,234,567.89 USDC"
 func (f *FilesystemSink) eventToText(event sinks.Event) string {
-	// TODO: Implement
-	// - Format event as human-readable text
-	// - Include all important details
-	// - Make it easy to read
-	return ""
+	var b strings.Builder
+	fmt.Fprintf(&b, "Block #%d | %s | Tx %s | %s\n",
+		event.BlockNumber,
+		event.Timestamp().UTC().Format(time.RFC3339),
+		event.Receipt.TxHash.Hex(),
+		f.statusText(event.Receipt.Status))
+
+	for _, log := range event.Logs {
+		decoded, found := event.Decode(log)
+		if !found {
+			fmt.Fprintf(&b, "  Unknown event at %s\n", log.Address.Hex())
+			continue
+		}
+
+		switch decoded.Event {
+		case erc20.Transfer:
+			fmt.Fprintf(&b, "  Transfer %s -> %s: %s\n", decoded.From, decoded.To, f.formatValue(decoded.Value))
+		case erc20.Approval:
+			amount := f.formatValue(decoded.Value)
+			if erc20.IsInfiniteApproval(decoded.Value) {
+				amount = erc20.InfiniteApprovalValue
+			}
+			fmt.Fprintf(&b, "  Approval %s -> %s: %s\n", decoded.Owner, decoded.Spender, amount)
+		case erc20.Upgraded:
+			fmt.Fprintf(&b, "  Upgraded %s to %s\n", log.Address.Hex(), decoded.Implementation)
+		default:
+			fmt.Fprintf(&b, "  %s at %s\n", decoded.Event, log.Address.Hex())
+		}
+	}
+	b.WriteByte('\n')
+
+	return b.String()
+}
+
+// formatValue formats a decoded amount of the tracked token for the text format
+func (f *FilesystemSink) formatValue(value *big.Int) string {
+	return erc20.FormatAmount(value, sinks.TrackedToken.Decimals, sinks.TrackedToken.Symbol)
 }
 
 // statusText returns human-readable status
 func (f *FilesystemSink) statusText(status uint64) string {
-	// TODO: Implement
-	// - Convert status code to text
-	return ""
+	if status == types.ReceiptStatusSuccessful {
+		return "Success"
+	}
+	return "Failed"
 }
 
-// topicsToStrings converts topics to string array
-func (f *FilesystemSink) topicsToStrings(topics []common.Hash) []string {
-	// TODO: Implement
-	// - Convert each topic to hex string
-	return nil
+// indexEntry is a line of index/<prefix>.jsonl locating one event. The offset is
+// in the uncompressed content of the file, which is named without its directory
+// because files move from current to archive when they are rotated.
+type indexEntry struct {
+	BlockNumber uint64 `json:"block_number"`
+	TxHash      string `json:"tx_hash"`
+	File        string `json:"file"`
+	Offset      int64  `json:"offset"`
 }
 
-// updateIndex adds entries to the index file
-func (f *FilesystemSink) updateIndex(events []sinks.Event) {
-	// TODO: Implement
-	// - Write index entries for quick lookups
-	// - Include file location and offset
+// updateIndex adds the index entry of an event written at offset of the current
+// file. Callers must hold f.mu.
+func (f *FilesystemSink) updateIndex(event sinks.Event, offset int64) {
+	if f.indexWriter == nil {
+		return
+	}
+
+	data, err := json.Marshal(indexEntry{
+		BlockNumber: event.BlockNumber,
+		TxHash:      event.Receipt.TxHash.Hex(),
+		File:        filepath.Base(f.currentFile.Name()),
+		Offset:      offset,
+	})
+	if err != nil {
+		return
+	}
+	f.indexWriter.Write(append(data, '\n'))
 }
 
-// writeMetadata creates a metadata file with sink statistics
+// sinkMetadata is the content of metadata/sink.json
+type sinkMetadata struct {
+	Format      FileFormat       `json:"format"`
+	FilePrefix  string           `json:"file_prefix"`
+	Rotation    RotationStrategy `json:"rotation"`
+	Compression string           `json:"compression"`
+	TotalEvents int64            `json:"total_events"`
+	TotalFiles  int              `json:"total_files"`
+	UpdatedAt   string           `json:"updated_at"`
+}
+
+// writeMetadata writes the sink's configuration and statistics to
+// metadata/sink.json. Callers must hold f.mu.
 func (f *FilesystemSink) writeMetadata() error {
-	// TODO: Implement
-	// - Write statistics and configuration
-	// - JSON format in metadata directory
+	data, err := json.MarshalIndent(sinkMetadata{
+		Format:      f.config.Format,
+		FilePrefix:  f.config.FilePrefix,
+		Rotation:    f.config.RotationStrategy,
+		Compression: f.compressionName(),
+		TotalEvents: f.totalEvents,
+		TotalFiles:  f.totalFiles,
+		UpdatedAt:   time.Now().UTC().Format(time.RFC3339Nano),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	path := filepath.Join(f.config.OutputDir, "metadata", "sink.json")
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write metadata %s: %w", path, err)
+	}
 	return nil
-}
\ No newline at end of file
+}
+
+// countingWriter tracks the number of bytes written to the current file
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
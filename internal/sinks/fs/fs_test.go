@@ -0,0 +1,340 @@
+package fs
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"usdc-event-tracker/internal/erc20"
+	"usdc-event-tracker/internal/sinks"
+)
+
+var (
+	token = common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	alice = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	bob   = common.HexToAddress("0x2222222222222222222222222222222222222222")
+)
+
+// transferEvent returns an event with a single Transfer of value base units
+func transferEvent(blockNumber uint64, value int64) sinks.Event {
+	txHash := common.BigToHash(new(big.Int).SetUint64(blockNumber))
+	log := &types.Log{
+		Address: token,
+		Topics: []common.Hash{
+			common.HexToHash(erc20.EventSignatures[erc20.Transfer]),
+			common.BytesToHash(alice.Bytes()),
+			common.BytesToHash(bob.Bytes()),
+		},
+		Data:        common.BigToHash(big.NewInt(value)).Bytes(),
+		BlockNumber: blockNumber,
+		TxHash:      txHash,
+	}
+	return sinks.Event{
+		BlockNumber: blockNumber,
+		Receipt: &types.Receipt{
+			Status:      types.ReceiptStatusSuccessful,
+			TxHash:      txHash,
+			BlockNumber: new(big.Int).SetUint64(blockNumber),
+			Logs:        []*types.Log{log},
+		},
+		Logs:      []*types.Log{log},
+		BlockTime: time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC),
+	}
+}
+
+// newTestSink returns an initialized sink writing to a temporary directory
+func newTestSink(t *testing.T, config Config) *FilesystemSink {
+	t.Helper()
+
+	config.OutputDir = t.TempDir()
+	f := New(config)
+	if err := f.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	return f
+}
+
+// write writes one event per block to f
+func write(t *testing.T, f *FilesystemSink, blocks ...uint64) {
+	t.Helper()
+
+	for _, block := range blocks {
+		if err := f.Write(context.Background(), []sinks.Event{transferEvent(block, int64(block)*1_000_000)}); err != nil {
+			t.Fatalf("Write block %d: %v", block, err)
+		}
+	}
+}
+
+// archived returns the paths of the archived files in name order
+func archived(t *testing.T, f *FilesystemSink) []string {
+	t.Helper()
+
+	paths, err := filepath.Glob(filepath.Join(f.config.OutputDir, "archive", "*", "*", "*"))
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	return paths
+}
+
+func TestWriteJSONRotatesAndArchives(t *testing.T) {
+	f := newTestSink(t, Config{RotationStrategy: RotateByEvents, MaxEvents: 2})
+
+	write(t, f, 1, 2, 3)
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	files := archived(t, f)
+	if len(files) != 2 {
+		t.Fatalf("archived %v, want 2 files", files)
+	}
+
+	var blocks []uint64
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		var events []sinks.EventJSON
+		if err := json.Unmarshal(data, &events); err != nil {
+			t.Fatalf("%s is not a JSON array: %v\n%s", path, err, data)
+		}
+		for _, event := range events {
+			blocks = append(blocks, event.BlockNumber)
+		}
+	}
+	if len(blocks) != 3 || blocks[0] != 1 || blocks[1] != 2 || blocks[2] != 3 {
+		t.Errorf("archived files hold blocks %v, want [1 2 3]", blocks)
+	}
+
+	current, _ := os.ReadDir(filepath.Join(f.config.OutputDir, "current"))
+	if len(current) != 0 {
+		t.Errorf("current directory holds %d files after Close, want 0", len(current))
+	}
+}
+
+func TestNoFileWithoutEvents(t *testing.T) {
+	f := newTestSink(t, Config{})
+
+	if err := f.Write(context.Background(), nil); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if files := archived(t, f); len(files) != 0 {
+		t.Errorf("archived %v without events, want no files", files)
+	}
+}
+
+func TestIndexOffsetsPointAtEvents(t *testing.T) {
+	f := newTestSink(t, Config{Format: FormatJSONL, CreateIndex: true})
+
+	write(t, f, 7, 8)
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	files := archived(t, f)
+	if len(files) != 1 {
+		t.Fatalf("archived %v, want 1 file", files)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	index, err := os.Open(filepath.Join(f.config.OutputDir, "index", "usdc-events.jsonl"))
+	if err != nil {
+		t.Fatalf("Open index: %v", err)
+	}
+	defer index.Close()
+
+	entries := 0
+	scanner := bufio.NewScanner(index)
+	for scanner.Scan() {
+		var entry indexEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("index line %q: %v", scanner.Text(), err)
+		}
+		if entry.File != filepath.Base(files[0]) {
+			t.Errorf("index names %s, want %s", entry.File, filepath.Base(files[0]))
+		}
+
+		line, _, _ := strings.Cut(string(data[entry.Offset:]), "\n")
+		var event sinks.EventJSON
+		if err := json.Unmarshal([]byte(line), &event); err != nil || event.BlockNumber != entry.BlockNumber {
+			t.Errorf("offset %d of block %d points at %q", entry.Offset, entry.BlockNumber, line)
+		}
+		entries++
+	}
+	if entries != 2 {
+		t.Errorf("index has %d entries, want 2", entries)
+	}
+}
+
+func TestExportManifest(t *testing.T) {
+	f := newTestSink(t, Config{
+		RotationStrategy: RotateByEvents,
+		MaxEvents:        2,
+		Compress:         true,
+		ExportFromBlock:  10,
+		ExportToBlock:    13,
+	})
+
+	write(t, f, 9, 10, 11, 12, 13, 14)
+
+	readManifest := func() Manifest {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(f.config.OutputDir, "metadata", "manifest.json"))
+		if err != nil {
+			t.Fatalf("ReadFile manifest: %v", err)
+		}
+		var manifest Manifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			t.Fatalf("Unmarshal manifest: %v", err)
+		}
+		return manifest
+	}
+
+	// Rotated once after blocks 10 and 11, before the sink is closed
+	if manifest := readManifest(); len(manifest.Files) != 1 || manifest.Finalized {
+		t.Fatalf("manifest before Close has %d files, finalized %v; want 1 unfinalized file", len(manifest.Files), manifest.Finalized)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	manifest := readManifest()
+	if !manifest.Finalized || manifest.TotalEvents != 4 || manifest.Compression != "gzip" {
+		t.Errorf("manifest = finalized %v, %d events, compression %q; want finalized, 4 events, gzip",
+			manifest.Finalized, manifest.TotalEvents, manifest.Compression)
+	}
+
+	want := []ManifestFile{{FromBlock: 10, ToBlock: 11, EventCount: 2}, {FromBlock: 12, ToBlock: 13, EventCount: 2}}
+	if len(manifest.Files) != len(want) {
+		t.Fatalf("manifest lists %d files, want %d", len(manifest.Files), len(want))
+	}
+	for i, file := range manifest.Files {
+		if file.FromBlock != want[i].FromBlock || file.ToBlock != want[i].ToBlock || file.EventCount != want[i].EventCount {
+			t.Errorf("file %d covers blocks %d-%d with %d events, want %d-%d with %d",
+				i, file.FromBlock, file.ToBlock, file.EventCount, want[i].FromBlock, want[i].ToBlock, want[i].EventCount)
+		}
+		if !strings.HasPrefix(file.Path, "archive/") || !strings.HasSuffix(file.Path, ".json.gz") {
+			t.Errorf("file %d path %q, want an archived .json.gz path", i, file.Path)
+		}
+
+		sum, size, err := checksumFile(filepath.Join(f.config.OutputDir, file.Path))
+		if err != nil {
+			t.Fatalf("checksum %s: %v", file.Path, err)
+		}
+		if sum != file.SHA256 || size != file.Bytes {
+			t.Errorf("file %d has checksum %s and %d bytes, manifest says %s and %d", i, sum, size, file.SHA256, file.Bytes)
+		}
+	}
+}
+
+func TestDailyRotationUsesLocation(t *testing.T) {
+	// UTC+9, where 2024-01-31 20:00 UTC is already February 1st
+	tokyo := time.FixedZone("UTC+9", 9*60*60)
+	now := time.Date(2024, 1, 31, 20, 0, 0, 0, time.UTC)
+
+	if got, want := nextMidnight(now, tokyo), time.Date(2024, 2, 2, 0, 0, 0, 0, tokyo); !got.Equal(want) {
+		t.Errorf("nextMidnight in UTC+9 = %v, want %v", got, want)
+	}
+	if got, want := nextMidnight(now, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("nextMidnight in UTC = %v, want %v", got, want)
+	}
+
+	f := newTestSink(t, Config{Location: tokyo})
+	if name := f.generateFilename(now); name != "usdc-events-20240201-050000-000001.json" {
+		t.Errorf("generateFilename = %s, want the time in UTC+9", name)
+	}
+
+	write(t, f, 1)
+	f.fileStartTime = now
+	f.rotationTime = time.Now().Add(-time.Second) // Midnight has passed
+	write(t, f, 2)
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	files := archived(t, f)
+	if len(files) != 2 {
+		t.Fatalf("archived %v, want a file per day", files)
+	}
+	if dir := filepath.Dir(files[0]); dir != filepath.Join(f.config.OutputDir, "archive", "2024", "02") {
+		t.Errorf("file opened on February 1st in UTC+9 archived to %s", dir)
+	}
+}
+
+func TestCSVTracksSizeAndRotates(t *testing.T) {
+	f := newTestSink(t, Config{Format: FormatCSV, RotationStrategy: RotateBySize, MaxFileSize: 1})
+
+	write(t, f, 1)
+	if err := f.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	info, err := f.currentFile.Stat()
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if written := f.Stats()["current_bytes"]; written != info.Size() {
+		t.Errorf("current_bytes = %v, want the %d bytes on disk", written, info.Size())
+	}
+
+	// Every file is past MaxFileSize after its first row
+	write(t, f, 2, 3)
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	files := archived(t, f)
+	if len(files) != 3 {
+		t.Fatalf("archived %v, want a file per write", files)
+	}
+
+	file, err := os.Open(files[0])
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("CSV has %d records, want the header and one row", len(records))
+	}
+	if got := strings.Join(records[0], ","); got != strings.Join(csvHeader, ",") {
+		t.Errorf("header = %s", got)
+	}
+
+	want := []string{"1", "2024-01-15T09:30:00Z", transferEvent(1, 0).Receipt.TxHash.Hex(), "0", "Transfer",
+		alice.Hex(), bob.Hex(), "1000000", "1.000000"}
+	if got := strings.Join(records[1], ","); got != strings.Join(want, ",") {
+		t.Errorf("row = %s, want %s", got, strings.Join(want, ","))
+	}
+}
+
+func TestEventToText(t *testing.T) {
+	f := New(Config{})
+
+	event := transferEvent(42, 1_234_567_890_000)
+	want := "Block #42 | 2024-01-15T09:30:00Z | Tx " + event.Receipt.TxHash.Hex() + " | Success\n" +
+		"  Transfer " + alice.Hex() + " -> " + bob.Hex() + ": 1,234,567.89 USDC\n\n"
+	if got := f.eventToText(event); got != want {
+		t.Errorf("eventToText =\n%q\nwant\n%q", got, want)
+	}
+}
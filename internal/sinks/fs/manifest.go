@@ -0,0 +1,170 @@
+package fs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// manifestVersion is bumped whenever the manifest layout changes incompatibly
+const manifestVersion = 1
+
+// Manifest describes a block-range export so downstream tooling can verify that
+// every output file is present and intact. It is written to metadata/manifest.json.
+type Manifest struct {
+	Version     int            `json:"version"`
+	FromBlock   uint64         `json:"from_block"`
+	ToBlock     uint64         `json:"to_block"`
+	Format      FileFormat     `json:"format"`
+	Compression string         `json:"compression"`
+	Files       []ManifestFile `json:"files"`
+	TotalEvents int64          `json:"total_events"`
+	CreatedAt   string         `json:"created_at"`
+	UpdatedAt   string         `json:"updated_at"`
+	Finalized   bool           `json:"finalized"` // Set on Close; an unfinalized manifest is an incomplete export
+}
+
+// ManifestFile is a single rotated output file of an export
+type ManifestFile struct {
+	Path        string `json:"path"` // Relative to the output directory
+	FromBlock   uint64 `json:"from_block"`
+	ToBlock     uint64 `json:"to_block"`
+	EventCount  int    `json:"event_count"`
+	Bytes       int64  `json:"bytes"`
+	SHA256      string `json:"sha256"`
+	Compression string `json:"compression"`
+}
+
+// exportEnabled reports whether the sink runs as a block-range export
+func (f *FilesystemSink) exportEnabled() bool {
+	return f.config.ExportToBlock > 0
+}
+
+// inExportRange reports whether a block belongs to the configured export range.
+// Blocks outside the range are not written in export mode.
+func (f *FilesystemSink) inExportRange(blockNumber uint64) bool {
+	if !f.exportEnabled() {
+		return true
+	}
+	return blockNumber >= f.config.ExportFromBlock && blockNumber <= f.config.ExportToBlock
+}
+
+// newManifest starts an empty manifest for the configured export
+func (f *FilesystemSink) newManifest() *Manifest {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	return &Manifest{
+		Version:     manifestVersion,
+		FromBlock:   f.config.ExportFromBlock,
+		ToBlock:     f.config.ExportToBlock,
+		Format:      f.config.Format,
+		Compression: f.compressionName(),
+		Files:       make([]ManifestFile, 0),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// recordManifestFile adds a rotated file to the manifest and rewrites it.
+// Callers must hold f.mu and pass the file's final (archived) path.
+func (f *FilesystemSink) recordManifestFile(path string, fromBlock, toBlock uint64, eventCount int) error {
+	if !f.exportEnabled() {
+		return nil
+	}
+
+	sum, size, err := checksumFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to checksum %s: %w", path, err)
+	}
+
+	rel, err := filepath.Rel(f.config.OutputDir, path)
+	if err != nil {
+		rel = path
+	}
+
+	f.manifest.Files = append(f.manifest.Files, ManifestFile{
+		Path:        filepath.ToSlash(rel),
+		FromBlock:   fromBlock,
+		ToBlock:     toBlock,
+		EventCount:  eventCount,
+		Bytes:       size,
+		SHA256:      sum,
+		Compression: f.compressionName(),
+	})
+	f.manifest.TotalEvents += int64(eventCount)
+
+	return f.writeManifest()
+}
+
+// finalizeManifest marks the export complete. Called from Close after the last
+// file has been recorded. Callers must hold f.mu.
+func (f *FilesystemSink) finalizeManifest() error {
+	if !f.exportEnabled() {
+		return nil
+	}
+	f.manifest.Finalized = true
+	return f.writeManifest()
+}
+
+// writeManifest atomically replaces metadata/manifest.json, so readers only ever
+// see a complete manifest. Callers must hold f.mu.
+func (f *FilesystemSink) writeManifest() error {
+	f.manifest.UpdatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+
+	data, err := json.MarshalIndent(f.manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	dir := filepath.Join(f.config.OutputDir, "metadata")
+	tmp, err := os.CreateTemp(dir, "manifest-*.json.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create manifest: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync manifest: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close manifest: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, "manifest.json")); err != nil {
+		return fmt.Errorf("failed to replace manifest: %w", err)
+	}
+	return nil
+}
+
+// compressionName returns the compression recorded in the manifest
+func (f *FilesystemSink) compressionName() string {
+	if f.config.Compress {
+		return "gzip"
+	}
+	return "none"
+}
+
+// checksumFile returns the hex SHA-256 and size of a file
+func checksumFile(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, file)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
@@ -0,0 +1,81 @@
+package sinks
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Fee models a transaction can be priced under
+const (
+	FeeModelLegacy  = "legacy"  // Sender-chosen gas price (type 0 and 1 transactions)
+	FeeModelEIP1559 = "eip1559" // Base fee plus priority fee (type 2 and later)
+)
+
+// GasPricing describes what a receipt reports about the price paid for gas.
+// Receipts do not carry the priority fee; EffectiveGasPrice is the base fee
+// plus the priority fee actually paid.
+type GasPricing struct {
+	TxType            string
+	FeeModel          string
+	EffectiveGasPrice *big.Int // nil on nodes or chains that do not report it
+	TxFee             *big.Int // GasUsed * EffectiveGasPrice in wei, nil if the price is not reported
+	BlobGasUsed       uint64
+	BlobGasPrice      *big.Int // nil unless the transaction carried blobs
+}
+
+// ReceiptGasPricing extracts gas pricing from a receipt. Missing fields stay nil
+// rather than being reported as zero.
+func ReceiptGasPricing(receipt *types.Receipt) GasPricing {
+	pricing := GasPricing{
+		TxType:            TxTypeName(receipt.Type),
+		FeeModel:          FeeModelEIP1559,
+		EffectiveGasPrice: receipt.EffectiveGasPrice,
+		TxFee:             TxFee(receipt),
+	}
+	if receipt.Type == types.LegacyTxType || receipt.Type == types.AccessListTxType {
+		pricing.FeeModel = FeeModelLegacy
+	}
+	if receipt.BlobGasUsed > 0 {
+		pricing.BlobGasUsed = receipt.BlobGasUsed
+		pricing.BlobGasPrice = receipt.BlobGasPrice
+	}
+	return pricing
+}
+
+// TxFee returns what the transaction paid for execution gas in the native
+// currency's smallest unit, GasUsed * EffectiveGasPrice, or nil if the receipt
+// does not report the price. Blob gas is not included.
+func TxFee(receipt *types.Receipt) *big.Int {
+	if receipt.EffectiveGasPrice == nil {
+		return nil
+	}
+	return new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
+}
+
+// TxTypeName returns a readable name for a transaction type
+func TxTypeName(txType uint8) string {
+	switch txType {
+	case types.LegacyTxType:
+		return "legacy"
+	case types.AccessListTxType:
+		return "access_list"
+	case types.DynamicFeeTxType:
+		return "dynamic_fee"
+	case types.BlobTxType:
+		return "blob"
+	case types.SetCodeTxType:
+		return "set_code"
+	default:
+		return fmt.Sprintf("type_%d", txType)
+	}
+}
+
+// BigString formats v as a decimal string, returning "" for nil
+func BigString(v *big.Int) string {
+	if v == nil {
+		return ""
+	}
+	return v.String()
+}
@@ -0,0 +1,67 @@
+package sinks
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestTxFee(t *testing.T) {
+	tests := []struct {
+		name    string
+		receipt *types.Receipt
+		want    *big.Int
+	}{
+		{
+			name:    "reported price",
+			receipt: &types.Receipt{GasUsed: 21_000, EffectiveGasPrice: big.NewInt(30_000_000_000)},
+			want:    big.NewInt(630_000_000_000_000),
+		},
+		{
+			name:    "zero price",
+			receipt: &types.Receipt{GasUsed: 21_000, EffectiveGasPrice: big.NewInt(0)},
+			want:    big.NewInt(0),
+		},
+		{
+			name:    "price not reported",
+			receipt: &types.Receipt{GasUsed: 21_000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TxFee(tt.receipt)
+			if (got == nil) != (tt.want == nil) || (got != nil && got.Cmp(tt.want) != 0) {
+				t.Errorf("TxFee = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNilEffectiveGasPrice(t *testing.T) {
+	receipt := &types.Receipt{
+		Type:        types.LegacyTxType,
+		Status:      types.ReceiptStatusSuccessful,
+		TxHash:      common.HexToHash("0x01"),
+		BlockNumber: big.NewInt(10),
+		GasUsed:     21_000,
+	}
+
+	pricing := ReceiptGasPricing(receipt)
+	if pricing.EffectiveGasPrice != nil || pricing.TxFee != nil {
+		t.Errorf("ReceiptGasPricing = price %v, fee %v; want both nil", pricing.EffectiveGasPrice, pricing.TxFee)
+	}
+	if pricing.FeeModel != FeeModelLegacy || pricing.TxType != "legacy" {
+		t.Errorf("ReceiptGasPricing = %s, %s; want legacy, legacy", pricing.TxType, pricing.FeeModel)
+	}
+
+	doc := NewEventJSON(Event{BlockNumber: 10, Receipt: receipt}, nil)
+	if doc.EffectiveGasPrice != "" || doc.TxFeeWei != "" {
+		t.Errorf("NewEventJSON = price %q, fee %q; want both omitted", doc.EffectiveGasPrice, doc.TxFeeWei)
+	}
+	if doc.GasUsed == nil || *doc.GasUsed != 21_000 {
+		t.Errorf("NewEventJSON gas used = %v, want 21000", doc.GasUsed)
+	}
+}
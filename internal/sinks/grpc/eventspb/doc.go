@@ -0,0 +1,4 @@
+// Package eventspb contains the protobuf messages and gRPC service for streaming USDC events.
+package eventspb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative events.proto
@@ -0,0 +1,326 @@
+// Package grpc implements a sink that streams events to gRPC subscribers
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"usdc-event-tracker/internal/logging"
+	"usdc-event-tracker/internal/sinks"
+	"usdc-event-tracker/internal/sinks/grpc/eventspb"
+	"usdc-event-tracker/internal/usdc"
+)
+
+// SlowConsumerPolicy defines what happens when a subscriber's buffer is full
+type SlowConsumerPolicy string
+
+const (
+	DropEvents SlowConsumerPolicy = "drop"       // Drop events the subscriber cannot keep up with
+	Disconnect SlowConsumerPolicy = "disconnect" // Terminate the subscriber's stream
+)
+
+// Config holds gRPC sink configuration
+type Config struct {
+	Port         int                // Port the gRPC server listens on
+	BufferSize   int                // Per-subscriber event buffer
+	SlowConsumer SlowConsumerPolicy // Policy when a subscriber's buffer is full
+}
+
+// Sink implements the sinks.Sink interface by fanning out events to gRPC subscribers.
+type Sink struct {
+	eventspb.UnimplementedEventStreamServer
+
+	config Config
+	server *gogrpc.Server
+	logger *logging.Logger
+
+	mu          sync.Mutex
+	subscribers map[uint64]*subscriber
+	nextID      uint64
+
+	// Metrics
+	totalEvents   int64
+	droppedEvents int64
+}
+
+// subscriber is a connected SubscribeEvents stream
+type subscriber struct {
+	events     chan *eventspb.Event
+	kicked     chan struct{}
+	eventTypes map[string]bool
+}
+
+func init() {
+	sinks.Register("grpc", func(opts sinks.Options) (sinks.Sink, error) {
+		return New(NewConfig()), nil
+	})
+}
+
+// NewConfig creates a new gRPC sink configuration from environment variables
+func NewConfig() Config {
+	config := Config{
+		Port:         50051,
+		BufferSize:   256,
+		SlowConsumer: DropEvents,
+	}
+
+	if port := os.Getenv("GRPC_PORT"); port != "" {
+		if p, err := strconv.Atoi(port); err == nil && p > 0 {
+			config.Port = p
+		}
+	}
+
+	if size := os.Getenv("GRPC_BUFFER_SIZE"); size != "" {
+		if n, err := strconv.Atoi(size); err == nil && n > 0 {
+			config.BufferSize = n
+		}
+	}
+
+	if policy := strings.ToLower(os.Getenv("GRPC_SLOW_CONSUMER")); policy == string(Disconnect) {
+		config.SlowConsumer = Disconnect
+	}
+
+	return config
+}
+
+// New creates a new gRPC sink
+func New(config Config) *Sink {
+	return &Sink{
+		config:      config,
+		logger:      logging.GetLogger("grpc-sink"),
+		subscribers: make(map[uint64]*subscriber),
+	}
+}
+
+// Name returns the sink name
+func (s *Sink) Name() string {
+	return "grpc"
+}
+
+// Initialize starts the gRPC server
+func (s *Sink) Initialize(ctx context.Context) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.config.Port))
+	if err != nil {
+		s.logger.Error("Failed to listen for gRPC connections", err, map[string]interface{}{
+			"port": s.config.Port,
+		})
+		return fmt.Errorf("failed to listen on port %d: %w", s.config.Port, err)
+	}
+
+	s.server = gogrpc.NewServer()
+	eventspb.RegisterEventStreamServer(s.server, s)
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil {
+			s.logger.Error("gRPC server stopped", err)
+		}
+	}()
+
+	s.logger.Info("gRPC event stream server started", map[string]interface{}{
+		"port":          s.config.Port,
+		"buffer_size":   s.config.BufferSize,
+		"slow_consumer": s.config.SlowConsumer,
+	})
+
+	return nil
+}
+
+// Write fans out events to all connected subscribers without blocking
+func (s *Sink) Write(ctx context.Context, events []sinks.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, event := range events {
+		msg := s.eventToMessage(event)
+		s.totalEvents++
+
+		for id, sub := range s.subscribers {
+			select {
+			case sub.events <- msg:
+			default:
+				s.handleSlowConsumer(id, sub)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Close disconnects all subscribers and stops the gRPC server
+func (s *Sink) Close() error {
+	s.mu.Lock()
+	for id, sub := range s.subscribers {
+		close(sub.kicked)
+		delete(s.subscribers, id)
+	}
+	s.mu.Unlock()
+
+	if s.server != nil {
+		s.server.GracefulStop()
+	}
+
+	s.logger.Info("Closing gRPC sink", map[string]interface{}{
+		"total_events":   s.totalEvents,
+		"dropped_events": s.droppedEvents,
+	})
+
+	return nil
+}
+
+// Stats returns fan-out metrics and the number of connected subscribers
+func (s *Sink) Stats() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return map[string]interface{}{
+		"total_events":   s.totalEvents,
+		"dropped_events": s.droppedEvents,
+		"subscribers":    len(s.subscribers),
+	}
+}
+
+// SubscribeEvents streams events written after the subscription starts
+func (s *Sink) SubscribeEvents(req *eventspb.SubscribeRequest, stream gogrpc.ServerStreamingServer[eventspb.Event]) error {
+	sub := &subscriber{
+		events:     make(chan *eventspb.Event, s.config.BufferSize),
+		kicked:     make(chan struct{}),
+		eventTypes: make(map[string]bool, len(req.GetEventTypes())),
+	}
+	for _, eventType := range req.GetEventTypes() {
+		sub.eventTypes[eventType] = true
+	}
+
+	s.mu.Lock()
+	id := s.nextID
+	s.nextID++
+	s.subscribers[id] = sub
+	subscriberCount := len(s.subscribers)
+	s.mu.Unlock()
+
+	s.logger.Info("gRPC subscriber connected", map[string]interface{}{
+		"subscriber_id": id,
+		"event_types":   req.GetEventTypes(),
+		"subscribers":   subscriberCount,
+	})
+
+	defer s.removeSubscriber(id)
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-sub.kicked:
+			return status.Error(codes.ResourceExhausted, "subscriber disconnected")
+		case msg := <-sub.events:
+			if msg = sub.filter(msg); msg == nil {
+				continue
+			}
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// handleSlowConsumer applies the slow consumer policy. Callers must hold s.mu.
+func (s *Sink) handleSlowConsumer(id uint64, sub *subscriber) {
+	s.droppedEvents++
+
+	if s.config.SlowConsumer != Disconnect {
+		return
+	}
+
+	close(sub.kicked)
+	delete(s.subscribers, id)
+
+	s.logger.Warn("Disconnected slow gRPC subscriber", map[string]interface{}{
+		"subscriber_id": id,
+		"buffer_size":   s.config.BufferSize,
+	})
+}
+
+// removeSubscriber unregisters a subscriber whose stream has ended
+func (s *Sink) removeSubscriber(id uint64) {
+	s.mu.Lock()
+	delete(s.subscribers, id)
+	s.mu.Unlock()
+
+	s.logger.Info("gRPC subscriber disconnected", map[string]interface{}{
+		"subscriber_id": id,
+	})
+}
+
+// filter removes logs the subscriber is not interested in.
+// Returns nil if no logs remain.
+func (sub *subscriber) filter(msg *eventspb.Event) *eventspb.Event {
+	if len(sub.eventTypes) == 0 {
+		return msg
+	}
+
+	logs := make([]*eventspb.Log, 0, len(msg.GetLogs()))
+	for _, log := range msg.GetLogs() {
+		if sub.eventTypes[log.GetEventType()] {
+			logs = append(logs, log)
+		}
+	}
+	if len(logs) == 0 {
+		return nil
+	}
+
+	return &eventspb.Event{
+		BlockNumber: msg.GetBlockNumber(),
+		TxHash:      msg.GetTxHash(),
+		TxIndex:     msg.GetTxIndex(),
+		Status:      msg.GetStatus(),
+		GasUsed:     msg.GetGasUsed(),
+		Variant:     msg.GetVariant(),
+		Logs:        logs,
+	}
+}
+
+// eventToMessage converts a sink event to its protobuf representation
+func (s *Sink) eventToMessage(event sinks.Event) *eventspb.Event {
+	logs := make([]*eventspb.Log, 0, len(event.Logs))
+	for _, log := range event.Logs {
+		topics := make([]string, len(log.Topics))
+		for i, topic := range log.Topics {
+			topics[i] = topic.Hex()
+		}
+
+		eventType := "Unknown"
+		if len(log.Topics) > 0 {
+			eventType = usdc.GetEventType(log.Topics[0])
+		}
+
+		logs = append(logs, &eventspb.Log{
+			Address:   log.Address.Hex(),
+			LogIndex:  uint32(log.Index),
+			EventType: eventType,
+			Topics:    topics,
+			Data:      log.Data,
+		})
+	}
+
+	return &eventspb.Event{
+		BlockNumber: event.BlockNumber,
+		TxHash:      event.Receipt.TxHash.Hex(),
+		TxIndex:     uint32(event.Receipt.TransactionIndex),
+		Status:      event.Receipt.Status,
+		GasUsed:     event.Receipt.GasUsed,
+		Variant:     event.Variant,
+		Logs:        logs,
+	}
+}
@@ -0,0 +1,28 @@
+package sinks
+
+import "context"
+
+// BlockHeartbeat reports a processed block without any tracked activity. With
+// EMIT_EMPTY_BLOCKS the tracker writes one for every such block, so dashboards
+// can tell a quiet chain from a stuck tracker.
+type BlockHeartbeat struct {
+	BlockNumber uint64 `json:"block_number"`
+	Timestamp   string `json:"timestamp"`   // Block time in RFC 3339, the ingestion time if unknown
+	TxCount     int    `json:"tx_count"`    // Transactions in the block
+	EventCount  int    `json:"event_count"` // Tracked events in the block, always 0
+}
+
+// HeartbeatWriter is implemented by sinks that store block heartbeats. Sinks
+// that do not implement it never see heartbeats.
+type HeartbeatWriter interface {
+	// WriteHeartbeat stores a heartbeat, called in ascending block order
+	WriteHeartbeat(ctx context.Context, heartbeat BlockHeartbeat) error
+}
+
+// writeHeartbeat hands heartbeat to sink if it stores heartbeats
+func writeHeartbeat(ctx context.Context, sink Sink, heartbeat BlockHeartbeat) error {
+	if w, ok := sink.(HeartbeatWriter); ok {
+		return w.WriteHeartbeat(ctx, heartbeat)
+	}
+	return nil
+}
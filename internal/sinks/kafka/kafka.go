@@ -3,11 +3,23 @@ package kafka
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"usdc-event-tracker/internal/sinks"
 )
@@ -21,14 +33,42 @@ type Config struct {
 	FlushInterval time.Duration // Maximum time to wait before flushing batch
 	Compression   string        // Compression algorithm (gzip, snappy, lz4, zstd)
 	Partitioner   string        // Partitioning strategy (hash, manual, round-robin)
+	PartitionKey  string        // Message key strategy (tx, tx:log, from, contract), empty keeps the per-message defaults
 	RequiredAcks  int           // Required acknowledgments (0, 1, -1)
 	Timeout       time.Duration // Write timeout
+	MaxPending    int           // Block writes while this many messages await a flush, 0 disables the limit
+	ChainID       uint64        // Chain ID included in idempotency keys
+
+	ReceiptFields sinks.ReceiptFields // Receipt-level fields included in event messages, nil includes all
+
+	// Security, required by managed Kafka services such as Confluent Cloud and MSK
+	TLSEnabled    bool   // Connect to brokers over TLS
+	SASLMechanism string // SASL mechanism (PLAIN, SCRAM-SHA-256, SCRAM-SHA-512), empty disables SASL
+	Username      string // SASL username
+	Password      string // SASL password
 }
 
+// Supported SASL mechanisms
+const (
+	SASLPlain       = "PLAIN"
+	SASLScramSHA256 = "SCRAM-SHA-256"
+	SASLScramSHA512 = "SCRAM-SHA-512"
+)
+
+// Message key strategies. The key decides the partition, and Kafka only orders
+// messages within a partition.
+const (
+	PartitionKeyTx       = "tx"       // Transaction hash, keeps a transaction's logs together and in order
+	PartitionKeyTxLog    = "tx:log"   // Transaction hash and log index, spreads logs evenly
+	PartitionKeyFrom     = "from"     // Transfer sender or Approval owner, for per-account ordering
+	PartitionKeyContract = "contract" // Emitting contract address
+)
+
 // KafkaSink writes events to Kafka topics
 type KafkaSink struct {
-	config Config
-	writer *kafka.Writer
+	config    Config
+	writer    *kafka.Writer
+	transport *kafka.Transport
 	
 	// Batch processing
 	messageBatch []kafka.Message
@@ -46,23 +86,75 @@ type KafkaSink struct {
 	errors        int64
 }
 
-// EventMessage represents an event message for Kafka
-type EventMessage struct {
-	Type        string    `json:"type"`        // "event" or "log"
-	Timestamp   time.Time `json:"timestamp"`
-	BlockNumber uint64    `json:"blockNumber"`
-	TxHash      string    `json:"txHash"`
-	TxStatus    uint64    `json:"txStatus"`
-	GasUsed     uint64    `json:"gasUsed"`
-	EventCount  int       `json:"eventCount,omitempty"`
-	
-	// Log-specific fields (when Type = "log")
-	LogIndex        *uint   `json:"logIndex,omitempty"`
-	EventType       *string `json:"eventType,omitempty"`
-	ContractAddress *string `json:"contractAddress,omitempty"`
-	Topics          []string `json:"topics,omitempty"`
-	Data            *string `json:"data,omitempty"`
-	DecodedData     interface{} `json:"decodedData,omitempty"`
+// LogMessage is published to LogsTopic for every log. It is the shared
+// sinks.LogJSON format plus the fields identifying the log's event.
+type LogMessage struct {
+	SchemaVersion int    `json:"schema_version"`
+	Timestamp     string `json:"timestamp"`
+	BlockNumber   uint64 `json:"block_number"`
+	TxHash        string `json:"tx_hash"`
+	sinks.LogJSON
+}
+
+func init() {
+	sinks.Register("kafka", func(opts sinks.Options) (sinks.Sink, error) {
+		config := NewConfig()
+		config.MaxPending = opts.MaxPending
+		config.ReceiptFields = opts.ReceiptFields
+		config.ChainID = opts.ChainID
+		sink := New(config)
+		if sink == nil {
+			// TODO: Remove once New is implemented
+			return nil, fmt.Errorf("kafka sink not yet implemented")
+		}
+		return sink, nil
+	})
+}
+
+// NewConfig creates a new Kafka sink configuration from environment variables
+func NewConfig() Config {
+	config := Config{
+		Topic:         "usdc-events",
+		LogsTopic:     os.Getenv("KAFKA_LOGS_TOPIC"),
+		BatchSize:     100,
+		Compression:   "gzip",
+		SASLMechanism: strings.ToUpper(os.Getenv("KAFKA_SASL_MECHANISM")),
+		Username:      os.Getenv("KAFKA_USERNAME"),
+		Password:      os.Getenv("KAFKA_PASSWORD"),
+	}
+
+	if brokers := os.Getenv("KAFKA_BROKERS"); brokers != "" {
+		for _, broker := range strings.Split(brokers, ",") {
+			if trimmed := strings.TrimSpace(broker); trimmed != "" {
+				config.Brokers = append(config.Brokers, trimmed)
+			}
+		}
+	}
+
+	if topic := os.Getenv("KAFKA_TOPIC"); topic != "" {
+		config.Topic = topic
+	}
+
+	if batchSize := os.Getenv("KAFKA_BATCH_SIZE"); batchSize != "" {
+		if size, err := strconv.Atoi(batchSize); err == nil && size > 0 {
+			config.BatchSize = size
+		}
+	}
+
+	if compression := os.Getenv("KAFKA_COMPRESSION"); compression != "" {
+		config.Compression = strings.ToLower(compression)
+	}
+
+	switch partitionKey := strings.ToLower(os.Getenv("KAFKA_PARTITION_KEY")); partitionKey {
+	case PartitionKeyTx, PartitionKeyTxLog, PartitionKeyFrom, PartitionKeyContract:
+		config.PartitionKey = partitionKey
+	}
+
+	if tlsEnabled := os.Getenv("KAFKA_TLS_ENABLED"); tlsEnabled != "" {
+		config.TLSEnabled = strings.ToLower(tlsEnabled) == "true"
+	}
+
+	return config
 }
 
 // New creates a new Kafka sink with the given configuration
@@ -81,12 +173,18 @@ func (k *KafkaSink) Name() string {
 }
 
 // Initialize prepares the Kafka sink
-func (k *KafkaSink) Initialize() error {
+func (k *KafkaSink) Initialize(ctx context.Context) error {
+	transport, err := k.newTransport()
+	if err != nil {
+		return fmt.Errorf("failed to configure Kafka transport: %w", err)
+	}
+	k.transport = transport
+
 	// TODO: Implement
 	// - Configure compression codec based on config
 	// - Configure partitioner/balancer based on config
-	// - Create kafka.Writer with all settings
-	// - Test connection (optional validation)
+	// - Create kafka.Writer with all settings, using k.transport for TLS/SASL
+	// - Test connection with ctx (optional validation)
 	// - Start background batch processor
 	// - Print initialization info
 	return nil
@@ -96,7 +194,9 @@ func (k *KafkaSink) Initialize() error {
 func (k *KafkaSink) Write(ctx context.Context, events []sinks.Event) error {
 	// TODO: Implement
 	// - Lock batch mutex
-	// - Convert events to Kafka messages
+	// - If config.MaxPending messages are pending, retry flushBatch with backoff
+	//   (releasing the mutex while waiting) until there is room or ctx is done
+	// - Convert events to Kafka messages with createEventMessage
 	// - Add event messages to batch
 	// - If LogsTopic is set, create separate log messages
 	// - Check if batch is full and flush if needed
@@ -104,6 +204,14 @@ func (k *KafkaSink) Write(ctx context.Context, events []sinks.Event) error {
 	return nil
 }
 
+// Flush publishes the pending batch immediately
+func (k *KafkaSink) Flush(ctx context.Context) error {
+	k.batchMutex.Lock()
+	defer k.batchMutex.Unlock()
+
+	return k.flushBatch()
+}
+
 // Close cleanly shuts down the Kafka sink
 func (k *KafkaSink) Close() error {
 	// TODO: Implement
@@ -138,44 +246,180 @@ func (k *KafkaSink) flushBatch() error {
 	return nil
 }
 
-// createEventMessage creates a Kafka message for an event
+// newTransport builds the writer transport with the configured TLS and SASL settings
+func (k *KafkaSink) newTransport() (*kafka.Transport, error) {
+	mechanism, err := k.saslMechanism()
+	if err != nil {
+		return nil, err
+	}
+
+	return &kafka.Transport{
+		TLS:  k.tlsConfig(),
+		SASL: mechanism,
+	}, nil
+}
+
+// newDialer builds a dialer for admin connections (e.g. CreateTopic) with the
+// same TLS and SASL settings as the writer
+func (k *KafkaSink) newDialer() (*kafka.Dialer, error) {
+	mechanism, err := k.saslMechanism()
+	if err != nil {
+		return nil, err
+	}
+
+	return &kafka.Dialer{
+		Timeout:       10 * time.Second,
+		DualStack:     true,
+		TLS:           k.tlsConfig(),
+		SASLMechanism: mechanism,
+	}, nil
+}
+
+// tlsConfig returns the TLS configuration, or nil if TLS is disabled
+func (k *KafkaSink) tlsConfig() *tls.Config {
+	if !k.config.TLSEnabled {
+		return nil
+	}
+	return &tls.Config{MinVersion: tls.VersionTLS12}
+}
+
+// saslMechanism returns the configured SASL mechanism, or nil if SASL is disabled
+func (k *KafkaSink) saslMechanism() (sasl.Mechanism, error) {
+	switch k.config.SASLMechanism {
+	case "":
+		return nil, nil
+	case SASLPlain:
+		return plain.Mechanism{
+			Username: k.config.Username,
+			Password: k.config.Password,
+		}, nil
+	case SASLScramSHA256:
+		return scram.Mechanism(scram.SHA256, k.config.Username, k.config.Password)
+	case SASLScramSHA512:
+		return scram.Mechanism(scram.SHA512, k.config.Username, k.config.Password)
+	default:
+		return nil, fmt.Errorf("unsupported SASL mechanism %q (supported: %s, %s, %s)",
+			k.config.SASLMechanism, SASLPlain, SASLScramSHA256, SASLScramSHA512)
+	}
+}
+
+// IdempotencyKeyHeader carries a stable hash identifying the log a message was
+// produced for, so consumers can drop duplicates from producer restarts or reorg
+// re-emits
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotencyKey returns the hex-encoded SHA-256 of chainID, blockNumber, txHash
+// and logIndex. The chain ID keeps the same transaction hash on different chains
+// from colliding.
+func IdempotencyKey(chainID, blockNumber uint64, txHash common.Hash, logIndex uint) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d:%d:%s:%d", chainID, blockNumber, txHash.Hex(), logIndex)))
+	return hex.EncodeToString(sum[:])
+}
+
+// createEventMessage creates a Kafka message for an event in the shared
+// sinks.EventJSON format, keyed by transaction hash unless PartitionKey is set.
+// Its idempotency key uses the index of the event's first log, since an event's
+// logs belong to it alone.
 func (k *KafkaSink) createEventMessage(event sinks.Event) (kafka.Message, error) {
-	// TODO: Implement
-	// - Create EventMessage struct with type "event"
-	// - If no separate logs topic, embed logs in DecodedData
-	// - Marshal to JSON
-	// - Create kafka.Message with key (tx hash), value (JSON), headers
-	// - Return kafka.Message
-	return kafka.Message{}, nil
+	value, err := sinks.MarshalEvent(event, k.config.ReceiptFields)
+	if err != nil {
+		return kafka.Message{}, fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	var first *types.Log
+	var logIndex uint
+	if len(event.Logs) > 0 {
+		first = event.Logs[0]
+		logIndex = first.Index
+	}
+
+	key := event.Receipt.TxHash.Hex()
+	if k.config.PartitionKey != "" {
+		key = k.partitionKey(event, first)
+	}
+
+	return kafka.Message{
+		Topic: k.config.Topic,
+		Key:   []byte(key),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: "type", Value: []byte("event")},
+			{Key: IdempotencyKeyHeader, Value: []byte(IdempotencyKey(k.config.ChainID, event.BlockNumber, event.Receipt.TxHash, logIndex))},
+		},
+	}, nil
 }
 
-// createLogMessage creates a Kafka message for an event log
+// createLogMessage creates a Kafka message for an event log, keyed by tx:logIndex
+// unless PartitionKey is set
 func (k *KafkaSink) createLogMessage(event sinks.Event, log *types.Log) (kafka.Message, error) {
-	// TODO: Implement
-	// - Decode event type using erc20 package
-	// - Extract and decode event-specific data (Transfer, Approval)
-	// - Convert topics to string array
-	// - Create EventMessage struct with type "log"
-	// - Marshal to JSON
-	// - Create kafka.Message with key (tx:logIndex), value, headers
-	// - Set appropriate topic (LogsTopic or main Topic)
-	return kafka.Message{}, nil
-}
-
-// logToMap converts a log to a map for embedding in event messages
-func (k *KafkaSink) logToMap(event sinks.Event, log *types.Log) map[string]interface{} {
-	// TODO: Implement
-	// - Decode event type and data
-	// - Convert topics to strings
-	// - Create map with all log fields
-	// - Include decoded data for known events
-	return nil
+	value, err := json.Marshal(LogMessage{
+		SchemaVersion: sinks.SchemaVersion,
+		Timestamp:     event.Timestamp().UTC().Format(time.RFC3339Nano),
+		BlockNumber:   event.BlockNumber,
+		TxHash:        event.Receipt.TxHash.Hex(),
+		LogJSON:       sinks.NewLogJSON(event, log),
+	})
+	if err != nil {
+		return kafka.Message{}, fmt.Errorf("failed to marshal log: %w", err)
+	}
+
+	topic := k.config.LogsTopic
+	if topic == "" {
+		topic = k.config.Topic
+	}
+
+	key := fmt.Sprintf("%s:%d", event.Receipt.TxHash.Hex(), log.Index)
+	if k.config.PartitionKey != "" {
+		key = k.partitionKey(event, log)
+	}
+
+	return kafka.Message{
+		Topic: topic,
+		Key:   []byte(key),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: "type", Value: []byte("log")},
+			{Key: IdempotencyKeyHeader, Value: []byte(IdempotencyKey(k.config.ChainID, event.BlockNumber, event.Receipt.TxHash, log.Index))},
+		},
+	}, nil
+}
+
+// partitionKey returns the message key for log under the configured PartitionKey.
+// Event messages pass their first log. Keys that cannot be derived, such as the
+// sender of an Upgraded log, fall back to the transaction hash.
+func (k *KafkaSink) partitionKey(event sinks.Event, log *types.Log) string {
+	txHash := event.Receipt.TxHash.Hex()
+	if log == nil {
+		return txHash
+	}
+
+	switch k.config.PartitionKey {
+	case PartitionKeyTxLog:
+		return fmt.Sprintf("%s:%d", txHash, log.Index)
+	case PartitionKeyContract:
+		return log.Address.Hex()
+	case PartitionKeyFrom:
+		if decoded, found := event.Decode(log); found {
+			switch {
+			case decoded.From != "":
+				return decoded.From
+			case decoded.Owner != "":
+				return decoded.Owner
+			}
+		}
+	}
+	return txHash
+}
+
+// Stats implements sinks.StatReporter
+func (k *KafkaSink) Stats() map[string]interface{} {
+	return k.GetStatistics()
 }
 
 // GetStatistics returns sink statistics
 func (k *KafkaSink) GetStatistics() map[string]interface{} {
 	// TODO: Implement
-	// - Return map with totalEvents, totalMessages, totalBatches, errors, pendingMessages
+	// - Return map with totalEvents, totalMessages, totalBatches, errors, pendingMessages, maxPending
 	// - Include Kafka writer stats if available
 	// - Calculate additional metrics (avgBatchSize, etc.)
 	return nil
@@ -184,7 +428,7 @@ func (k *KafkaSink) GetStatistics() map[string]interface{} {
 // CreateTopic creates a Kafka topic (useful for setup)
 func (k *KafkaSink) CreateTopic(ctx context.Context, topic string, partitions int, replicationFactor int) error {
 	// TODO: Implement
-	// - Connect to Kafka broker
+	// - Connect to Kafka broker with k.newDialer()
 	// - Get controller connection
 	// - Create topic with specified partitions and replication
 	// - Set topic configuration (compression, retention, etc.)
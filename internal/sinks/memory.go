@@ -0,0 +1,158 @@
+package sinks
+
+import (
+	"context"
+	"sync"
+)
+
+// MemorySink keeps every written event in memory. It is intended for tests
+// and tooling that need to assert on what the tracker emitted.
+type MemorySink struct {
+	name string
+
+	mu         sync.Mutex
+	events     []Event
+	summaries  []BlockSummary
+	heartbeats []BlockHeartbeat
+	balances   []BalanceSnapshot
+	writes     int
+	closed     bool
+}
+
+// NewMemorySink creates an empty in-memory sink.
+func NewMemorySink() *MemorySink {
+	return &MemorySink{name: "memory"}
+}
+
+// Name returns the sink name.
+func (m *MemorySink) Name() string {
+	return m.name
+}
+
+// Initialize is a no-op.
+func (m *MemorySink) Initialize(ctx context.Context) error {
+	return nil
+}
+
+// Write appends events to the in-memory store.
+func (m *MemorySink) Write(ctx context.Context, events []Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.events = append(m.events, events...)
+	m.writes++
+	return nil
+}
+
+// WriteSummaries appends block summaries to the in-memory store.
+func (m *MemorySink) WriteSummaries(ctx context.Context, summaries []BlockSummary) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.summaries = append(m.summaries, summaries...)
+	return nil
+}
+
+// WriteHeartbeat appends a block heartbeat to the in-memory store.
+func (m *MemorySink) WriteHeartbeat(ctx context.Context, heartbeat BlockHeartbeat) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.heartbeats = append(m.heartbeats, heartbeat)
+	return nil
+}
+
+// WriteBalances appends balance snapshots to the in-memory store.
+func (m *MemorySink) WriteBalances(ctx context.Context, snapshots []BalanceSnapshot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.balances = append(m.balances, snapshots...)
+	return nil
+}
+
+// Close marks the sink as closed. Stored events remain readable.
+func (m *MemorySink) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.closed = true
+	return nil
+}
+
+// Events returns a copy of all events written so far, in write order.
+func (m *MemorySink) Events() []Event {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	events := make([]Event, len(m.events))
+	copy(events, m.events)
+	return events
+}
+
+// Summaries returns a copy of all block summaries written so far, in write order.
+func (m *MemorySink) Summaries() []BlockSummary {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	summaries := make([]BlockSummary, len(m.summaries))
+	copy(summaries, m.summaries)
+	return summaries
+}
+
+// Heartbeats returns a copy of all block heartbeats written so far, in write order.
+func (m *MemorySink) Heartbeats() []BlockHeartbeat {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	heartbeats := make([]BlockHeartbeat, len(m.heartbeats))
+	copy(heartbeats, m.heartbeats)
+	return heartbeats
+}
+
+// Balances returns a copy of all balance snapshots written so far, in write order.
+func (m *MemorySink) Balances() []BalanceSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	balances := make([]BalanceSnapshot, len(m.balances))
+	copy(balances, m.balances)
+	return balances
+}
+
+// Count returns the number of events written so far.
+func (m *MemorySink) Count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.events)
+}
+
+// Writes returns the number of Write calls, including empty ones.
+func (m *MemorySink) Writes() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.writes
+}
+
+// Closed reports whether Close has been called.
+func (m *MemorySink) Closed() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.closed
+}
+
+// Reset discards all stored events and counters.
+func (m *MemorySink) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.events = nil
+	m.summaries = nil
+	m.heartbeats = nil
+	m.balances = nil
+	m.writes = 0
+	m.closed = false
+}
@@ -3,69 +3,101 @@ package mongodb
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"usdc-event-tracker/internal/erc20"
+	"usdc-event-tracker/internal/logging"
 	"usdc-event-tracker/internal/sinks"
 )
 
 // Config holds MongoDB sink configuration
 type Config struct {
-	URI            string        // MongoDB connection URI
-	Database       string        // Database name
-	Collection     string        // Collection name for events
-	LogsCollection string        // Collection name for event logs
-	BatchSize      int           // Number of events to batch before insert
-	FlushInterval  time.Duration // Maximum time to wait before flushing batch
-	CreateIndexes  bool          // Whether to create indexes
+	URI            string              // MongoDB connection URI
+	Database       string              // Database name
+	Collection     string              // Collection name for events
+	LogsCollection string              // Collection name for event logs
+	BatchSize      int                 // Number of events to batch before insert
+	FlushInterval  time.Duration       // Maximum time to wait before flushing batch
+	CreateIndexes  bool                // Whether to create indexes
+	ReceiptFields  sinks.ReceiptFields // Receipt-level fields to store, nil stores all
+	MaxPending     int                 // Block writes while this many events await a flush, 0 disables the limit
+
+	// Write to one collection per month of the block timestamp, e.g. "usdc_events_2024_01"
+	// and "usdc_logs_2024_01", so old months can be dropped or archived whole
+	MonthlyCollections bool
 }
 
 // MongoSink writes events to MongoDB
 type MongoSink struct {
 	config Config
 	client *mongo.Client
-	
-	// Collections
-	eventsCollection *mongo.Collection
-	logsCollection   *mongo.Collection
-	
+	db     *mongo.Database
+	logger *logging.Logger
+
+	// Collections known to have their indexes, guarded by batchMutex
+	indexed map[string]bool
+
 	// Batch processing
 	eventBatch []EventDocument
 	logsBatch  []LogDocument
 	batchMutex sync.Mutex
 	lastFlush  time.Time
-	
+
 	// Background processing
 	done chan struct{}
 	wg   sync.WaitGroup
-	
+
 	// Metrics
-	totalEvents int64
-	totalLogs   int64
-	totalBatches int64
+	totalEvents       int64
+	totalLogs         int64
+	totalBatches      int64
+	backpressureWaits int64
 }
 
 // EventDocument represents an event in MongoDB
 type EventDocument struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty"`
-	Timestamp   time.Time          `bson:"timestamp"`
-	BlockNumber uint64             `bson:"blockNumber"`
-	TxHash      string             `bson:"txHash"`
-	TxStatus    uint64             `bson:"txStatus"`
-	GasUsed     uint64             `bson:"gasUsed"`
-	EventCount  int                `bson:"eventCount"`
-	CreatedAt   time.Time          `bson:"createdAt"`
+	ID            primitive.ObjectID `bson:"_id,omitempty"`
+	SchemaVersion int                `bson:"schemaVersion"` // sinks.SchemaVersion
+	Timestamp     time.Time          `bson:"timestamp"`
+	BlockNumber   uint64             `bson:"blockNumber"`
+	TxHash        string             `bson:"txHash"`
+	LogIndex      uint               `bson:"logIndex"` // Lowest index of the event's logs
+	Variant       string             `bson:"variant,omitempty"`
+	EventCount    int                `bson:"eventCount"`
+	CreatedAt     time.Time          `bson:"createdAt"`
+
+	// Receipt-level fields, omitted when excluded by ReceiptFields or not reported
+	TxIndex           *uint   `bson:"txIndex,omitempty"`
+	TxStatus          *uint64 `bson:"txStatus,omitempty"`
+	GasUsed           *uint64 `bson:"gasUsed,omitempty"`
+	CumulativeGasUsed *uint64 `bson:"cumulativeGasUsed,omitempty"`
+	EffectiveGasPrice string  `bson:"effectiveGasPrice,omitempty"`
+	TxFeeWei          string  `bson:"txFeeWei,omitempty"` // sinks.TxFee, empty if the price is not reported
+	TxType            string  `bson:"txType,omitempty"`
+	LogsCount         *int    `bson:"logsCount,omitempty"`
 }
 
 // LogDocument represents an event log in MongoDB
 type LogDocument struct {
 	ID              primitive.ObjectID `bson:"_id,omitempty"`
+	SchemaVersion   int                `bson:"schemaVersion"` // sinks.SchemaVersion
 	EventID         primitive.ObjectID `bson:"eventId,omitempty"`
+	Timestamp       time.Time          `bson:"timestamp"` // Of the block, selects the monthly collection
 	BlockNumber     uint64             `bson:"blockNumber"`
 	TxHash          string             `bson:"txHash"`
 	LogIndex        uint               `bson:"logIndex"`
@@ -77,160 +109,701 @@ type LogDocument struct {
 	CreatedAt       time.Time          `bson:"createdAt"`
 }
 
+func init() {
+	sinks.Register("mongodb", func(opts sinks.Options) (sinks.Sink, error) {
+		config := NewConfig()
+		config.ReceiptFields = opts.ReceiptFields
+		config.MaxPending = opts.MaxPending
+		return New(config), nil
+	})
+}
+
+// NewConfig creates a new MongoDB sink configuration from environment variables
+func NewConfig() Config {
+	config := Config{
+		URI:                os.Getenv("MONGO_URI"),
+		Database:           os.Getenv("MONGO_DATABASE"),
+		Collection:         os.Getenv("MONGO_COLLECTION"),
+		LogsCollection:     os.Getenv("MONGO_LOGS_COLLECTION"),
+		CreateIndexes:      true,
+		MonthlyCollections: strings.ToLower(os.Getenv("MONGO_MONTHLY_COLLECTIONS")) == "true",
+	}
+
+	if batchSize := os.Getenv("MONGO_BATCH_SIZE"); batchSize != "" {
+		if size, err := strconv.Atoi(batchSize); err == nil && size > 0 {
+			config.BatchSize = size
+		}
+	}
+
+	return config
+}
+
 // New creates a new MongoDB sink with the given configuration
 func New(config Config) *MongoSink {
-	// TODO: Implement
-	// - Set defaults for empty config values
-	// - Initialize MongoSink struct
-	// - Setup batch slices and channels
-	return nil
+	if config.Database == "" {
+		config.Database = "blockchain"
+	}
+	if config.Collection == "" {
+		config.Collection = "usdc_events"
+	}
+	if config.LogsCollection == "" {
+		config.LogsCollection = "usdc_logs"
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 50
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 5 * time.Second
+	}
+
+	return &MongoSink{
+		config:     config,
+		logger:     logging.GetLogger("mongodb-sink"),
+		indexed:    make(map[string]bool),
+		eventBatch: make([]EventDocument, 0, config.BatchSize),
+		logsBatch:  make([]LogDocument, 0, config.BatchSize),
+		lastFlush:  time.Now(),
+		done:       make(chan struct{}),
+	}
 }
 
 // Name returns "mongodb" as the sink identifier
 func (m *MongoSink) Name() string {
-	// TODO: Implement
-	return ""
-}
-
-// Initialize prepares the MongoDB sink
-func (m *MongoSink) Initialize() error {
-	// TODO: Implement
-	// - Parse MongoDB URI and set connection options
-	// - Connect to MongoDB with timeout
-	// - Test connection with Ping
-	// - Get database and collections
-	// - Create indexes if config.CreateIndexes is true
-	// - Start background batch processor
-	// - Print initialization info
+	return "mongodb"
+}
+
+// Initialize connects to MongoDB, creates the indexes and starts the batch processor.
+// With MonthlyCollections, indexes are created as each month's collections are first
+// written to instead.
+func (m *MongoSink) Initialize(ctx context.Context) (err error) {
+	if m.config.URI == "" {
+		return fmt.Errorf("MONGO_URI environment variable is required for the mongodb sink")
+	}
+
+	connectCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(connectCtx, options.Client().ApplyURI(m.config.URI))
+	if err != nil {
+		m.logger.Error("Failed to connect to MongoDB", err)
+		return fmt.Errorf("failed to connect to MongoDB: %w", err)
+	}
+	// Release the connection pool if a later step fails, Close is not called then
+	defer func() {
+		if err != nil {
+			client.Disconnect(context.Background())
+		}
+	}()
+
+	if err := client.Ping(connectCtx, nil); err != nil {
+		m.logger.Error("Failed to ping MongoDB", err)
+		return fmt.Errorf("failed to ping MongoDB: %w", err)
+	}
+
+	m.client = client
+	m.db = client.Database(m.config.Database)
+
+	if m.config.CreateIndexes && !m.config.MonthlyCollections {
+		if err := m.createIndexes(ctx, m.config.Collection, m.config.LogsCollection); err != nil {
+			m.client = nil
+			m.db = nil
+			return err
+		}
+	}
+
+	m.wg.Add(1)
+	go m.batchProcessor()
+
+	m.logger.Info("Connected to MongoDB", map[string]interface{}{
+		"database":            m.config.Database,
+		"events_collection":   m.config.Collection,
+		"logs_collection":     m.config.LogsCollection,
+		"monthly_collections": m.config.MonthlyCollections,
+		"batch_size":          m.config.BatchSize,
+		"flush_interval":      m.config.FlushInterval.String(),
+	})
+
 	return nil
 }
 
 // Write adds events to the batch for database insertion
 func (m *MongoSink) Write(ctx context.Context, events []sinks.Event) error {
-	// TODO: Implement
-	// - Lock batch mutex
-	// - Convert events to MongoDB documents
-	// - Add events and logs to respective batches
-	// - Check if batch is full and flush if needed
-	// - Unlock mutex
+	if len(events) == 0 {
+		return nil
+	}
+
+	m.batchMutex.Lock()
+	defer m.batchMutex.Unlock()
+
+	if err := m.waitForCapacity(ctx); err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		doc := m.eventToDocument(event)
+		m.eventBatch = append(m.eventBatch, doc)
+		for _, log := range event.Logs {
+			logDoc := m.logToDocument(event, log)
+			logDoc.EventID = doc.ID
+			m.logsBatch = append(m.logsBatch, logDoc)
+		}
+	}
+
+	if len(m.eventBatch) >= m.config.BatchSize {
+		return m.flushBatch(ctx)
+	}
+
 	return nil
 }
 
+// waitForCapacity blocks while MaxPending events are waiting, retrying the flush
+// with backoff so a stalled database slows the tracker down instead of growing
+// the batch without bound. Callers must hold batchMutex; it is released while waiting.
+func (m *MongoSink) waitForCapacity(ctx context.Context) error {
+	if m.config.MaxPending <= 0 {
+		return nil
+	}
+
+	backoff := time.Second
+	for len(m.eventBatch) >= m.config.MaxPending {
+		err := m.flushBatch(ctx)
+		if err == nil {
+			return nil
+		}
+
+		m.backpressureWaits++
+		m.logger.Warn("MongoDB sink at max pending events, waiting for flush", map[string]interface{}{
+			"pending_events": len(m.eventBatch),
+			"max_pending":    m.config.MaxPending,
+			"retry_in":       backoff.String(),
+			"error":          err.Error(),
+		})
+
+		m.batchMutex.Unlock()
+		select {
+		case <-ctx.Done():
+			m.batchMutex.Lock()
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		m.batchMutex.Lock()
+
+		if backoff *= 2; backoff > 30*time.Second {
+			backoff = 30 * time.Second
+		}
+	}
+
+	return nil
+}
+
+// Flush inserts the pending batches immediately
+func (m *MongoSink) Flush(ctx context.Context) error {
+	m.batchMutex.Lock()
+	defer m.batchMutex.Unlock()
+
+	return m.flushBatch(ctx)
+}
+
 // Close cleanly shuts down the MongoDB sink
 func (m *MongoSink) Close() error {
-	// TODO: Implement
-	// - Signal shutdown to background processor
-	// - Wait for background goroutine to finish
-	// - Flush any remaining events in batches
-	// - Close MongoDB client connection
-	// - Print final statistics
-	return nil
+	close(m.done)
+	m.wg.Wait()
+
+	if m.client == nil {
+		return nil
+	}
+
+	m.batchMutex.Lock()
+	err := m.flushBatch(context.Background())
+	m.batchMutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if disconnectErr := m.client.Disconnect(ctx); disconnectErr != nil && err == nil {
+		err = fmt.Errorf("failed to disconnect from MongoDB: %w", disconnectErr)
+	}
+	m.client = nil
+
+	m.logger.Info("Closing MongoDB sink", map[string]interface{}{
+		"total_events":  m.totalEvents,
+		"total_logs":    m.totalLogs,
+		"total_batches": m.totalBatches,
+	})
+
+	return err
 }
 
-// createIndexes creates database indexes for performance
-func (m *MongoSink) createIndexes() error {
-	// TODO: Implement
-	// - Create indexes for events collection:
-	//   - blockNumber, txHash, timestamp, unique(blockNumber+txHash)
-	// - Create indexes for logs collection:
-	//   - blockNumber, txHash, eventType, contractAddress, eventId, timestamp
-	// - Use context with timeout
+// createIndexes creates the query indexes of an events and a logs collection, and the
+// unique keys that make re-inserting a batch after a failed flush harmless
+func (m *MongoSink) createIndexes(ctx context.Context, events, logs string) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	eventIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "blockNumber", Value: 1}}},
+		{Keys: bson.D{{Key: "txHash", Value: 1}}},
+		{Keys: bson.D{{Key: "timestamp", Value: 1}}},
+		{
+			Keys: bson.D{
+				{Key: "blockNumber", Value: 1},
+				{Key: "txHash", Value: 1},
+				{Key: "variant", Value: 1},
+				{Key: "logIndex", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+	if _, err := m.db.Collection(events).Indexes().CreateMany(ctx, eventIndexes); err != nil {
+		return fmt.Errorf("failed to create indexes on %s: %w", events, err)
+	}
+
+	logIndexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "txHash", Value: 1}}},
+		{Keys: bson.D{{Key: "eventType", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "contractAddress", Value: 1}}},
+		{Keys: bson.D{{Key: "eventId", Value: 1}}},
+		{Keys: bson.D{{Key: "timestamp", Value: 1}}},
+		{
+			Keys: bson.D{
+				{Key: "blockNumber", Value: 1},
+				{Key: "txHash", Value: 1},
+				{Key: "logIndex", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+	if _, err := m.db.Collection(logs).Indexes().CreateMany(ctx, logIndexes); err != nil {
+		return fmt.Errorf("failed to create indexes on %s: %w", logs, err)
+	}
+
+	m.indexed[events] = true
+	m.indexed[logs] = true
 	return nil
 }
 
 // batchProcessor runs in background to flush batches periodically
 func (m *MongoSink) batchProcessor() {
-	// TODO: Implement
-	// - Run in goroutine (called with go keyword)
-	// - Use ticker for periodic flushing
-	// - Check if flush interval has passed
-	// - Flush batches if time limit reached
-	// - Handle shutdown signal
-	// - Call wg.Done() when finished
-}
-
-// flushBatch inserts the current batches into MongoDB
-func (m *MongoSink) flushBatch() error {
-	// TODO: Implement
-	// - Start MongoDB session and transaction
-	// - Insert events batch with InsertMany (handle duplicates)
-	// - Update log documents with event IDs
-	// - Insert logs batch with InsertMany
-	// - Commit transaction
-	// - Update metrics (totalEvents, totalLogs, totalBatches)
-	// - Clear both batches
-	// - Update lastFlush timestamp
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+			m.batchMutex.Lock()
+			if time.Since(m.lastFlush) >= m.config.FlushInterval {
+				if err := m.flushBatch(context.Background()); err != nil {
+					m.logger.Error("Failed to flush MongoDB batch", err)
+				}
+			}
+			m.batchMutex.Unlock()
+		}
+	}
+}
+
+// flushBatch inserts the current batches into MongoDB, events before their logs.
+// Documents already stored by an earlier, partly failed flush are rejected by the
+// unique indexes and skipped, so a failed batch is kept and simply retried.
+// Callers must hold batchMutex.
+func (m *MongoSink) flushBatch(ctx context.Context) error {
+	if len(m.eventBatch) == 0 {
+		m.lastFlush = time.Now()
+		return nil
+	}
+
+	start := time.Now()
+
+	events := groupByCollection(m.eventBatch, func(doc EventDocument) string {
+		return m.collectionName(m.config.Collection, doc.Timestamp)
+	})
+	logs := groupByCollection(m.logsBatch, func(doc LogDocument) string {
+		return m.collectionName(m.config.LogsCollection, doc.Timestamp)
+	})
+
+	if m.config.CreateIndexes {
+		for name := range events {
+			if err := m.ensureIndexes(ctx, name); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(events) {
+		if err := m.insertMany(ctx, name, events[name]); err != nil {
+			m.logger.Error("Failed to insert MongoDB batch", err, map[string]interface{}{
+				"collection":  name,
+				"event_count": len(m.eventBatch),
+			})
+			return err
+		}
+	}
+	for _, name := range sortedKeys(logs) {
+		if err := m.insertMany(ctx, name, logs[name]); err != nil {
+			m.logger.Error("Failed to insert MongoDB batch", err, map[string]interface{}{
+				"collection": name,
+				"log_count":  len(m.logsBatch),
+			})
+			return err
+		}
+	}
+
+	m.totalEvents += int64(len(m.eventBatch))
+	m.totalLogs += int64(len(m.logsBatch))
+	m.totalBatches++
+
+	m.logger.LogSinkOperation(m.Name(), "flush", len(m.eventBatch), time.Since(start), true)
+
+	m.eventBatch = m.eventBatch[:0]
+	m.logsBatch = m.logsBatch[:0]
+	m.lastFlush = time.Now()
+
+	return nil
+}
+
+// ensureIndexes creates the indexes of an events collection and the matching logs
+// collection the first time the events collection is written to
+func (m *MongoSink) ensureIndexes(ctx context.Context, events string) error {
+	if m.indexed[events] {
+		return nil
+	}
+	logs := m.config.LogsCollection + strings.TrimPrefix(events, m.config.Collection)
+	return m.createIndexes(ctx, events, logs)
+}
+
+// insertMany inserts docs into a collection, ignoring documents that already exist
+func (m *MongoSink) insertMany(ctx context.Context, name string, docs []interface{}) error {
+	_, err := m.db.Collection(name).InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if err != nil && !isDuplicateKeyError(err) {
+		return fmt.Errorf("failed to insert into %s: %w", name, err)
+	}
 	return nil
 }
 
-// updateLogEventIDs updates the event IDs in log documents
-func (m *MongoSink) updateLogEventIDs(insertedIDs []interface{}) {
-	// TODO: Implement
-	// - Match inserted event IDs to corresponding log documents
-	// - Update EventID field in log documents
+// groupByCollection splits docs by the collection name returns for each of them
+func groupByCollection[T any](docs []T, name func(T) string) map[string][]interface{} {
+	groups := make(map[string][]interface{})
+	for _, doc := range docs {
+		groups[name(doc)] = append(groups[name(doc)], doc)
+	}
+	return groups
+}
+
+// sortedKeys returns the keys of groups in ascending order, oldest month first
+func sortedKeys(groups map[string][]interface{}) []string {
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
-// eventToDocument converts a sink event to MongoDB document
+// collectionName returns the collection documents with timestamp t are written
+// to: base itself, or base plus the month in UTC with MonthlyCollections
+func (m *MongoSink) collectionName(base string, t time.Time) string {
+	if !m.config.MonthlyCollections {
+		return base
+	}
+	return base + "_" + t.UTC().Format("2006_01")
+}
+
+// collections returns the collections holding documents of base: base itself, or
+// every monthly collection of base with MonthlyCollections
+func (m *MongoSink) collections(ctx context.Context, base string) ([]*mongo.Collection, error) {
+	if !m.config.MonthlyCollections {
+		return []*mongo.Collection{m.db.Collection(base)}, nil
+	}
+
+	filter := bson.M{"name": bson.M{"$regex": "^" + regexp.QuoteMeta(base) + `_\d{4}_\d{2}$`}}
+	names, err := m.db.ListCollectionNames(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s collections: %w", base, err)
+	}
+	sort.Strings(names)
+
+	collections := make([]*mongo.Collection, 0, len(names))
+	for _, name := range names {
+		collections = append(collections, m.db.Collection(name))
+	}
+	return collections, nil
+}
+
+// find runs a query on every collection of base and decodes the results into T
+func find[T any](ctx context.Context, m *MongoSink, base string, filter bson.M, opts *options.FindOptions) ([]T, error) {
+	collections, err := m.collections(ctx, base)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]T, 0)
+	for _, collection := range collections {
+		cursor, err := collection.Find(ctx, filter, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query %s: %w", collection.Name(), err)
+		}
+		var docs []T
+		if err := cursor.All(ctx, &docs); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", collection.Name(), err)
+		}
+		results = append(results, docs...)
+	}
+	return results, nil
+}
+
+// eventToDocument converts a sink event to MongoDB document with a new ID, so
+// its logs can reference it before it is inserted. Receipt-level fields are only
+// copied when allowed by config.ReceiptFields.
 func (m *MongoSink) eventToDocument(event sinks.Event) EventDocument {
-	// TODO: Implement
-	// - Extract fields from sinks.Event
-	// - Create EventDocument with proper BSON tags
-	// - Set timestamp and createdAt
-	return EventDocument{}
+	doc := EventDocument{
+		ID:            primitive.NewObjectID(),
+		SchemaVersion: sinks.SchemaVersion,
+		Timestamp:     event.Timestamp().UTC(),
+		BlockNumber:   event.BlockNumber,
+		TxHash:        event.Receipt.TxHash.Hex(),
+		LogIndex:      firstLogIndex(event),
+		Variant:       event.Variant,
+		EventCount:    len(event.Logs),
+		CreatedAt:     event.IngestedAt.UTC(),
+	}
+
+	fields := m.config.ReceiptFields
+	receipt := event.Receipt
+	if fields.Has(sinks.FieldTxIndex) {
+		txIndex := receipt.TransactionIndex
+		doc.TxIndex = &txIndex
+	}
+	if fields.Has(sinks.FieldStatus) {
+		status := receipt.Status
+		doc.TxStatus = &status
+	}
+	if fields.Has(sinks.FieldGasUsed) {
+		gasUsed := receipt.GasUsed
+		doc.GasUsed = &gasUsed
+	}
+	if fields.Has(sinks.FieldCumulativeGasUsed) {
+		cumulative := receipt.CumulativeGasUsed
+		doc.CumulativeGasUsed = &cumulative
+	}
+	// Kept with the price it is derived from, like tx_fee_wei in the wire format
+	if fields.Has(sinks.FieldEffectiveGasPrice) {
+		doc.EffectiveGasPrice = sinks.BigString(receipt.EffectiveGasPrice)
+		doc.TxFeeWei = sinks.BigString(sinks.TxFee(receipt))
+	}
+	if fields.Has(sinks.FieldTxType) {
+		doc.TxType = sinks.TxTypeName(receipt.Type)
+	}
+	if fields.Has(sinks.FieldLogsCount) {
+		logsCount := len(receipt.Logs)
+		doc.LogsCount = &logsCount
+	}
+
+	return doc
+}
+
+// firstLogIndex returns the lowest log index of event, its position within the
+// transaction, or 0 for an event without logs
+func firstLogIndex(event sinks.Event) uint {
+	if len(event.Logs) == 0 {
+		return 0
+	}
+	first := event.Logs[0].Index
+	for _, log := range event.Logs[1:] {
+		if log.Index < first {
+			first = log.Index
+		}
+	}
+	return first
 }
 
 // logToDocument converts an event log to MongoDB document
 func (m *MongoSink) logToDocument(event sinks.Event, log *types.Log) LogDocument {
-	// TODO: Implement
-	// - Extract topics as string array
-	// - Decode event type using erc20 package
-	// - Decode event-specific data (Transfer, Approval)
-	// - Create LogDocument with all fields
-	// - Set createdAt timestamp
-	return LogDocument{}
+	topics := make([]string, len(log.Topics))
+	for i, topic := range log.Topics {
+		topics[i] = topic.Hex()
+	}
+
+	doc := LogDocument{
+		SchemaVersion:   sinks.SchemaVersion,
+		Timestamp:       event.Timestamp().UTC(),
+		BlockNumber:     event.BlockNumber,
+		TxHash:          event.Receipt.TxHash.Hex(),
+		LogIndex:        log.Index,
+		EventType:       "Unknown",
+		ContractAddress: log.Address.Hex(),
+		Topics:          topics,
+		Data:            common.Bytes2Hex(log.Data),
+		CreatedAt:       event.IngestedAt.UTC(),
+	}
+
+	decoded, found := event.Decode(log)
+	if !found {
+		return doc
+	}
+	doc.EventType = string(decoded.Event)
+
+	if decoded.Fields != nil {
+		doc.DecodedData = bson.M(decoded.Fields)
+		return doc
+	}
+
+	data := bson.M{}
+	for key, value := range map[string]string{
+		"from":           decoded.From,
+		"to":             decoded.To,
+		"owner":          decoded.Owner,
+		"spender":        decoded.Spender,
+		"implementation": decoded.Implementation,
+	} {
+		if value != "" {
+			data[key] = value
+		}
+	}
+	if decoded.Value != nil {
+		data["value"] = decoded.Value.String()
+		if decoded.Event == erc20.Approval && erc20.IsInfiniteApproval(decoded.Value) {
+			data["value"] = erc20.InfiniteApprovalValue
+			data["infiniteApproval"] = true
+		}
+	}
+	if len(data) > 0 {
+		doc.DecodedData = data
+	}
+
+	return doc
 }
 
-// isDuplicateKeyError checks if an error is a duplicate key error
+// isDuplicateKeyError reports whether every write of a failed insert was rejected
+// as a duplicate key, i.e. the documents were already stored
 func isDuplicateKeyError(err error) bool {
-	// TODO: Implement
-	// - Check if error is mongo.WriteException
-	// - Look for error code 11000 (duplicate key)
-	return false
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) {
+		return false
+	}
+	if bulkErr.WriteConcernError != nil || len(bulkErr.WriteErrors) == 0 {
+		return false
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		if writeErr.Code != 11000 {
+			return false
+		}
+	}
+	return true
 }
 
 // GetEventsByBlock retrieves events for a specific block number
 func (m *MongoSink) GetEventsByBlock(blockNumber uint64) ([]EventDocument, error) {
-	// TODO: Implement
-	// - Create filter for blockNumber
-	// - Query events collection
-	// - Sort by createdAt
-	// - Return results
-	return nil, nil
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+	return find[EventDocument](ctx, m, m.config.Collection, bson.M{"blockNumber": blockNumber}, opts)
 }
 
 // GetLogsByTxHash retrieves logs for a specific transaction
 func (m *MongoSink) GetLogsByTxHash(txHash string) ([]LogDocument, error) {
-	// TODO: Implement
-	// - Create filter for txHash
-	// - Query logs collection
-	// - Sort by logIndex
-	// - Return results
-	return nil, nil
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "logIndex", Value: 1}})
+	return find[LogDocument](ctx, m, m.config.LogsCollection, bson.M{"txHash": txHash}, opts)
+}
+
+// PersistedBlocks implements sinks.BlockLister
+func (m *MongoSink) PersistedBlocks(ctx context.Context, from, to uint64) ([]uint64, error) {
+	collections, err := m.collections(ctx, m.config.Collection)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"blockNumber": bson.M{"$gte": from, "$lte": to}}
+	seen := make(map[uint64]bool)
+	for _, collection := range collections {
+		values, err := collection.Distinct(ctx, "blockNumber", filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query blocks %d-%d: %w", from, to, err)
+		}
+		for _, value := range values {
+			if number, ok := blockNumberValue(value); ok {
+				seen[number] = true
+			}
+		}
+	}
+
+	blocks := make([]uint64, 0, len(seen))
+	for number := range seen {
+		blocks = append(blocks, number)
+	}
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i] < blocks[j] })
+
+	return blocks, nil
+}
+
+// blockNumberValue converts a blockNumber read back from MongoDB, which stores
+// integers as int32 or int64, to a block number
+func blockNumberValue(value interface{}) (uint64, bool) {
+	switch v := value.(type) {
+	case int32:
+		return uint64(v), v >= 0
+	case int64:
+		return uint64(v), v >= 0
+	}
+	return 0, false
+}
+
+// Stats implements sinks.StatReporter
+func (m *MongoSink) Stats() map[string]interface{} {
+	return m.GetStatistics()
 }
 
 // GetStatistics returns sink statistics
 func (m *MongoSink) GetStatistics() map[string]interface{} {
-	// TODO: Implement
-	// - Return map with totalEvents, totalLogs, totalBatches, pendingEvents, pendingLogs
-	return nil
+	m.batchMutex.Lock()
+	defer m.batchMutex.Unlock()
+
+	return map[string]interface{}{
+		"total_events":       m.totalEvents,
+		"total_logs":         m.totalLogs,
+		"total_batches":      m.totalBatches,
+		"batch_size":         m.config.BatchSize,
+		"pending_events":     len(m.eventBatch),
+		"pending_logs":       len(m.logsBatch),
+		"max_pending":        m.config.MaxPending,
+		"backpressure_waits": m.backpressureWaits,
+	}
 }
 
-// GetEventsByEventType retrieves events by event type with pagination
+// GetEventsByEventType retrieves logs by event type with pagination, most recent first
 func (m *MongoSink) GetEventsByEventType(eventType string, limit int64, skip int64) ([]LogDocument, error) {
-	// TODO: Implement
-	// - Create filter for eventType
-	// - Apply limit and skip for pagination
-	// - Sort by createdAt descending
-	// - Return results
-	return nil, nil
-}
\ No newline at end of file
+	if limit <= 0 {
+		limit = 100
+	}
+	if skip < 0 {
+		skip = 0
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Each monthly collection can hold any part of the page, so take enough from each
+	// and paginate the merged result
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetLimit(skip + limit)
+	logs, err := find[LogDocument](ctx, m, m.config.LogsCollection, bson.M{"eventType": eventType}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(logs, func(i, j int) bool { return logs[i].CreatedAt.After(logs[j].CreatedAt) })
+	if skip >= int64(len(logs)) {
+		return []LogDocument{}, nil
+	}
+	logs = logs[skip:]
+	if int64(len(logs)) > limit {
+		logs = logs[:limit]
+	}
+	return logs, nil
+}
@@ -0,0 +1,232 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.mongodb.org/mongo-driver/mongo"
+	"usdc-event-tracker/internal/erc20"
+	"usdc-event-tracker/internal/sinks"
+)
+
+var (
+	token = common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	alice = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	bob   = common.HexToAddress("0x2222222222222222222222222222222222222222")
+)
+
+// transferEvent returns an event in a block at blockTime with a Transfer of value
+// base units at each of the given log indexes
+func transferEvent(blockNumber uint64, blockTime time.Time, value int64, logIndexes ...uint) sinks.Event {
+	txHash := common.BigToHash(new(big.Int).SetUint64(blockNumber))
+	var logs []*types.Log
+	for _, index := range logIndexes {
+		logs = append(logs, &types.Log{
+			Address: token,
+			Topics: []common.Hash{
+				common.HexToHash(erc20.EventSignatures[erc20.Transfer]),
+				common.BytesToHash(alice.Bytes()),
+				common.BytesToHash(bob.Bytes()),
+			},
+			Data:        common.BigToHash(big.NewInt(value)).Bytes(),
+			BlockNumber: blockNumber,
+			TxHash:      txHash,
+			Index:       index,
+		})
+	}
+	return sinks.Event{
+		BlockNumber: blockNumber,
+		Receipt: &types.Receipt{
+			Status:      types.ReceiptStatusSuccessful,
+			TxHash:      txHash,
+			BlockNumber: new(big.Int).SetUint64(blockNumber),
+			GasUsed:     50_000,
+			Logs:        logs,
+		},
+		Logs:       logs,
+		Variant:    "native",
+		BlockTime:  blockTime,
+		IngestedAt: blockTime.Add(time.Minute),
+	}
+}
+
+func TestNewConfigReadsMonthlyCollections(t *testing.T) {
+	t.Setenv("MONGO_URI", "mongodb://localhost:27017")
+	t.Setenv("MONGO_BATCH_SIZE", "25")
+	t.Setenv("MONGO_MONTHLY_COLLECTIONS", "true")
+
+	config := NewConfig()
+	if !config.MonthlyCollections {
+		t.Error("MONGO_MONTHLY_COLLECTIONS=true did not enable monthly collections")
+	}
+	if config.BatchSize != 25 || config.URI != "mongodb://localhost:27017" {
+		t.Errorf("NewConfig = batch size %d, URI %q", config.BatchSize, config.URI)
+	}
+
+	t.Setenv("MONGO_MONTHLY_COLLECTIONS", "")
+	if NewConfig().MonthlyCollections {
+		t.Error("monthly collections enabled without MONGO_MONTHLY_COLLECTIONS")
+	}
+}
+
+func TestInitializeRequiresURI(t *testing.T) {
+	m := New(Config{})
+	if err := m.Initialize(context.Background()); err == nil {
+		t.Fatal("Initialize without MONGO_URI succeeded")
+	}
+	if err := m.Close(); err != nil {
+		t.Errorf("Close after a failed Initialize: %v", err)
+	}
+}
+
+func TestMonthlyCollectionGrouping(t *testing.T) {
+	january := time.Date(2024, 1, 31, 23, 59, 59, 0, time.UTC)
+	// Still January in UTC-5, February in UTC
+	february := time.Date(2024, 1, 31, 22, 0, 0, 0, time.FixedZone("UTC-5", -5*60*60))
+
+	m := New(Config{MonthlyCollections: true})
+	for _, event := range []sinks.Event{transferEvent(1, january, 1, 0), transferEvent(2, february, 2, 0, 1)} {
+		doc := m.eventToDocument(event)
+		m.eventBatch = append(m.eventBatch, doc)
+		for _, log := range event.Logs {
+			logDoc := m.logToDocument(event, log)
+			logDoc.EventID = doc.ID
+			m.logsBatch = append(m.logsBatch, logDoc)
+		}
+	}
+
+	events := groupByCollection(m.eventBatch, func(doc EventDocument) string {
+		return m.collectionName(m.config.Collection, doc.Timestamp)
+	})
+	logs := groupByCollection(m.logsBatch, func(doc LogDocument) string {
+		return m.collectionName(m.config.LogsCollection, doc.Timestamp)
+	})
+
+	if keys := sortedKeys(events); len(keys) != 2 || keys[0] != "usdc_events_2024_01" || keys[1] != "usdc_events_2024_02" {
+		t.Errorf("event collections = %v, want usdc_events_2024_01 and usdc_events_2024_02", keys)
+	}
+	if got := len(logs["usdc_logs_2024_02"]); got != 2 {
+		t.Errorf("usdc_logs_2024_02 gets %d logs, want both logs of the February event", got)
+	}
+
+	february0 := logs["usdc_logs_2024_02"][0].(LogDocument)
+	if february0.EventID != events["usdc_events_2024_02"][0].(EventDocument).ID {
+		t.Error("log does not reference the ID of its event")
+	}
+
+	if name := New(Config{}).collectionName("usdc_events", february); name != "usdc_events" {
+		t.Errorf("collectionName without monthly collections = %s, want usdc_events", name)
+	}
+}
+
+func TestEventToDocument(t *testing.T) {
+	blockTime := time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC)
+	event := transferEvent(42, blockTime, 1_500_000, 7, 3)
+
+	m := New(Config{})
+	doc := m.eventToDocument(event)
+	if doc.ID.IsZero() {
+		t.Error("event document has no ID for its logs to reference")
+	}
+	if doc.LogIndex != 3 || doc.EventCount != 2 || doc.Variant != "native" {
+		t.Errorf("event document has log index %d, %d events, variant %q; want 3, 2, native", doc.LogIndex, doc.EventCount, doc.Variant)
+	}
+	if !doc.Timestamp.Equal(blockTime) || !doc.CreatedAt.Equal(blockTime.Add(time.Minute)) {
+		t.Errorf("event document times = %v and %v, want the block and ingestion times", doc.Timestamp, doc.CreatedAt)
+	}
+
+	logDoc := m.logToDocument(event, event.Logs[0])
+	if logDoc.EventType != "Transfer" || logDoc.LogIndex != 7 {
+		t.Errorf("log document = %s at %d, want Transfer at 7", logDoc.EventType, logDoc.LogIndex)
+	}
+	if logDoc.DecodedData["from"] != alice.Hex() || logDoc.DecodedData["to"] != bob.Hex() || logDoc.DecodedData["value"] != "1500000" {
+		t.Errorf("decodedData = %v", logDoc.DecodedData)
+	}
+}
+
+func TestIsDuplicateKeyError(t *testing.T) {
+	duplicate := mongo.BulkWriteError{WriteError: mongo.WriteError{Code: 11000}}
+	other := mongo.BulkWriteError{WriteError: mongo.WriteError{Code: 121}}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "all duplicates", err: mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{duplicate, duplicate}}, want: true},
+		{name: "mixed", err: mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{duplicate, other}}, want: false},
+		{name: "write concern", err: mongo.BulkWriteException{WriteConcernError: &mongo.WriteConcernError{}, WriteErrors: []mongo.BulkWriteError{duplicate}}, want: false},
+		{name: "other error", err: errors.New("connection refused"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isDuplicateKeyError(tt.err); got != tt.want {
+				t.Errorf("isDuplicateKeyError = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventToDocumentHonorsReceiptFields(t *testing.T) {
+	event := transferEvent(42, time.Now(), 1, 0)
+	event.Receipt.EffectiveGasPrice = big.NewInt(20_000_000_000)
+
+	all := New(Config{}).eventToDocument(event)
+	if all.TxStatus == nil || all.GasUsed == nil || all.TxIndex == nil || all.LogsCount == nil || all.EffectiveGasPrice == "" {
+		t.Errorf("document without an allowlist lacks receipt fields: %+v", all)
+	}
+
+	fields, err := sinks.ParseReceiptFields([]string{sinks.FieldStatus})
+	if err != nil {
+		t.Fatalf("ParseReceiptFields: %v", err)
+	}
+	doc := New(Config{ReceiptFields: fields}).eventToDocument(event)
+	if doc.TxStatus == nil || *doc.TxStatus != types.ReceiptStatusSuccessful {
+		t.Errorf("txStatus = %v, want the allowed status", doc.TxStatus)
+	}
+	if doc.GasUsed != nil || doc.TxIndex != nil || doc.CumulativeGasUsed != nil || doc.LogsCount != nil ||
+		doc.EffectiveGasPrice != "" || doc.TxType != "" {
+		t.Errorf("document keeps receipt fields outside the allowlist: %+v", doc)
+	}
+}
+
+func TestDocumentsCarrySchemaVersion(t *testing.T) {
+	event := transferEvent(42, time.Now(), 1, 0)
+	m := New(Config{})
+
+	if version := m.eventToDocument(event).SchemaVersion; version != sinks.SchemaVersion {
+		t.Errorf("event schemaVersion = %d, want %d", version, sinks.SchemaVersion)
+	}
+	if version := m.logToDocument(event, event.Logs[0]).SchemaVersion; version != sinks.SchemaVersion {
+		t.Errorf("log schemaVersion = %d, want %d", version, sinks.SchemaVersion)
+	}
+}
+
+func TestEventToDocumentTxFee(t *testing.T) {
+	event := transferEvent(42, time.Now(), 1, 0)
+	m := New(Config{})
+
+	// Not reported by the node
+	if fee := m.eventToDocument(event).TxFeeWei; fee != "" {
+		t.Errorf("txFeeWei without a gas price = %q, want it omitted", fee)
+	}
+
+	event.Receipt.EffectiveGasPrice = big.NewInt(20_000_000_000)
+	if fee := m.eventToDocument(event).TxFeeWei; fee != "1000000000000000" {
+		t.Errorf("txFeeWei = %q, want 50000 gas * 20 gwei = 1000000000000000", fee)
+	}
+
+	fields, err := sinks.ParseReceiptFields([]string{sinks.FieldGasUsed})
+	if err != nil {
+		t.Fatalf("ParseReceiptFields: %v", err)
+	}
+	if fee := New(Config{ReceiptFields: fields}).eventToDocument(event).TxFeeWei; fee != "" {
+		t.Errorf("txFeeWei without effective_gas_price in RECEIPT_FIELDS = %q, want it omitted", fee)
+	}
+}
@@ -0,0 +1,273 @@
+// Package netflow implements a sink that keeps running Transfer net flows
+// (inflow minus outflow) per address and periodically reports the top senders
+// and receivers
+package netflow
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"usdc-event-tracker/internal/erc20"
+	"usdc-event-tracker/internal/logging"
+	"usdc-event-tracker/internal/sinks"
+)
+
+// zeroAddress is the sender of mints and the receiver of burns, not a holder
+const zeroAddress = "0x0000000000000000000000000000000000000000"
+
+// Config holds net-flow sink configuration
+type Config struct {
+	TopN         int           // Senders and receivers listed per report
+	Interval     time.Duration // Report this often; a final report is logged on Close
+	MaxAddresses int           // Addresses tracked before the smallest flows are evicted
+}
+
+// Sink implements the sinks.Sink interface by accumulating net flows in memory.
+// Flows are totals since startup. Memory is bounded by MaxAddresses: when full,
+// the tenth of addresses with the smallest absolute net flow is evicted, so small
+// flows are approximate while large ones are kept.
+type Sink struct {
+	config Config
+	logger *logging.Logger
+
+	mu        sync.Mutex
+	flows     map[string]*big.Int
+	transfers int64
+	evicted   int64
+	since     time.Time
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// Flow is an address and its net flow in token base units
+type Flow struct {
+	Address string
+	Net     *big.Int
+}
+
+func init() {
+	sinks.Register("netflow", func(opts sinks.Options) (sinks.Sink, error) {
+		return New(NewConfig()), nil
+	})
+}
+
+// NewConfig creates a net-flow sink configuration from environment variables
+func NewConfig() Config {
+	config := Config{
+		TopN:         10,
+		Interval:     5 * time.Minute,
+		MaxAddresses: 100000,
+	}
+
+	if topN, err := strconv.Atoi(os.Getenv("NETFLOW_TOP_N")); err == nil && topN > 0 {
+		config.TopN = topN
+	}
+
+	if interval := os.Getenv("NETFLOW_INTERVAL"); interval != "" {
+		if d, err := time.ParseDuration(interval); err == nil && d > 0 {
+			config.Interval = d
+		}
+	}
+
+	if maxAddresses, err := strconv.Atoi(os.Getenv("NETFLOW_MAX_ADDRESSES")); err == nil && maxAddresses > 0 {
+		config.MaxAddresses = maxAddresses
+	}
+
+	// Leave room beyond the reported addresses, or eviction would churn through them
+	if config.MaxAddresses < 2*config.TopN {
+		config.MaxAddresses = 2 * config.TopN
+	}
+
+	return config
+}
+
+// New creates a new net-flow sink with the given configuration
+func New(config Config) *Sink {
+	return &Sink{
+		config: config,
+		logger: logging.GetLogger("netflow-sink"),
+		flows:  make(map[string]*big.Int),
+		since:  time.Now(),
+		done:   make(chan struct{}),
+	}
+}
+
+// Name returns the sink name
+func (s *Sink) Name() string {
+	return "netflow"
+}
+
+// Initialize starts the periodic report
+func (s *Sink) Initialize(ctx context.Context) error {
+	s.wg.Add(1)
+	go s.reportLoop()
+
+	s.logger.Info("Net-flow sink initialized", map[string]interface{}{
+		"top_n":         s.config.TopN,
+		"interval":      s.config.Interval.String(),
+		"max_addresses": s.config.MaxAddresses,
+	})
+	return nil
+}
+
+// Write adds the value of every decoded Transfer to the receiver's flow and
+// subtracts it from the sender's
+func (s *Sink) Write(ctx context.Context, events []sinks.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, event := range events {
+		for _, log := range event.Logs {
+			decoded, found := event.Decode(log)
+			if !found || decoded.Event != erc20.Transfer || decoded.Value == nil {
+				continue
+			}
+
+			s.transfers++
+			if decoded.From != zeroAddress {
+				net := s.flow(decoded.From)
+				net.Sub(net, decoded.Value)
+			}
+			if decoded.To != zeroAddress {
+				net := s.flow(decoded.To)
+				net.Add(net, decoded.Value)
+			}
+		}
+	}
+	return nil
+}
+
+// Stats implements sinks.StatReporter
+func (s *Sink) Stats() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return map[string]interface{}{
+		"transfers":         s.transfers,
+		"tracked_addresses": len(s.flows),
+		"evicted_addresses": s.evicted,
+	}
+}
+
+// Close stops the periodic report and logs a final one
+func (s *Sink) Close() error {
+	close(s.done)
+	s.wg.Wait()
+	s.report()
+	return nil
+}
+
+// Top returns up to n addresses with the largest net inflow and up to n with
+// the largest net outflow, each ordered from the largest flow down
+func (s *Sink) Top(n int) (receivers, senders []Flow) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	flows := make([]Flow, 0, len(s.flows))
+	for address, net := range s.flows {
+		if net.Sign() != 0 {
+			flows = append(flows, Flow{Address: address, Net: new(big.Int).Set(net)})
+		}
+	}
+	sort.Slice(flows, func(i, j int) bool {
+		if c := flows[i].Net.Cmp(flows[j].Net); c != 0 {
+			return c > 0
+		}
+		return flows[i].Address < flows[j].Address
+	})
+
+	for i := 0; i < len(flows) && i < n && flows[i].Net.Sign() > 0; i++ {
+		receivers = append(receivers, flows[i])
+	}
+	for i := len(flows) - 1; i >= 0 && len(flows)-i <= n && flows[i].Net.Sign() < 0; i-- {
+		senders = append(senders, flows[i])
+	}
+	return receivers, senders
+}
+
+// flow returns the running flow of address, evicting small flows first if the
+// map is full. The caller must hold mu.
+func (s *Sink) flow(address string) *big.Int {
+	if net, ok := s.flows[address]; ok {
+		return net
+	}
+	if len(s.flows) >= s.config.MaxAddresses {
+		s.evict()
+	}
+
+	net := new(big.Int)
+	s.flows[address] = net
+	return net
+}
+
+// evict drops the tenth of addresses with the smallest absolute net flow.
+// The caller must hold mu.
+func (s *Sink) evict() {
+	addresses := make([]string, 0, len(s.flows))
+	for address := range s.flows {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return s.flows[addresses[i]].CmpAbs(s.flows[addresses[j]]) < 0
+	})
+
+	count := max(len(addresses)/10, 1)
+	for _, address := range addresses[:count] {
+		delete(s.flows, address)
+	}
+	s.evicted += int64(count)
+}
+
+// reportLoop logs a report every Interval until Close
+func (s *Sink) reportLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.report()
+		}
+	}
+}
+
+// report logs the top receivers and senders
+func (s *Sink) report() {
+	receivers, senders := s.Top(s.config.TopN)
+
+	s.mu.Lock()
+	fields := map[string]interface{}{
+		"event_type":        "net_flow",
+		"since":             s.since.UTC().Format(time.RFC3339),
+		"transfers":         s.transfers,
+		"tracked_addresses": len(s.flows),
+		"top_receivers":     flowFields(receivers),
+		"top_senders":       flowFields(senders),
+	}
+	s.mu.Unlock()
+
+	s.logger.Info("Top net flows", fields)
+}
+
+// flowFields converts flows to log fields with exact and display amounts
+func flowFields(flows []Flow) []map[string]interface{} {
+	fields := make([]map[string]interface{}, 0, len(flows))
+	for _, flow := range flows {
+		fields = append(fields, map[string]interface{}{
+			"address":   flow.Address,
+			"net_value": flow.Net.String(),
+			"net":       sinks.TrackedToken.Format(flow.Net),
+		})
+	}
+	return fields
+}
@@ -0,0 +1,297 @@
+package sinks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"usdc-event-tracker/internal/logging"
+)
+
+// maxReorderHold is how long a block may wait in an ordered queue for an earlier
+// block. Blocks without USDC events are never written, so gaps in block numbers
+// are normal and the queue cannot wait for every missing number.
+const maxReorderHold = time.Second
+
+// queueItem is a block's events, a block heartbeat when heartbeat is set, a
+// block's balance snapshots when balances is set, or a flush request when
+// flushed is set
+type queueItem struct {
+	ctx         context.Context
+	blockNumber uint64
+	events      []Event
+	heartbeat   *BlockHeartbeat
+	balances    []BalanceSnapshot
+	arrived     time.Time
+	flushed     chan error
+}
+
+// orderedQueue delivers blocks to a single sink from a dedicated goroutine in
+// block-number order. Blocks arriving out of order are reordered within a bounded
+// window: a block is held until its predecessor was delivered, until window later
+// blocks are waiting behind it, or for at most maxReorderHold. A block arriving
+// after a later one was already delivered is written immediately and counted as late.
+type orderedQueue struct {
+	sink            Sink
+	window          int
+	flushEveryWrite bool
+	logger          *logging.Logger
+
+	in   chan queueItem
+	done chan struct{}
+
+	// Metrics
+	mu            sync.Mutex
+	pendingBlocks int
+	lateBlocks    int64
+	writeErrors   int64
+
+	// First failed block write, reported by every later flush
+	failed error
+}
+
+// newOrderedQueue starts the delivery goroutine for sink
+func newOrderedQueue(sink Sink, window int, flushEveryWrite bool) *orderedQueue {
+	q := &orderedQueue{
+		sink:            sink,
+		window:          window,
+		flushEveryWrite: flushEveryWrite,
+		logger:          logging.GetLogger("ordered-queue"),
+		in:              make(chan queueItem, window),
+		done:            make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// enqueue hands a block's events to the queue, blocking while the queue is full
+func (q *orderedQueue) enqueue(ctx context.Context, blockNumber uint64, events []Event) error {
+	select {
+	case q.in <- queueItem{ctx: ctx, blockNumber: blockNumber, events: events, arrived: time.Now()}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// enqueueHeartbeat hands a block heartbeat to the queue, ordered like a block
+func (q *orderedQueue) enqueueHeartbeat(ctx context.Context, heartbeat BlockHeartbeat) error {
+	select {
+	case q.in <- queueItem{ctx: ctx, blockNumber: heartbeat.BlockNumber, heartbeat: &heartbeat, arrived: time.Now()}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// enqueueBalances hands a block's balance snapshots to the queue. They follow the
+// block's events, which the tracker writes first.
+func (q *orderedQueue) enqueueBalances(ctx context.Context, snapshots []BalanceSnapshot) error {
+	select {
+	case q.in <- queueItem{ctx: ctx, blockNumber: snapshots[0].BlockNumber, balances: snapshots, arrived: time.Now()}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// flush delivers every queued block, including held ones, then flushes the sink
+func (q *orderedQueue) flush(ctx context.Context) error {
+	flushed := make(chan error, 1)
+	select {
+	case q.in <- queueItem{ctx: ctx, flushed: flushed}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-flushed:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close delivers every queued block and stops the delivery goroutine
+func (q *orderedQueue) close() {
+	close(q.in)
+	<-q.done
+}
+
+// stats returns the queue's metrics
+func (q *orderedQueue) stats() map[string]interface{} {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return map[string]interface{}{
+		"queue_pending_blocks": q.pendingBlocks,
+		"queue_late_blocks":    q.lateBlocks,
+		"queue_write_errors":   q.writeErrors,
+	}
+}
+
+// run reorders and delivers blocks until the queue is closed
+func (q *orderedQueue) run() {
+	defer close(q.done)
+
+	var (
+		pending   []queueItem // Sorted by block number
+		last      uint64
+		delivered bool
+	)
+
+	deliver := func(item queueItem) {
+		q.write(item)
+		if !delivered || item.blockNumber > last {
+			last = item.blockNumber
+		}
+		delivered = true
+	}
+
+	drain := func() {
+		for _, item := range pending {
+			deliver(item)
+		}
+		pending = nil
+	}
+
+	for {
+		var hold <-chan time.Time
+		if len(pending) > 0 {
+			hold = time.After(maxReorderHold - time.Since(oldestArrival(pending)))
+		}
+
+		select {
+		case item, ok := <-q.in:
+			if !ok {
+				drain()
+				q.setPending(0)
+				return
+			}
+			if item.flushed != nil {
+				drain()
+				q.setPending(0)
+				item.flushed <- errors.Join(q.failure(), flush(context.WithoutCancel(item.ctx), q.sink))
+				continue
+			}
+			// Snapshots of the block just delivered are on time
+			if item.balances != nil && delivered && item.blockNumber <= last {
+				deliver(item)
+				continue
+			}
+			if delivered && item.blockNumber <= last {
+				q.mu.Lock()
+				q.lateBlocks++
+				q.mu.Unlock()
+				q.logger.Warn("Block arrived outside the reorder window, delivering out of order", map[string]interface{}{
+					"sink_name":      q.sink.Name(),
+					"block_number":   item.blockNumber,
+					"last_delivered": last,
+				})
+				deliver(item)
+				continue
+			}
+
+			i := sort.Search(len(pending), func(i int) bool {
+				return pending[i].blockNumber > item.blockNumber
+			})
+			pending = append(pending, queueItem{})
+			copy(pending[i+1:], pending[i:])
+			pending[i] = item
+		case <-hold:
+		}
+
+		for len(pending) > 0 {
+			next := pending[0]
+			contiguous := delivered && next.blockNumber == last+1
+			if !contiguous && len(pending) <= q.window && time.Since(oldestArrival(pending)) < maxReorderHold {
+				break
+			}
+			deliver(next)
+			pending = pending[1:]
+		}
+		q.setPending(len(pending))
+	}
+}
+
+// write delivers a block to the sink. Errors are counted but do not stop
+// delivery of later blocks. A failed block write is also recorded, so flushes
+// fail from then on and the block is not checkpointed.
+func (q *orderedQueue) write(item queueItem) {
+	// Held blocks may outlive the caller's context, e.g. when draining on shutdown
+	ctx := context.WithoutCancel(item.ctx)
+
+	if item.heartbeat != nil {
+		if err := writeHeartbeat(ctx, q.sink, *item.heartbeat); err != nil {
+			q.mu.Lock()
+			q.writeErrors++
+			q.mu.Unlock()
+		}
+		return
+	}
+	if item.balances != nil {
+		if err := writeBalances(ctx, q.sink, item.balances); err != nil {
+			q.mu.Lock()
+			q.writeErrors++
+			q.mu.Unlock()
+		}
+		return
+	}
+
+	err := safeWrite(ctx, q.sink, item.events)
+	if err == nil && q.flushEveryWrite {
+		err = flush(ctx, q.sink)
+	}
+	if err != nil {
+		q.logger.Error("Sink write failed", err, map[string]interface{}{
+			"sink_name":    q.sink.Name(),
+			"block_number": item.blockNumber,
+		})
+		q.mu.Lock()
+		q.writeErrors++
+		if q.failed == nil {
+			q.failed = fmt.Errorf("%s: block %d: %w", q.sink.Name(), item.blockNumber, err)
+		}
+		q.mu.Unlock()
+	}
+}
+
+// failure returns the first failed block write, if any
+func (q *orderedQueue) failure() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.failed
+}
+
+// setPending records the number of held blocks
+func (q *orderedQueue) setPending(n int) {
+	q.mu.Lock()
+	q.pendingBlocks = n
+	q.mu.Unlock()
+}
+
+// oldestArrival returns the earliest arrival time among items
+func oldestArrival(items []queueItem) time.Time {
+	oldest := items[0].arrived
+	for _, item := range items[1:] {
+		if item.arrived.Before(oldest) {
+			oldest = item.arrived
+		}
+	}
+	return oldest
+}
+
+// groupByBlock splits events into per-block batches, keeping their order
+func groupByBlock(events []Event) [][]Event {
+	var batches [][]Event
+	for i, event := range events {
+		if i == 0 || event.BlockNumber != events[i-1].BlockNumber {
+			batches = append(batches, nil)
+		}
+		batches[len(batches)-1] = append(batches[len(batches)-1], event)
+	}
+	return batches
+}
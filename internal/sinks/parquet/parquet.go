@@ -0,0 +1,394 @@
+// Package parquet implements a sink that writes events as Parquet files for columnar analytics
+package parquet
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/parquet-go/parquet-go"
+	"usdc-event-tracker/internal/erc20"
+	"usdc-event-tracker/internal/logging"
+	"usdc-event-tracker/internal/sinks"
+)
+
+// rowGroupRows is the number of rows buffered in memory before a row group is
+// written out. File sizes are only known for written row groups, so it also
+// bounds how far a file can overshoot MaxFileSize.
+const rowGroupRows = 64 * 1024
+
+// Row is the flat Parquet schema, one row per USDC log. Approval rows store the
+// owner in from and the spender in to; Upgraded rows store the new implementation in to. value_decimal is the raw token amount in
+// base units as an exact decimal string, since infinite approvals exceed every
+// Parquet decimal precision; cast it in the query engine, e.g. value_decimal::HUGEINT.
+type Row struct {
+	SchemaVersion int32     `parquet:"schema_version"`
+	BlockNumber   uint64    `parquet:"block_number"`
+	TxHash        string    `parquet:"tx_hash,dict"`
+	LogIndex      uint32    `parquet:"log_index"`
+	EventType     string    `parquet:"event_type,dict"`
+	From          string    `parquet:"from,optional,dict"`
+	To            string    `parquet:"to,optional,dict"`
+	ValueDecimal  string    `parquet:"value_decimal,optional"`
+	Timestamp     time.Time `parquet:"timestamp,timestamp(millisecond)"`
+}
+
+// Config holds Parquet sink configuration
+type Config struct {
+	OutputDir        string        // Directory files are written to
+	FilePrefix       string        // Prefix for file names
+	MaxFileSize      int64         // Rotate once the file reaches this many bytes
+	RotationInterval time.Duration // Rotate once the file is this old
+}
+
+// Sink implements the sinks.Sink interface for Parquet files.
+// Rows are written to a temporary file that is renamed into a Hive-style
+// partition once it is rotated, so readers never see incomplete files.
+type Sink struct {
+	config Config
+	logger *logging.Logger
+
+	// Current file
+	mu         sync.Mutex
+	file       *os.File
+	counter    *countingWriter
+	writer     *parquet.GenericWriter[Row]
+	buffered   int // Rows not yet written out as a row group
+	rowCount   int
+	firstBlock uint64
+	lastBlock  uint64
+	firstTime  time.Time // Block time of the earliest row, used for partitioning
+	fileStart  time.Time
+
+	// Background rotation
+	done chan struct{}
+	wg   sync.WaitGroup
+
+	// Metrics
+	totalRows  int64
+	totalFiles int64
+}
+
+func init() {
+	sinks.Register("parquet", func(opts sinks.Options) (sinks.Sink, error) {
+		return New(NewConfig()), nil
+	})
+}
+
+// NewConfig creates a new Parquet configuration from environment variables
+func NewConfig() Config {
+	config := Config{
+		OutputDir:        "./usdc-events-parquet",
+		FilePrefix:       "usdc-events",
+		MaxFileSize:      128 * 1024 * 1024,
+		RotationInterval: time.Hour,
+	}
+
+	if dir := os.Getenv("PARQUET_OUTPUT_DIR"); dir != "" {
+		config.OutputDir = dir
+	}
+
+	if prefix := os.Getenv("PARQUET_FILE_PREFIX"); prefix != "" {
+		config.FilePrefix = prefix
+	}
+
+	if size := os.Getenv("PARQUET_MAX_FILE_SIZE"); size != "" {
+		if n, err := strconv.ParseInt(size, 10, 64); err == nil && n > 0 {
+			config.MaxFileSize = n
+		}
+	}
+
+	if interval := os.Getenv("PARQUET_ROTATION_INTERVAL"); interval != "" {
+		if d, err := time.ParseDuration(interval); err == nil && d > 0 {
+			config.RotationInterval = d
+		}
+	}
+
+	return config
+}
+
+// New creates a new Parquet sink
+func New(config Config) *Sink {
+	return &Sink{
+		config: config,
+		logger: logging.GetLogger("parquet-sink"),
+		done:   make(chan struct{}),
+	}
+}
+
+// Name returns the sink name
+func (s *Sink) Name() string {
+	return "parquet"
+}
+
+// Initialize creates the output directory and starts the rotation worker
+func (s *Sink) Initialize(ctx context.Context) error {
+	if err := os.MkdirAll(s.config.OutputDir, 0o755); err != nil {
+		s.logger.Error("Failed to create Parquet output directory", err, map[string]interface{}{
+			"output_dir": s.config.OutputDir,
+		})
+		return fmt.Errorf("failed to create output directory %s: %w", s.config.OutputDir, err)
+	}
+
+	s.wg.Add(1)
+	go s.rotationWorker()
+
+	s.logger.Info("Parquet sink initialized", map[string]interface{}{
+		"output_dir":        s.config.OutputDir,
+		"file_prefix":       s.config.FilePrefix,
+		"max_file_size":     s.config.MaxFileSize,
+		"rotation_interval": s.config.RotationInterval.String(),
+	})
+
+	return nil
+}
+
+// Write appends one row per log to the current file, rotating it when it is full
+func (s *Sink) Write(ctx context.Context, events []sinks.Event) error {
+	rows := make([]Row, 0, len(events))
+	for _, event := range events {
+		for _, log := range event.Logs {
+			rows = append(rows, newRow(event, log))
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.writer == nil {
+		if err := s.openFile(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.writer.Write(rows); err != nil {
+		return fmt.Errorf("failed to write parquet rows: %w", err)
+	}
+
+	for _, row := range rows {
+		if s.rowCount == 0 || row.BlockNumber < s.firstBlock {
+			s.firstBlock = row.BlockNumber
+			s.firstTime = row.Timestamp
+		}
+		if row.BlockNumber > s.lastBlock {
+			s.lastBlock = row.BlockNumber
+		}
+		s.rowCount++
+	}
+
+	s.buffered += len(rows)
+	if s.buffered >= rowGroupRows {
+		if err := s.writer.Flush(); err != nil {
+			return fmt.Errorf("failed to write parquet row group: %w", err)
+		}
+		s.buffered = 0
+	}
+
+	if s.counter.n >= s.config.MaxFileSize {
+		return s.rotate()
+	}
+
+	return nil
+}
+
+// Stats implements sinks.StatReporter
+func (s *Sink) Stats() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pendingBytes int64
+	if s.counter != nil {
+		pendingBytes = s.counter.n
+	}
+
+	return map[string]interface{}{
+		"total_rows":    s.totalRows,
+		"total_files":   s.totalFiles,
+		"pending_rows":  s.rowCount,
+		"pending_bytes": pendingBytes,
+	}
+}
+
+// Flush finalizes the current file. Parquet files are only readable once their
+// footer is written, so this is what makes the rows written so far durable.
+func (s *Sink) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rotate()
+}
+
+// Close finalizes the current file and stops the rotation worker
+func (s *Sink) Close() error {
+	close(s.done)
+	s.wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.rotate()
+
+	s.logger.Info("Closing Parquet sink", map[string]interface{}{
+		"total_rows":  s.totalRows,
+		"total_files": s.totalFiles,
+	})
+
+	return err
+}
+
+// rotationWorker finalizes the current file once it exceeds the rotation interval
+func (s *Sink) rotationWorker() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(time.Minute)
+	if s.config.RotationInterval < time.Minute {
+		ticker.Reset(s.config.RotationInterval)
+	}
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			if s.writer != nil && time.Since(s.fileStart) >= s.config.RotationInterval {
+				if err := s.rotate(); err != nil {
+					s.logger.Error("Failed to rotate Parquet file", err)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// openFile starts a new temporary file. Callers must hold s.mu.
+func (s *Sink) openFile() error {
+	file, err := os.CreateTemp(s.config.OutputDir, "."+s.config.FilePrefix+"-*.parquet.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create parquet file: %w", err)
+	}
+
+	s.file = file
+	s.counter = &countingWriter{w: file}
+	s.writer = parquet.NewGenericWriter[Row](s.counter, parquet.Compression(&parquet.Snappy))
+	s.buffered = 0
+	s.rowCount = 0
+	s.firstBlock = 0
+	s.lastBlock = 0
+	s.firstTime = time.Time{}
+	s.fileStart = time.Now()
+
+	return nil
+}
+
+// rotate writes the footer of the current file, syncs it and moves it into its
+// partition. The next Write opens a new file. Callers must hold s.mu.
+func (s *Sink) rotate() error {
+	if s.writer == nil {
+		return nil
+	}
+
+	tmpPath := s.file.Name()
+	if err := s.writer.Close(); err != nil {
+		s.file.Close()
+		s.writer = nil
+		return fmt.Errorf("failed to finalize parquet file %s: %w", tmpPath, err)
+	}
+	s.writer = nil
+
+	if err := s.file.Sync(); err != nil {
+		s.file.Close()
+		return fmt.Errorf("failed to sync parquet file %s: %w", tmpPath, err)
+	}
+	if err := s.file.Close(); err != nil {
+		return fmt.Errorf("failed to close parquet file %s: %w", tmpPath, err)
+	}
+
+	finalPath := s.filePath(s.firstTime)
+	if err := os.MkdirAll(filepath.Dir(finalPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create partition directory: %w", err)
+	}
+	if err := os.Rename(tmpPath, finalPath); err != nil {
+		return fmt.Errorf("failed to move parquet file to %s: %w", finalPath, err)
+	}
+
+	s.logger.Info("Wrote Parquet file", map[string]interface{}{
+		"path":      finalPath,
+		"row_count": s.rowCount,
+		"bytes":     s.counter.n,
+	})
+
+	s.totalRows += int64(s.rowCount)
+	s.totalFiles++
+	s.rowCount = 0
+	s.file = nil
+	s.counter = nil
+
+	return nil
+}
+
+// filePath builds a Hive-style partitioned path for DuckDB/Spark, e.g.
+// <dir>/year=2024/month=01/day=15/usdc-events-19000000-19000042.parquet
+func (s *Sink) filePath(t time.Time) string {
+	t = t.UTC()
+	return filepath.Join(
+		s.config.OutputDir,
+		fmt.Sprintf("year=%04d", t.Year()),
+		fmt.Sprintf("month=%02d", t.Month()),
+		fmt.Sprintf("day=%02d", t.Day()),
+		fmt.Sprintf("%s-%d-%d.parquet", s.config.FilePrefix, s.firstBlock, s.lastBlock),
+	)
+}
+
+// newRow flattens a single log into a Row
+func newRow(event sinks.Event, log *types.Log) Row {
+	decoded := sinks.NewLogJSON(event, log)
+	row := Row{
+		SchemaVersion: sinks.SchemaVersion,
+		BlockNumber:   event.BlockNumber,
+		TxHash:        event.Receipt.TxHash.Hex(),
+		LogIndex:      uint32(log.Index),
+		EventType:     decoded.Type,
+		From:          decoded.From,
+		To:            decoded.To,
+		Timestamp:     event.Timestamp().UTC(),
+	}
+
+	switch erc20.Event(decoded.Type) {
+	case erc20.Approval:
+		row.From = decoded.Owner
+		row.To = decoded.Spender
+	case erc20.Upgraded:
+		row.To = decoded.Implementation
+		return row
+	}
+
+	// The raw value, not the normalized one sinks.LogJSON uses for infinite approvals
+	if value, ok := erc20.DecodeValue(log.Data); ok {
+		row.ValueDecimal = value.String()
+	}
+
+	return row
+}
+
+// countingWriter tracks the number of bytes written to the current file
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,49 @@
+package sinks
+
+import (
+	"math/big"
+
+	"usdc-event-tracker/internal/erc20"
+)
+
+// PrimaryStrategy selects which Transfer of a transaction fills the event-level
+// from, to and value fields. A transaction can emit several USDC transfers, e.g.
+// a swap routing through USDC; every log is still listed in the logs array.
+type PrimaryStrategy string
+
+const (
+	PrimaryFirst   PrimaryStrategy = "first"   // First Transfer in log order
+	PrimaryLargest PrimaryStrategy = "largest" // Transfer with the largest value, ties go to the earliest
+)
+
+// PrimarySelection is the strategy used by NewEventJSON. It is set once at
+// startup from PRIMARY_EVENT, before any events are processed.
+var PrimarySelection = PrimaryLargest
+
+// primaryTransfer returns the primary Transfer among logs according to
+// PrimarySelection, or false if there is no Transfer with a decoded value
+func primaryTransfer(logs []LogJSON) (LogJSON, bool) {
+	var (
+		primary LogJSON
+		largest *big.Int
+	)
+
+	for _, log := range logs {
+		if log.Type != string(erc20.Transfer) || log.Value == "" {
+			continue
+		}
+		if PrimarySelection == PrimaryFirst {
+			return log, true
+		}
+
+		value, ok := new(big.Int).SetString(log.Value, 10)
+		if !ok {
+			continue
+		}
+		if largest == nil || value.Cmp(largest) > 0 {
+			primary, largest = log, value
+		}
+	}
+
+	return primary, largest != nil
+}
@@ -0,0 +1,80 @@
+package sinks
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Receipt-level fields a sink can persist. The block number and transaction
+// hash identify an event and are always kept.
+const (
+	FieldTxIndex           = "tx_index"
+	FieldStatus            = "status"
+	FieldGasUsed           = "gas_used"
+	FieldCumulativeGasUsed = "cumulative_gas_used"
+	FieldEffectiveGasPrice = "effective_gas_price"
+	FieldTxType            = "tx_type"
+	FieldLogsCount         = "logs_count"
+)
+
+// ReceiptFieldNames lists every selectable receipt field.
+var ReceiptFieldNames = []string{
+	FieldTxIndex,
+	FieldStatus,
+	FieldGasUsed,
+	FieldCumulativeGasUsed,
+	FieldEffectiveGasPrice,
+	FieldTxType,
+	FieldLogsCount,
+}
+
+// ReceiptFields is the allowlist of receipt-level fields a sink persists.
+// A nil set keeps every field.
+type ReceiptFields map[string]bool
+
+// ParseReceiptFields builds an allowlist from field names. An empty list
+// returns nil, keeping every field.
+func ParseReceiptFields(names []string) (ReceiptFields, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	fields := make(ReceiptFields, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !isReceiptField(name) {
+			return nil, fmt.Errorf("unknown receipt field %q, supported fields: %s", name, strings.Join(ReceiptFieldNames, ", "))
+		}
+		fields[name] = true
+	}
+	return fields, nil
+}
+
+// Has reports whether the field should be persisted.
+func (f ReceiptFields) Has(name string) bool {
+	return f == nil || f[name]
+}
+
+// NeedsReceipt reports whether any selected field has to be read from the full
+// transaction receipt. The transaction index is known from its logs, and a
+// transaction that emitted logs succeeded, so those two never need it.
+func (f ReceiptFields) NeedsReceipt() bool {
+	if f == nil {
+		return true
+	}
+	for name := range f {
+		if name != FieldTxIndex && name != FieldStatus {
+			return true
+		}
+	}
+	return false
+}
+
+func isReceiptField(name string) bool {
+	for _, field := range ReceiptFieldNames {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
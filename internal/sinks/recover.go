@@ -0,0 +1,37 @@
+package sinks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"usdc-event-tracker/internal/logging"
+)
+
+// ErrSinkPanic is returned for writes during which a sink panicked
+var ErrSinkPanic = errors.New("sink panicked")
+
+// safeWrite calls sink.Write, converting a panic into an ErrSinkPanic error so a
+// misbehaving sink cannot crash the process and take every other sink with it.
+// The panic is logged with its stack trace.
+func safeWrite(ctx context.Context, sink Sink, events []Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %s: %v", ErrSinkPanic, sink.Name(), r)
+
+			fields := map[string]interface{}{
+				"sink_name":   sink.Name(),
+				"event_count": len(events),
+				"stack":       string(debug.Stack()),
+			}
+			if len(events) > 0 {
+				fields["first_block"] = events[0].BlockNumber
+				fields["last_block"] = events[len(events)-1].BlockNumber
+			}
+			logging.GetLogger("sink-manager").Error("Recovered from panic in sink write", err, fields)
+		}
+	}()
+
+	return sink.Write(ctx, events)
+}
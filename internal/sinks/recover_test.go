@@ -0,0 +1,64 @@
+package sinks
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// panickingSink panics on every write
+type panickingSink struct {
+	*MemorySink
+}
+
+func (p *panickingSink) Name() string {
+	return "panicking"
+}
+
+func (p *panickingSink) Write(ctx context.Context, events []Event) error {
+	panic("boom")
+}
+
+func TestManagerRecoversFromPanickingSink(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(m *Manager)
+	}{
+		{"direct", func(m *Manager) {}},
+		{"ordered", func(m *Manager) { m.SetOrderWindow(4) }},
+		{"buffered", func(m *Manager) { m.SetBuffer(4, BufferBlock, nil) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			before, after := NewMemorySink(), NewMemorySink()
+
+			m := NewManager()
+			m.AddSink(before)
+			m.AddSink(&panickingSink{MemorySink: NewMemorySink()})
+			m.AddSink(after)
+			tt.setup(m)
+			if err := m.Initialize(ctx); err != nil {
+				t.Fatalf("Initialize: %v", err)
+			}
+			defer m.Close()
+
+			var errs []error
+			for block := uint64(1); block <= 2; block++ {
+				errs = append(errs, m.Write(ctx, blockEvents(block)))
+			}
+			errs = append(errs, m.Flush(ctx))
+			if err := errors.Join(errs...); !errors.Is(err, ErrSinkPanic) {
+				t.Errorf("Write and Flush returned %v, want %v", err, ErrSinkPanic)
+			}
+
+			for _, sink := range []*MemorySink{before, after} {
+				events := sink.Events()
+				if len(events) != 2 || events[0].BlockNumber != 1 || events[1].BlockNumber != 2 {
+					t.Errorf("healthy sink got %d events, want blocks 1 and 2", len(events))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package sinks
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Options carries tracker-wide settings that sink factories apply on top of
+// their own environment configuration.
+type Options struct {
+	USDCAddress   string         // Primary USDC contract address being tracked
+	ReceiptFields ReceiptFields  // Receipt-level fields to persist, nil keeps all
+	MaxPending    int            // Events a batching sink may buffer before Write blocks, 0 disables
+	Location      *time.Location // Time zone of day boundaries for daily rotation and date-suffixed names
+	ChainID       uint64         // Chain ID of the tracked network, 0 for local networks
+}
+
+// Factory creates a sink. Factories should only build configuration; connecting
+// to external services belongs in Sink.Initialize.
+type Factory func(opts Options) (Sink, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes a sink available under name. Sink packages call it from init,
+// so a sink is enabled by importing its package (see package sinks/all).
+// It panics if name is registered twice or factory is nil.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if factory == nil {
+		panic("sinks: Register factory is nil for " + name)
+	}
+	if _, dup := registry[name]; dup {
+		panic("sinks: Register called twice for " + name)
+	}
+	registry[name] = factory
+}
+
+// Lookup returns the factory registered under name.
+func Lookup(name string) (Factory, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	factory, ok := registry[name]
+	return factory, ok
+}
+
+// Registered returns the sorted names of all registered sinks.
+func Registered() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Create builds the sink registered under name.
+func Create(name string, opts Options) (Sink, error) {
+	factory, ok := Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown sink %q", name)
+	}
+	return factory(opts)
+}
@@ -0,0 +1,431 @@
+// Package s3 implements a sink that archives events to AWS S3 or any S3-compatible object storage
+package s3
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	awss3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"usdc-event-tracker/internal/logging"
+	"usdc-event-tracker/internal/sinks"
+)
+
+// Config holds S3 sink configuration
+type Config struct {
+	Bucket        string              // Destination bucket
+	Prefix        string              // Key prefix inside the bucket
+	Region        string              // AWS region (falls back to the credential chain default)
+	Endpoint      string              // Custom endpoint for S3-compatible storage (optional)
+	Compress      bool                // Whether to gzip objects
+	MaxObjectSize int64               // Rotate once the buffered object reaches this many bytes
+	FlushInterval time.Duration       // Rotate once the buffered object is this old
+	ReceiptFields sinks.ReceiptFields // Receipt-level fields to archive, nil archives all
+	MaxPending    int                 // Block writes while this many events await upload, 0 disables the limit
+}
+
+// Sink implements the sinks.Sink interface for S3.
+// Events are buffered as JSON Lines and uploaded as one object per rotation.
+type Sink struct {
+	config Config
+	client *awss3.Client
+	logger *logging.Logger
+
+	// Current object buffer
+	mu          sync.Mutex
+	buf         bytes.Buffer
+	gz          *gzip.Writer
+	eventCount  int
+	firstBlock  uint64
+	lastBlock   uint64
+	firstTime   time.Time // Block time of the earliest event, used for partitioning
+	objectStart time.Time
+
+	// Background rotation
+	done chan struct{}
+	wg   sync.WaitGroup
+
+	// Metrics
+	totalEvents       int64
+	totalObjects      int64
+	backpressureWaits int64
+}
+
+func init() {
+	sinks.Register("s3", func(opts sinks.Options) (sinks.Sink, error) {
+		config := NewConfig()
+		config.ReceiptFields = opts.ReceiptFields
+		config.MaxPending = opts.MaxPending
+		return New(config), nil
+	})
+}
+
+// NewConfig creates a new S3 configuration from environment variables
+func NewConfig() Config {
+	config := Config{
+		Prefix:        "usdc-events",
+		Region:        os.Getenv("S3_REGION"),
+		Endpoint:      os.Getenv("S3_ENDPOINT"),
+		Bucket:        os.Getenv("S3_BUCKET"),
+		Compress:      true,
+		MaxObjectSize: 64 * 1024 * 1024,
+		FlushInterval: 15 * time.Minute,
+	}
+
+	if prefix, ok := os.LookupEnv("S3_PREFIX"); ok {
+		config.Prefix = strings.Trim(prefix, "/")
+	}
+
+	if compress := os.Getenv("S3_COMPRESS"); compress != "" {
+		config.Compress = strings.ToLower(compress) == "true"
+	}
+
+	if size := os.Getenv("S3_MAX_OBJECT_SIZE"); size != "" {
+		if n, err := strconv.ParseInt(size, 10, 64); err == nil && n > 0 {
+			config.MaxObjectSize = n
+		}
+	}
+
+	if interval := os.Getenv("S3_FLUSH_INTERVAL"); interval != "" {
+		if d, err := time.ParseDuration(interval); err == nil && d > 0 {
+			config.FlushInterval = d
+		}
+	}
+
+	return config
+}
+
+// New creates a new S3 sink
+func New(config Config) *Sink {
+	return &Sink{
+		config: config,
+		logger: logging.GetLogger("s3-sink"),
+		done:   make(chan struct{}),
+	}
+}
+
+// Name returns the sink name
+func (s *Sink) Name() string {
+	return "s3"
+}
+
+// Initialize loads AWS credentials from the standard chain and verifies bucket access
+func (s *Sink) Initialize(ctx context.Context) error {
+	if s.config.Bucket == "" {
+		return fmt.Errorf("S3_BUCKET environment variable is required for the s3 sink")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var opts []func(*awsconfig.LoadOptions) error
+	if s.config.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(s.config.Region))
+	}
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		s.logger.Error("Failed to load AWS configuration", err)
+		return fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+
+	s.client = awss3.NewFromConfig(awsCfg, func(o *awss3.Options) {
+		if s.config.Endpoint != "" {
+			o.BaseEndpoint = aws.String(s.config.Endpoint)
+			o.UsePathStyle = true
+		}
+	})
+
+	if _, err := s.client.HeadBucket(ctx, &awss3.HeadBucketInput{Bucket: aws.String(s.config.Bucket)}); err != nil {
+		s.logger.Error("Failed to access S3 bucket", err, map[string]interface{}{
+			"bucket": s.config.Bucket,
+		})
+		return fmt.Errorf("failed to access S3 bucket %s: %w", s.config.Bucket, err)
+	}
+
+	s.resetBuffer()
+
+	s.wg.Add(1)
+	go s.rotationWorker()
+
+	s.logger.Info("Connected to S3", map[string]interface{}{
+		"bucket":          s.config.Bucket,
+		"prefix":          s.config.Prefix,
+		"region":          awsCfg.Region,
+		"compress":        s.config.Compress,
+		"max_object_size": s.config.MaxObjectSize,
+		"flush_interval":  s.config.FlushInterval.String(),
+	})
+
+	return nil
+}
+
+// Write appends events to the current object buffer, uploading it when it is full
+func (s *Sink) Write(ctx context.Context, events []sinks.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.waitForCapacity(ctx); err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		line, err := sinks.MarshalEvent(event, s.config.ReceiptFields)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event: %w", err)
+		}
+		line = append(line, '\n')
+
+		if _, err := s.writer().Write(line); err != nil {
+			return fmt.Errorf("failed to buffer event: %w", err)
+		}
+
+		if s.eventCount == 0 || event.BlockNumber < s.firstBlock {
+			s.firstBlock = event.BlockNumber
+			s.firstTime = event.Timestamp()
+		}
+		if event.BlockNumber > s.lastBlock {
+			s.lastBlock = event.BlockNumber
+		}
+		s.eventCount++
+	}
+
+	if int64(s.buf.Len()) >= s.config.MaxObjectSize {
+		return s.upload(ctx)
+	}
+
+	return nil
+}
+
+// waitForCapacity blocks while MaxPending events are buffered, retrying the upload
+// with backoff so a stalled bucket slows the tracker down instead of growing the
+// buffer without bound. Callers must hold s.mu; it is released while waiting.
+func (s *Sink) waitForCapacity(ctx context.Context) error {
+	if s.config.MaxPending <= 0 {
+		return nil
+	}
+
+	backoff := time.Second
+	for s.eventCount >= s.config.MaxPending {
+		err := s.upload(ctx)
+		if err == nil {
+			return nil
+		}
+
+		s.backpressureWaits++
+		s.logger.Warn("S3 sink at max pending events, waiting for upload", map[string]interface{}{
+			"pending_events": s.eventCount,
+			"max_pending":    s.config.MaxPending,
+			"retry_in":       backoff.String(),
+		})
+
+		s.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			s.mu.Lock()
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		s.mu.Lock()
+
+		if backoff *= 2; backoff > 30*time.Second {
+			backoff = 30 * time.Second
+		}
+	}
+
+	return nil
+}
+
+// Stats implements sinks.StatReporter
+func (s *Sink) Stats() map[string]interface{} {
+	return s.GetStatistics()
+}
+
+// GetStatistics returns sink statistics
+func (s *Sink) GetStatistics() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return map[string]interface{}{
+		"total_events":       s.totalEvents,
+		"total_objects":      s.totalObjects,
+		"pending_events":     s.eventCount,
+		"pending_bytes":      s.buf.Len(),
+		"max_pending":        s.config.MaxPending,
+		"backpressure_waits": s.backpressureWaits,
+	}
+}
+
+// Flush uploads the current object immediately, regardless of the flush interval
+func (s *Sink) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client == nil {
+		return nil
+	}
+	return s.upload(ctx)
+}
+
+// Close uploads any buffered events and stops the rotation worker
+func (s *Sink) Close() error {
+	close(s.done)
+	s.wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var err error
+	if s.client != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		err = s.upload(ctx)
+	}
+
+	s.logger.Info("Closing S3 sink", map[string]interface{}{
+		"total_events":  s.totalEvents,
+		"total_objects": s.totalObjects,
+	})
+
+	return err
+}
+
+// rotationWorker uploads the current object once it exceeds the flush interval
+func (s *Sink) rotationWorker() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(time.Minute)
+	if s.config.FlushInterval < time.Minute {
+		ticker.Reset(s.config.FlushInterval)
+	}
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			if time.Since(s.objectStart) >= s.config.FlushInterval {
+				if err := s.upload(context.Background()); err != nil {
+					s.logger.Error("Failed to upload S3 object", err)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// upload sends the buffered object to S3 and starts a new one. Callers must hold s.mu.
+func (s *Sink) upload(ctx context.Context) error {
+	if s.eventCount == 0 {
+		s.objectStart = time.Now()
+		return nil
+	}
+
+	if s.gz != nil {
+		if err := s.gz.Close(); err != nil {
+			return fmt.Errorf("failed to finalize gzip stream: %w", err)
+		}
+	}
+
+	key := s.objectKey(s.firstTime)
+	start := time.Now()
+
+	input := &awss3.PutObjectInput{
+		Bucket:      aws.String(s.config.Bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(s.buf.Bytes()),
+		ContentType: aws.String("application/x-ndjson"),
+	}
+	if s.config.Compress {
+		input.ContentEncoding = aws.String("gzip")
+	}
+
+	if _, err := s.client.PutObject(ctx, input); err != nil {
+		s.logger.Error("Failed to upload S3 object", err, map[string]interface{}{
+			"bucket":      s.config.Bucket,
+			"key":         key,
+			"event_count": s.eventCount,
+		})
+		// Keep the buffer so the next rotation retries the upload
+		s.reopenGzip()
+		return fmt.Errorf("failed to upload object %s: %w", key, err)
+	}
+
+	s.logger.Info("Uploaded S3 object", map[string]interface{}{
+		"bucket":      s.config.Bucket,
+		"key":         key,
+		"event_count": s.eventCount,
+		"bytes":       s.buf.Len(),
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+
+	s.totalEvents += int64(s.eventCount)
+	s.totalObjects++
+	s.resetBuffer()
+
+	return nil
+}
+
+// objectKey builds a Hive-style partitioned key for Athena/Glue, e.g.
+// usdc-events/year=2024/month=01/day=15/19000000-19000042.jsonl.gz
+func (s *Sink) objectKey(t time.Time) string {
+	t = t.UTC()
+	name := fmt.Sprintf("%d-%d.jsonl", s.firstBlock, s.lastBlock)
+	if s.config.Compress {
+		name += ".gz"
+	}
+
+	return path.Join(
+		s.config.Prefix,
+		fmt.Sprintf("year=%04d", t.Year()),
+		fmt.Sprintf("month=%02d", t.Month()),
+		fmt.Sprintf("day=%02d", t.Day()),
+		name,
+	)
+}
+
+// writer returns the writer for the current object, compressing if enabled
+func (s *Sink) writer() io.Writer {
+	if s.gz != nil {
+		return s.gz
+	}
+	return &s.buf
+}
+
+// resetBuffer clears the current object and starts a new one
+func (s *Sink) resetBuffer() {
+	s.buf.Reset()
+	s.gz = nil
+	if s.config.Compress {
+		s.gz = gzip.NewWriter(&s.buf)
+	}
+	s.eventCount = 0
+	s.firstBlock = 0
+	s.lastBlock = 0
+	s.firstTime = time.Time{}
+	s.objectStart = time.Now()
+}
+
+// reopenGzip re-creates the gzip writer after a failed upload so that
+// further events are appended as a new gzip member of the same object
+func (s *Sink) reopenGzip() {
+	if s.config.Compress {
+		s.gz = gzip.NewWriter(&s.buf)
+	}
+}
@@ -0,0 +1,156 @@
+package sinks
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/fnv"
+	"math/big"
+	"math/rand/v2"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"usdc-event-tracker/internal/erc20"
+)
+
+// SampleMode selects how SamplingSink decides which logs to keep
+type SampleMode string
+
+const (
+	SampleHash   SampleMode = "hash"   // Keep a log if the hash of its tx hash and log index falls below the rate
+	SampleRandom SampleMode = "random" // Keep each log with probability rate
+)
+
+// SamplingSink wraps another sink and only forwards a fraction of Transfer and
+// Approval logs. In hash mode the decision depends only on the log's tx hash and
+// index, so every sink and every replay keeps the same logs. Logs whose value is
+// at least minValue, and logs without a value such as Upgraded, always pass.
+// Events left without any kept logs are dropped.
+type SamplingSink struct {
+	sink     Sink
+	rate     float64
+	mode     SampleMode
+	minValue *big.Int
+
+	mu      sync.Mutex
+	kept    int64
+	dropped int64
+}
+
+// NewSamplingSink wraps sink so it receives roughly rate (0.0-1.0) of all logs.
+// A nil minValue samples logs regardless of their value.
+func NewSamplingSink(sink Sink, rate float64, mode SampleMode, minValue *big.Int) *SamplingSink {
+	return &SamplingSink{
+		sink:     sink,
+		rate:     rate,
+		mode:     mode,
+		minValue: minValue,
+	}
+}
+
+// Name returns the name of the wrapped sink.
+func (s *SamplingSink) Name() string {
+	return s.sink.Name()
+}
+
+// Initialize prepares the wrapped sink for use.
+func (s *SamplingSink) Initialize(ctx context.Context) error {
+	return s.sink.Initialize(ctx)
+}
+
+// Write forwards only sampled logs.
+func (s *SamplingSink) Write(ctx context.Context, events []Event) error {
+	filtered := make([]Event, 0, len(events))
+	var kept, dropped int64
+
+	for _, event := range events {
+		matched := event
+		matched.Logs = matched.Logs[:0:0]
+		for _, log := range event.Logs {
+			if s.keep(log) {
+				matched.Logs = append(matched.Logs, log)
+				kept++
+			} else {
+				dropped++
+			}
+		}
+
+		if len(matched.Logs) > 0 {
+			filtered = append(filtered, matched)
+		}
+	}
+
+	s.mu.Lock()
+	s.kept += kept
+	s.dropped += dropped
+	s.mu.Unlock()
+
+	return s.sink.Write(ctx, filtered)
+}
+
+// Close cleans up the wrapped sink.
+func (s *SamplingSink) Close() error {
+	return s.sink.Close()
+}
+
+// Flush flushes the wrapped sink if it buffers events.
+func (s *SamplingSink) Flush(ctx context.Context) error {
+	return flush(ctx, s.sink)
+}
+
+// WriteHeartbeat forwards a block heartbeat to the wrapped sink if it stores heartbeats.
+func (s *SamplingSink) WriteHeartbeat(ctx context.Context, heartbeat BlockHeartbeat) error {
+	return writeHeartbeat(ctx, s.sink, heartbeat)
+}
+
+// WriteBalances forwards balance snapshots to the wrapped sink if it stores them.
+func (s *SamplingSink) WriteBalances(ctx context.Context, snapshots []BalanceSnapshot) error {
+	return writeBalances(ctx, s.sink, snapshots)
+}
+
+// Stats returns the wrapped sink's metrics plus the sampling counts.
+func (s *SamplingSink) Stats() map[string]interface{} {
+	collected := make(map[string]interface{})
+	for key, value := range stats(s.sink) {
+		collected[key] = value
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	collected["sample_rate"] = s.rate
+	collected["sampled_logs_kept"] = s.kept
+	collected["sampled_logs_dropped"] = s.dropped
+	return collected
+}
+
+// keep reports whether a log passes sampling
+func (s *SamplingSink) keep(log *types.Log) bool {
+	if len(log.Topics) == 0 {
+		return true
+	}
+	event, found := erc20.GetEventBySignature(log.Topics[0].Hex())
+	if !found || (event != erc20.Transfer && event != erc20.Approval) {
+		return true
+	}
+
+	if s.minValue != nil {
+		if value, ok := erc20.DecodeValue(log.Data); ok && value.Cmp(s.minValue) >= 0 {
+			return true
+		}
+	}
+
+	if s.mode == SampleRandom {
+		return rand.Float64() < s.rate
+	}
+	return sampleFraction(log) < s.rate
+}
+
+// sampleFraction maps a log's tx hash and index to a stable value in [0, 1)
+func sampleFraction(log *types.Log) float64 {
+	h := fnv.New64a()
+	h.Write(log.TxHash.Bytes())
+	var index [8]byte
+	binary.BigEndian.PutUint64(index[:], uint64(log.Index))
+	h.Write(index[:])
+	return float64(h.Sum64()>>11) / float64(uint64(1)<<53)
+}
@@ -3,7 +3,14 @@ package sinks
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
 	"github.com/ethereum/go-ethereum/core/types"
+	"usdc-event-tracker/internal/erc20"
+	"usdc-event-tracker/internal/logging"
 )
 
 // Event represents a processed USDC event with metadata
@@ -11,6 +18,25 @@ type Event struct {
 	BlockNumber uint64
 	Receipt     *types.Receipt
 	Logs        []*types.Log
+	Variant     string    // USDC variant the logs belong to (native or bridged)
+	BlockTime   time.Time // Timestamp from the block header
+	IngestedAt  time.Time // When the tracker processed the block
+
+	// A Transfer value exceeds MAX_SANE_VALUE, likely a decoding bug (MAX_SANE_VALUE_ACTION=flag)
+	SuspectValue bool
+
+	// Recognized logs decoded by the tracker, see DecodeLogs. Filters that drop logs
+	// keep the map, which is never modified. Sinks read it through Decode.
+	Decoded map[*types.Log]erc20.Decoded
+}
+
+// Timestamp returns the block time, falling back to the ingestion time if the
+// block time is unknown. Sinks should use it for their primary timestamp field.
+func (e Event) Timestamp() time.Time {
+	if e.BlockTime.IsZero() {
+		return e.IngestedAt
+	}
+	return e.BlockTime
 }
 
 // Sink defines the interface for data output destinations
@@ -18,8 +44,9 @@ type Sink interface {
 	// Name returns the name of the sink
 	Name() string
 	
-	// Initialize prepares the sink for use
-	Initialize() error
+	// Initialize prepares the sink for use. Connecting to external services must
+	// honor ctx, so a hanging setup can be interrupted.
+	Initialize(ctx context.Context) error
 	
 	// Write sends events to the sink
 	Write(ctx context.Context, events []Event) error
@@ -28,9 +55,75 @@ type Sink interface {
 	Close() error
 }
 
+// Flusher is implemented by sinks that buffer events before persisting them.
+// Once Flush returns nil, every event previously passed to Write is durably stored,
+// which is what allows the tracker to advance its block cursor.
+type Flusher interface {
+	// Flush persists any buffered events immediately
+	Flush(ctx context.Context) error
+}
+
+// StatReporter is implemented by sinks that report throughput and pending counts.
+type StatReporter interface {
+	// Stats returns a snapshot of the sink's metrics
+	Stats() map[string]interface{}
+}
+
+// BlockLister is implemented by sinks that can report which blocks they hold,
+// which allows verifying a backfill for gaps (see package verify).
+type BlockLister interface {
+	// PersistedBlocks returns the distinct numbers of blocks in [from, to] with at
+	// least one stored event, in ascending order
+	PersistedBlocks(ctx context.Context, from, to uint64) ([]uint64, error)
+}
+
+// stats returns the sink's metrics, or nil if it does not report any
+func stats(sink Sink) map[string]interface{} {
+	if r, ok := sink.(StatReporter); ok {
+		return r.Stats()
+	}
+	return nil
+}
+
+// flush flushes sink if it buffers events
+func flush(ctx context.Context, sink Sink) error {
+	if f, ok := sink.(Flusher); ok {
+		return f.Flush(ctx)
+	}
+	return nil
+}
+
 // Manager orchestrates multiple sinks, allowing data to be sent to multiple destinations.
 type Manager struct {
 	sinks []Sink
+
+	// Sinks that may fail to initialize without aborting Initialize, by index
+	// into sinks, and the names of those that did, see AddOptionalSink
+	optional []bool
+	skipped  []string
+
+	// Bound on each sink's Initialize, see SetInitTimeout
+	initTimeout time.Duration
+
+	// Flush buffering sinks after every Write instead of waiting for a full batch
+	flushEveryWrite bool
+
+	// Guards queues and buffers, which Close clears while CollectStats may be
+	// reading them from another goroutine
+	mu sync.Mutex
+
+	// Deliver to each sink from its own goroutine in block order, see SetOrderWindow
+	orderWindow int
+	queues      []*orderedQueue
+
+	// Buffer each sink's writes and deliver them from its own goroutine, see SetBuffer
+	bufferSize   int
+	bufferPolicy BufferPolicy
+	buffers      []*sinkBuffer
+
+	// Abandon sink writes that take longer than this, see SetWriteTimeout
+	writeTimeout time.Duration
+	deadLetter   *DeadLetterFile
 }
 
 // NewManager creates a new sink manager with an empty list of sinks.
@@ -43,44 +136,353 @@ func NewManager() *Manager {
 // AddSink registers a new sink with the manager.
 func (m *Manager) AddSink(sink Sink) {
 	m.sinks = append(m.sinks, sink)
+	m.optional = append(m.optional, false)
+}
+
+// AddOptionalSink registers a sink that Initialize drops, instead of failing,
+// when it cannot be initialized, so the others keep running without it.
+func (m *Manager) AddOptionalSink(sink Sink) {
+	m.sinks = append(m.sinks, sink)
+	m.optional = append(m.optional, true)
+}
+
+// SetInitTimeout bounds each sink's Initialize by timeout, so a sink whose
+// backend hangs fails, or is skipped if optional, instead of blocking startup.
+// Zero disables the timeout. It must be called before Initialize.
+func (m *Manager) SetInitTimeout(timeout time.Duration) {
+	m.initTimeout = timeout
+}
+
+// Skipped returns the names of optional sinks that failed to initialize
+func (m *Manager) Skipped() []string {
+	return m.skipped
+}
+
+// SetFlushEveryWrite makes Write flush buffering sinks after each call, trading
+// throughput for latency.
+func (m *Manager) SetFlushEveryWrite(enabled bool) {
+	m.flushEveryWrite = enabled
+}
+
+// SetOrderWindow switches the manager to ordered delivery when window is positive:
+// each sink gets its own queue drained by a dedicated goroutine, so a slow sink no
+// longer delays the others and blocks reach every sink in block-number order even
+// if Write is called out of order. Blocks are reordered within a bounded window
+// (see orderedQueue); Write blocks once window blocks are queued for a sink.
+// It must be called before Initialize.
+func (m *Manager) SetOrderWindow(window int) {
+	m.orderWindow = window
+}
+
+// SetBuffer gives every sink a buffer of size writes drained by a dedicated
+// goroutine when size is positive, so a slow sink lags behind within bounds
+// instead of throttling the others. When a sink's buffer is full, policy decides
+// whether Write waits, the oldest buffered write is dropped, or the new events
+// are appended to deadLetter, which may be nil to only log them. The manager
+// closes deadLetter on Close. It must be called before Initialize and cannot be
+// combined with SetOrderWindow, whose queues already buffer every sink.
+func (m *Manager) SetBuffer(size int, policy BufferPolicy, deadLetter *DeadLetterFile) {
+	m.bufferSize = size
+	m.bufferPolicy = policy
+	if deadLetter != nil {
+		m.deadLetter = deadLetter
+	}
+}
+
+// SetWriteTimeout bounds every sink Write by timeout. A write that takes longer is
+// abandoned and its events are appended to deadLetter, which may be nil to only log
+// them, so a slow sink cannot stall block processing. Zero disables the timeout.
+// The manager closes deadLetter on Close. It must be called before Initialize.
+func (m *Manager) SetWriteTimeout(timeout time.Duration, deadLetter *DeadLetterFile) {
+	m.writeTimeout = timeout
+	if deadLetter != nil {
+		m.deadLetter = deadLetter
+	}
 }
 
 // Initialize prepares all registered sinks for use.
-// If any sink fails to initialize, the error is returned immediately.
-func (m *Manager) Initialize() error {
-	for _, sink := range m.sinks {
-		if err := sink.Initialize(); err != nil {
+// If a sink fails to initialize, or ctx is canceled, the error is returned immediately,
+// except for optional sinks, which are logged and dropped. It fails if no sink is left.
+func (m *Manager) Initialize(ctx context.Context) error {
+	if m.writeTimeout > 0 {
+		for i, sink := range m.sinks {
+			m.sinks[i] = newTimeoutSink(sink, m.writeTimeout, m.deadLetter)
+		}
+	}
+
+	ready := make([]Sink, 0, len(m.sinks))
+	for i, sink := range m.sinks {
+		if err := ctx.Err(); err != nil {
 			return err
 		}
+		if err := m.initializeSink(ctx, sink); err != nil {
+			if !m.optional[i] || ctx.Err() != nil {
+				return err
+			}
+			logging.GetLogger("sink-manager").Error("Optional sink failed to initialize, continuing without it", err, map[string]interface{}{
+				"sink": sink.Name(),
+			})
+			m.skipped = append(m.skipped, sink.Name())
+			continue
+		}
+		ready = append(ready, sink)
+	}
+	if len(ready) == 0 && len(m.skipped) > 0 {
+		return fmt.Errorf("no sink could be initialized, skipped %v", m.skipped)
+	}
+	m.sinks = ready
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.orderWindow > 0 {
+		for _, sink := range m.sinks {
+			m.queues = append(m.queues, newOrderedQueue(sink, m.orderWindow, m.flushEveryWrite))
+		}
+	} else if m.bufferSize > 0 {
+		for _, sink := range m.sinks {
+			m.buffers = append(m.buffers, newSinkBuffer(sink, m.bufferSize, m.bufferPolicy, m.deadLetter, m.flushEveryWrite))
+		}
 	}
 	return nil
 }
 
+// initializeSink initializes sink within the init timeout, if any
+func (m *Manager) initializeSink(ctx context.Context, sink Sink) error {
+	if m.initTimeout <= 0 {
+		return sink.Initialize(ctx)
+	}
+
+	initCtx, cancel := context.WithTimeout(ctx, m.initTimeout)
+	defer cancel()
+	err := sink.Initialize(initCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(initCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%s did not initialize within %s: %w", sink.Name(), m.initTimeout, err)
+	}
+	return err
+}
+
 // Write distributes events to all registered sinks.
-// Errors from individual sinks are logged but don't stop other sinks from receiving data.
+// A sink that fails, or panics (see safeWrite), doesn't stop other sinks from
+// receiving data; the failures are logged and returned joined, so the caller
+// does not checkpoint past events a sink did not persist.
+// With ordered delivery or buffers, Write only queues the events and returns once
+// every sink's queue or buffer has accepted them; failed deliveries are returned
+// by the next Flush instead.
 func (m *Manager) Write(ctx context.Context, events []Event) error {
+	if m.buffers != nil {
+		for _, b := range m.buffers {
+			if err := b.enqueue(ctx, events); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if m.queues != nil {
+		for _, batch := range groupByBlock(events) {
+			for _, q := range m.queues {
+				if err := q.enqueue(ctx, batch[0].BlockNumber, batch); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	var errs []error
 	for _, sink := range m.sinks {
-		if err := sink.Write(ctx, events); err != nil {
-			// Log error but continue with other sinks
-			// In production, you might want different error handling
-			continue
+		err := safeWrite(ctx, sink, events)
+		if err == nil && m.flushEveryWrite {
+			// Flush errors are treated like write errors
+			err = flush(ctx, sink)
+		}
+		if err != nil {
+			logging.GetLogger("sink-manager").Error("Sink write failed", err, map[string]interface{}{
+				"sink_name":   sink.Name(),
+				"event_count": len(events),
+			})
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WriteHeartbeat hands a block heartbeat to every sink that stores heartbeats
+// (see HeartbeatWriter). Like events, heartbeats go through ordered queues and
+// buffers, so a sink sees them in order with its writes; a heartbeat that does
+// not fit in a full buffer is dropped. Errors are ignored: unlike events,
+// heartbeats are not needed to resume.
+func (m *Manager) WriteHeartbeat(ctx context.Context, heartbeat BlockHeartbeat) error {
+	if m.buffers != nil {
+		for _, b := range m.buffers {
+			b.enqueueHeartbeat(ctx, heartbeat)
+		}
+		return nil
+	}
+
+	if m.queues != nil {
+		for _, q := range m.queues {
+			if err := q.enqueueHeartbeat(ctx, heartbeat); err != nil {
+				return err
+			}
 		}
+		return nil
+	}
+
+	for _, sink := range m.sinks {
+		writeHeartbeat(ctx, sink, heartbeat)
 	}
 	return nil
 }
 
+// WriteBalances hands the balance snapshots taken at one block to every sink that
+// stores them (see BalanceWriter), after the block's events. Like events, they go
+// through ordered queues and buffers, waiting for room in a full buffer. Errors are
+// ignored, as snapshots are not needed to resume either.
+func (m *Manager) WriteBalances(ctx context.Context, snapshots []BalanceSnapshot) error {
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	if m.buffers != nil {
+		for _, b := range m.buffers {
+			if err := b.enqueueBalances(ctx, snapshots); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if m.queues != nil {
+		for _, q := range m.queues {
+			if err := q.enqueueBalances(ctx, snapshots); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, sink := range m.sinks {
+		writeBalances(ctx, sink, snapshots)
+	}
+	return nil
+}
+
+// Flush persists events buffered by any registered sink. Sinks that do not
+// buffer are skipped. All sinks are flushed; the first error is returned.
+// With ordered delivery or buffers, queued writes are delivered before each sink
+// is flushed, and a sink that failed to write any of them reports that failure
+// on every later Flush, since its events are lost.
+func (m *Manager) Flush(ctx context.Context) error {
+	var firstErr error
+	if m.buffers != nil {
+		for _, b := range m.buffers {
+			if err := b.flush(ctx); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	}
+	if m.queues != nil {
+		for _, q := range m.queues {
+			if err := q.flush(ctx); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	}
+
+	for _, sink := range m.sinks {
+		if err := flush(ctx, sink); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// ErrCloseTimeout is returned by CloseTimeout when sinks do not close in time.
+var ErrCloseTimeout = errors.New("timed out closing sinks")
+
+// CloseTimeout closes all sinks like Close, but gives up after timeout and returns
+// ErrCloseTimeout. Sinks still closing at that point are abandoned, so the caller
+// should exit. A timeout of zero waits indefinitely.
+func (m *Manager) CloseTimeout(timeout time.Duration) error {
+	if timeout <= 0 {
+		return m.Close()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Close()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return ErrCloseTimeout
+	}
+}
+
 // Close cleanly shuts down all registered sinks.
 // All sinks are closed even if some return errors.
+// With ordered delivery or buffers, queued writes are delivered first.
 func (m *Manager) Close() error {
+	m.mu.Lock()
+	queues, buffers := m.queues, m.buffers
+	m.queues, m.buffers = nil, nil
+	m.mu.Unlock()
+
+	for _, q := range queues {
+		q.close()
+	}
+	for _, b := range buffers {
+		b.close()
+	}
+
 	for _, sink := range m.sinks {
 		if err := sink.Close(); err != nil {
 			// Log error but continue closing others
 			continue
 		}
 	}
+	m.deadLetter.Close()
 	return nil
 }
 
+// CollectStats gathers metrics from every registered sink that reports them, keyed by sink name.
+// It is safe to call from another goroutine while the manager is writing or closing.
+func (m *Manager) CollectStats() map[string]map[string]interface{} {
+	m.mu.Lock()
+	queues, buffers := m.queues, m.buffers
+	m.mu.Unlock()
+
+	collected := make(map[string]map[string]interface{}, len(m.sinks))
+	for i, sink := range m.sinks {
+		s := stats(sink)
+		if i < len(queues) {
+			if s == nil {
+				s = make(map[string]interface{})
+			}
+			for key, value := range queues[i].stats() {
+				s[key] = value
+			}
+		}
+		if i < len(buffers) {
+			if s == nil {
+				s = make(map[string]interface{})
+			}
+			for key, value := range buffers[i].stats() {
+				s[key] = value
+			}
+		}
+		if s != nil {
+			collected[sink.Name()] = s
+		}
+	}
+	return collected
+}
+
 // HasSink checks if a sink with the specified name is registered.
 func (m *Manager) HasSink(name string) bool {
 	for _, sink := range m.sinks {
@@ -0,0 +1,144 @@
+package sinks
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// errSinkDown is returned by failingSink
+var errSinkDown = errors.New("sink down")
+
+// failingSink fails every write of a block in failBlocks and accepts the rest
+type failingSink struct {
+	*MemorySink
+	failBlocks map[uint64]bool
+}
+
+func newFailingSink(failBlocks ...uint64) *failingSink {
+	s := &failingSink{MemorySink: NewMemorySink(), failBlocks: make(map[uint64]bool)}
+	s.name = "failing"
+	for _, block := range failBlocks {
+		s.failBlocks[block] = true
+	}
+	return s
+}
+
+func (s *failingSink) Write(ctx context.Context, events []Event) error {
+	for _, event := range events {
+		if s.failBlocks[event.BlockNumber] {
+			return errSinkDown
+		}
+	}
+	return s.MemorySink.Write(ctx, events)
+}
+
+func blockEvents(blockNumber uint64) []Event {
+	return []Event{{BlockNumber: blockNumber}}
+}
+
+func TestManagerWriteReturnsSinkErrors(t *testing.T) {
+	ctx := context.Background()
+	memory := NewMemorySink()
+	failing := newFailingSink(2)
+
+	m := NewManager()
+	m.AddSink(failing)
+	m.AddSink(memory)
+	if err := m.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	defer m.Close()
+
+	if err := m.Write(ctx, blockEvents(1)); err != nil {
+		t.Fatalf("Write(block 1) = %v, want nil", err)
+	}
+	if err := m.Write(ctx, blockEvents(2)); !errors.Is(err, errSinkDown) {
+		t.Fatalf("Write(block 2) = %v, want %v", err, errSinkDown)
+	}
+	if got := memory.Count(); got != 2 {
+		t.Errorf("healthy sink got %d events, want 2", got)
+	}
+}
+
+func TestManagerFlushReportsFailedQueuedWrite(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(m *Manager)
+	}{
+		{"ordered", func(m *Manager) { m.SetOrderWindow(4) }},
+		{"buffered", func(m *Manager) { m.SetBuffer(4, BufferBlock, nil) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m := NewManager()
+			m.AddSink(newFailingSink(2))
+			tt.setup(m)
+			if err := m.Initialize(ctx); err != nil {
+				t.Fatalf("Initialize: %v", err)
+			}
+			defer m.Close()
+
+			if err := m.Write(ctx, blockEvents(1)); err != nil {
+				t.Fatalf("Write(block 1) = %v, want nil", err)
+			}
+			if err := m.Flush(ctx); err != nil {
+				t.Fatalf("Flush after block 1 = %v, want nil", err)
+			}
+
+			for block := uint64(2); block <= 3; block++ {
+				if err := m.Write(ctx, blockEvents(block)); err != nil {
+					t.Fatalf("Write(block %d) = %v, want nil", block, err)
+				}
+			}
+			// The lost block keeps failing flushes, even after later blocks succeed
+			for i := 0; i < 2; i++ {
+				if err := m.Flush(ctx); !errors.Is(err, errSinkDown) {
+					t.Fatalf("Flush #%d = %v, want %v", i+1, err, errSinkDown)
+				}
+			}
+		})
+	}
+}
+
+func TestManagerCollectStatsDuringClose(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(m *Manager)
+	}{
+		{"ordered", func(m *Manager) { m.SetOrderWindow(4) }},
+		{"buffered", func(m *Manager) { m.SetBuffer(4, BufferBlock, nil) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m := NewManager()
+			m.AddSink(NewMemorySink())
+			tt.setup(m)
+			if err := m.Initialize(ctx); err != nil {
+				t.Fatalf("Initialize: %v", err)
+			}
+			if err := m.Write(ctx, blockEvents(1)); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+
+			// Run with -race: stats are read while Close clears the queues or buffers
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for i := 0; i < 100; i++ {
+					m.CollectStats()
+				}
+			}()
+			m.Close()
+			<-done
+
+			if _, ok := m.CollectStats()["memory"]; ok {
+				t.Errorf("CollectStats after Close still reports queue or buffer stats")
+			}
+		})
+	}
+}
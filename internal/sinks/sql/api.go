@@ -0,0 +1,221 @@
+package sql
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// queryMaxLimit caps the page size of /events range queries
+const queryMaxLimit = 1000
+
+// defaultQueryHost keeps the query API local unless QUERY_HOST says otherwise
+const defaultQueryHost = "127.0.0.1"
+
+// startQueryAPI serves the read-only query API on QueryHost:QueryPort:
+//
+//	GET /events?block=N                         events of one block
+//	GET /events?from=N&to=M[&limit=L&after=ID]  events of a block range, paginated by id
+//	GET /logs?tx=0x...                          logs of one transaction
+//
+// Responses are JSON. With QueryToken set, requests must send it as a bearer token.
+// The token is required when listening on anything but a loopback address.
+func (s *SQLSink) startQueryAPI() error {
+	host := s.config.QueryHost
+	if host == "" {
+		host = defaultQueryHost
+	}
+	if s.config.QueryToken == "" && !isLoopback(host) {
+		return fmt.Errorf("QUERY_TOKEN is required to serve the query API on non-loopback address %q", host)
+	}
+
+	address := net.JoinHostPort(host, strconv.Itoa(s.config.QueryPort))
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on query address %s: %w", address, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", s.handleEvents)
+	mux.HandleFunc("/logs", s.handleLogs)
+
+	s.queryServer = &http.Server{
+		Handler:           s.authorize(mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.queryServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("Query API stopped", err, map[string]interface{}{
+				"address": address,
+			})
+		}
+	}()
+
+	s.logger.Info("Serving SQL query API", map[string]interface{}{
+		"address":       listener.Addr().String(),
+		"authenticated": s.config.QueryToken != "",
+	})
+	return nil
+}
+
+// stopQueryAPI stops accepting queries and waits briefly for those in flight
+func (s *SQLSink) stopQueryAPI() {
+	if s.queryServer == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.queryServer.Shutdown(ctx); err != nil {
+		s.queryServer.Close()
+	}
+}
+
+// authorize only lets through GET requests carrying the query token, if one is set
+func (s *SQLSink) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			writeQueryError(w, http.StatusMethodNotAllowed, "only GET is supported")
+			return
+		}
+
+		if s.config.QueryToken != "" {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.config.QueryToken)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeQueryError(w, http.StatusUnauthorized, "missing or invalid token")
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleEvents serves /events?block= and /events?from=&to=
+func (s *SQLSink) handleEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	if query.Has("block") {
+		block, err := strconv.ParseUint(query.Get("block"), 10, 64)
+		if err != nil {
+			writeQueryError(w, http.StatusBadRequest, "block must be a block number")
+			return
+		}
+
+		events, err := s.GetEventsByBlock(block)
+		if err != nil {
+			s.queryFailed(w, r, err)
+			return
+		}
+		writeQueryResult(w, map[string]interface{}{"events": events})
+		return
+	}
+
+	from, fromErr := strconv.ParseUint(query.Get("from"), 10, 64)
+	to, toErr := strconv.ParseUint(query.Get("to"), 10, 64)
+	if fromErr != nil || toErr != nil || from > to {
+		writeQueryError(w, http.StatusBadRequest, "pass block, or from and to block numbers with from <= to")
+		return
+	}
+
+	limit := int64(100)
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed <= 0 || parsed > queryMaxLimit {
+			writeQueryError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", queryMaxLimit))
+			return
+		}
+		limit = parsed
+	}
+	var afterID int64
+	if value := query.Get("after"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed < 0 {
+			writeQueryError(w, http.StatusBadRequest, "after must be a next_cursor from a previous page")
+			return
+		}
+		afterID = parsed
+	}
+
+	events, nextCursor, err := s.GetEventsByRange(from, to, limit, afterID)
+	if err != nil {
+		s.queryFailed(w, r, err)
+		return
+	}
+	writeQueryResult(w, map[string]interface{}{
+		"events":      events,
+		"next_cursor": nextCursor,
+	})
+}
+
+// handleLogs serves /logs?tx=
+func (s *SQLSink) handleLogs(w http.ResponseWriter, r *http.Request) {
+	txHash := strings.ToLower(r.URL.Query().Get("tx"))
+	if !isTxHash(txHash) {
+		writeQueryError(w, http.StatusBadRequest, "tx must be a 0x-prefixed 32-byte transaction hash")
+		return
+	}
+
+	logs, err := s.GetLogsByTx(txHash)
+	if err != nil {
+		s.queryFailed(w, r, err)
+		return
+	}
+	writeQueryResult(w, map[string]interface{}{"logs": logs})
+}
+
+// queryFailed logs a failed database query and answers with a generic error,
+// so database details are not exposed to API clients
+func (s *SQLSink) queryFailed(w http.ResponseWriter, r *http.Request, err error) {
+	s.logger.Error("Query API request failed", err, map[string]interface{}{
+		"path":  r.URL.Path,
+		"query": r.URL.RawQuery,
+	})
+	writeQueryError(w, http.StatusInternalServerError, "query failed")
+}
+
+// writeQueryResult writes result as a JSON response
+func writeQueryResult(w http.ResponseWriter, result interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// writeQueryError writes a JSON error response with the given status
+func writeQueryError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// isLoopback reports whether host only accepts local connections
+func isLoopback(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// isTxHash reports whether s is a lowercase 0x-prefixed 32-byte hash, the form
+// transaction hashes are stored in
+func isTxHash(s string) bool {
+	if len(s) != 66 || !strings.HasPrefix(s, "0x") {
+		return false
+	}
+	_, err := hex.DecodeString(s[2:])
+	return err == nil
+}
@@ -0,0 +1,107 @@
+package sql
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestQueryAPIRequiresTokenOffLoopback(t *testing.T) {
+	tests := []struct {
+		name    string
+		host    string
+		token   string
+		wantErr bool
+	}{
+		{name: "default host", host: ""},
+		{name: "loopback", host: "127.0.0.1"},
+		{name: "localhost", host: "localhost"},
+		{name: "ipv6 loopback", host: "::1"},
+		{name: "all interfaces", host: "0.0.0.0", wantErr: true},
+		{name: "all interfaces with token", host: "0.0.0.0", token: "secret"},
+		{name: "lan address", host: "192.168.1.10", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Port 0 picks a free port, Initialize never starts the API with it
+			s := New(Config{QueryHost: tt.host, QueryToken: tt.token})
+			err := s.startQueryAPI()
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "QUERY_TOKEN") {
+					t.Fatalf("startQueryAPI = %v, want a QUERY_TOKEN error", err)
+				}
+				return
+			}
+			if err != nil {
+				// Hosts without IPv6 cannot bind ::1, that is not what this test is about
+				if tt.host == "::1" {
+					t.Skipf("cannot listen on ::1: %v", err)
+				}
+				t.Fatalf("startQueryAPI = %v, want nil", err)
+			}
+			s.stopQueryAPI()
+		})
+	}
+}
+
+func TestQueryAPIDefaultsToLoopback(t *testing.T) {
+	listener, err := net.Listen("tcp", net.JoinHostPort(defaultQueryHost, "0"))
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	s := New(Config{QueryPort: port})
+	if err := s.startQueryAPI(); err != nil {
+		t.Fatalf("startQueryAPI = %v, want nil", err)
+	}
+	defer s.stopQueryAPI()
+
+	// The port is taken on loopback, so that is where the API listens
+	if l, err := net.Listen("tcp", net.JoinHostPort(defaultQueryHost, strconv.Itoa(port))); err == nil {
+		l.Close()
+		t.Fatalf("port %d is still free on %s, want the query API listening there", port, defaultQueryHost)
+	}
+}
+
+func TestQueryAPIAuthorize(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		method string
+		auth   string
+		want   int
+	}{
+		{name: "no token configured", method: http.MethodGet, want: http.StatusOK},
+		{name: "valid token", token: "secret", method: http.MethodGet, auth: "Bearer secret", want: http.StatusOK},
+		{name: "missing token", token: "secret", method: http.MethodGet, want: http.StatusUnauthorized},
+		{name: "wrong token", token: "secret", method: http.MethodGet, auth: "Bearer nope", want: http.StatusUnauthorized},
+		{name: "not a bearer token", token: "secret", method: http.MethodGet, auth: "secret", want: http.StatusUnauthorized},
+		{name: "post", method: http.MethodPost, want: http.StatusMethodNotAllowed},
+	}
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(Config{QueryToken: tt.token})
+			req := httptest.NewRequest(tt.method, "/events?block=1", nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+
+			s.authorize(ok).ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
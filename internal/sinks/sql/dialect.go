@@ -0,0 +1,213 @@
+package sql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// dialect generates driver-specific SQL
+type dialect interface {
+	// placeholder returns the bind parameter for the n-th (1-based) argument
+	placeholder(n int) string
+
+	// createTablesDDL returns the statements creating the events and logs tables
+	createTablesDDL(eventsTable, logsTable, indexPrefix string) []string
+
+	// insertEventSQL upserts an event and returns its id. Events are keyed by
+	// block, transaction, variant and the index of their first log, so several
+	// events of one transaction are kept apart.
+	insertEventSQL(eventsTable string) string
+
+	// insertLogSQL inserts a log, ignoring duplicates
+	insertLogSQL(logsTable string) string
+}
+
+// postgresDialect generates PostgreSQL SQL
+type postgresDialect struct {
+	partitionBy Partitioning
+}
+
+func (postgresDialect) placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+func (d postgresDialect) createTablesDDL(eventsTable, logsTable, indexPrefix string) []string {
+	if d.partitionBy != PartitionNone {
+		return d.partitionedTablesDDL(eventsTable, logsTable, indexPrefix)
+	}
+
+	return []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id BIGSERIAL PRIMARY KEY,
+	timestamp TIMESTAMPTZ NOT NULL,
+	ingested_at TIMESTAMPTZ NOT NULL,
+	block_number BIGINT NOT NULL,
+	tx_hash VARCHAR(66) NOT NULL,
+	log_index INTEGER NOT NULL DEFAULT 0,
+	tx_status BIGINT NOT NULL,
+	gas_used BIGINT NOT NULL,
+	event_count INTEGER NOT NULL,
+	variant VARCHAR(16) NOT NULL DEFAULT '',
+	raw_data JSONB,
+	UNIQUE (block_number, tx_hash, variant, log_index)
+)`, eventsTable),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id BIGSERIAL PRIMARY KEY,
+	event_id BIGINT NOT NULL REFERENCES %s(id) ON DELETE CASCADE,
+	log_index INTEGER NOT NULL,
+	event_type VARCHAR(32) NOT NULL,
+	contract_address VARCHAR(42) NOT NULL,
+	topic0 VARCHAR(66),
+	topic1 VARCHAR(66),
+	topic2 VARCHAR(66),
+	topic3 VARCHAR(66),
+	data_hex TEXT,
+	decoded_data JSONB,
+	UNIQUE (event_id, log_index)
+)`, logsTable, eventsTable),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_block_number_idx ON %s (block_number)", indexPrefix, eventsTable),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_tx_hash_idx ON %s (tx_hash)", indexPrefix, eventsTable),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_logs_event_type_idx ON %s (event_type)", indexPrefix, logsTable),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_logs_contract_idx ON %s (contract_address)", indexPrefix, logsTable),
+	}
+}
+
+// partitionedTablesDDL creates the events table partitioned by range of the
+// partition key. Unique constraints of a partitioned table must include the key,
+// and foreign keys cannot reference it, so logs point at their event without one.
+// Partitions themselves are created as events arrive (see ensurePartitions).
+func (d postgresDialect) partitionedTablesDDL(eventsTable, logsTable, indexPrefix string) []string {
+	key := d.partitionKey()
+	unique := "block_number, tx_hash, variant, log_index"
+	if key != "block_number" {
+		unique += ", " + key
+	}
+
+	return []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id BIGSERIAL,
+	timestamp TIMESTAMPTZ NOT NULL,
+	ingested_at TIMESTAMPTZ NOT NULL,
+	block_number BIGINT NOT NULL,
+	tx_hash VARCHAR(66) NOT NULL,
+	log_index INTEGER NOT NULL DEFAULT 0,
+	tx_status BIGINT NOT NULL,
+	gas_used BIGINT NOT NULL,
+	event_count INTEGER NOT NULL,
+	variant VARCHAR(16) NOT NULL DEFAULT '',
+	raw_data JSONB,
+	PRIMARY KEY (id, %s),
+	UNIQUE (%s)
+) PARTITION BY RANGE (%s)`, eventsTable, key, unique, key),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id BIGSERIAL PRIMARY KEY,
+	event_id BIGINT NOT NULL,
+	log_index INTEGER NOT NULL,
+	event_type VARCHAR(32) NOT NULL,
+	contract_address VARCHAR(42) NOT NULL,
+	topic0 VARCHAR(66),
+	topic1 VARCHAR(66),
+	topic2 VARCHAR(66),
+	topic3 VARCHAR(66),
+	data_hex TEXT,
+	decoded_data JSONB,
+	UNIQUE (event_id, log_index)
+)`, logsTable),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_block_number_idx ON %s (block_number)", indexPrefix, eventsTable),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_tx_hash_idx ON %s (tx_hash)", indexPrefix, eventsTable),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_logs_event_type_idx ON %s (event_type)", indexPrefix, logsTable),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_logs_contract_idx ON %s (contract_address)", indexPrefix, logsTable),
+	}
+}
+
+// partitionKey returns the column the events table is partitioned by
+func (d postgresDialect) partitionKey() string {
+	if d.partitionBy == PartitionByMonth {
+		return "timestamp"
+	}
+	return "block_number"
+}
+
+func (d postgresDialect) insertEventSQL(eventsTable string) string {
+	// The conflict target must match the unique constraint, which includes the partition key
+	conflict := "block_number, tx_hash, variant, log_index"
+	if d.partitionBy == PartitionByMonth {
+		conflict += ", timestamp"
+	}
+	return fmt.Sprintf(`INSERT INTO %s (timestamp, ingested_at, block_number, tx_hash, log_index, tx_status, gas_used, event_count, variant, raw_data)
+VALUES (%s)
+ON CONFLICT (%s) DO UPDATE SET tx_status = EXCLUDED.tx_status
+RETURNING id`, eventsTable, placeholders(d, 10), conflict)
+}
+
+func (d postgresDialect) insertLogSQL(logsTable string) string {
+	return fmt.Sprintf(`INSERT INTO %s (event_id, log_index, event_type, contract_address, topic0, topic1, topic2, topic3, data_hex, decoded_data)
+VALUES (%s)
+ON CONFLICT (event_id, log_index) DO NOTHING`, logsTable, placeholders(d, 10))
+}
+
+// sqliteDialect generates SQLite SQL
+type sqliteDialect struct{}
+
+func (sqliteDialect) placeholder(int) string {
+	return "?"
+}
+
+func (sqliteDialect) createTablesDDL(eventsTable, logsTable, indexPrefix string) []string {
+	return []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	timestamp DATETIME NOT NULL,
+	ingested_at DATETIME NOT NULL,
+	block_number INTEGER NOT NULL,
+	tx_hash TEXT NOT NULL,
+	log_index INTEGER NOT NULL DEFAULT 0,
+	tx_status INTEGER NOT NULL,
+	gas_used INTEGER NOT NULL,
+	event_count INTEGER NOT NULL,
+	variant TEXT NOT NULL DEFAULT '',
+	raw_data TEXT,
+	UNIQUE (block_number, tx_hash, variant, log_index)
+)`, eventsTable),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	event_id INTEGER NOT NULL REFERENCES %s(id) ON DELETE CASCADE,
+	log_index INTEGER NOT NULL,
+	event_type TEXT NOT NULL,
+	contract_address TEXT NOT NULL,
+	topic0 TEXT,
+	topic1 TEXT,
+	topic2 TEXT,
+	topic3 TEXT,
+	data_hex TEXT,
+	decoded_data TEXT,
+	UNIQUE (event_id, log_index)
+)`, logsTable, eventsTable),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_block_number_idx ON %s (block_number)", indexPrefix, eventsTable),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_tx_hash_idx ON %s (tx_hash)", indexPrefix, eventsTable),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_logs_event_type_idx ON %s (event_type)", indexPrefix, logsTable),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_logs_contract_idx ON %s (contract_address)", indexPrefix, logsTable),
+	}
+}
+
+func (d sqliteDialect) insertEventSQL(eventsTable string) string {
+	// RETURNING requires SQLite 3.35+, which modernc.org/sqlite bundles
+	return fmt.Sprintf(`INSERT INTO %s (timestamp, ingested_at, block_number, tx_hash, log_index, tx_status, gas_used, event_count, variant, raw_data)
+VALUES (%s)
+ON CONFLICT (block_number, tx_hash, variant, log_index) DO UPDATE SET tx_status = excluded.tx_status
+RETURNING id`, eventsTable, placeholders(d, 10))
+}
+
+func (d sqliteDialect) insertLogSQL(logsTable string) string {
+	return fmt.Sprintf(`INSERT OR IGNORE INTO %s (event_id, log_index, event_type, contract_address, topic0, topic1, topic2, topic3, data_hex, decoded_data)
+VALUES (%s)`, logsTable, placeholders(d, 10))
+}
+
+// placeholders returns a comma-separated list of n bind parameters
+func placeholders(d dialect, n int) string {
+	params := make([]string, n)
+	for i := range params {
+		params[i] = d.placeholder(i + 1)
+	}
+	return strings.Join(params, ", ")
+}
@@ -0,0 +1,106 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"usdc-event-tracker/internal/sinks"
+)
+
+// Partitioning selects how the PostgreSQL events table is partitioned
+type Partitioning string
+
+const (
+	PartitionNone    Partitioning = ""      // A single unpartitioned table
+	PartitionByBlock Partitioning = "block" // Ranges of PartitionBlocks block numbers
+	PartitionByMonth Partitioning = "month" // Calendar months of the block timestamp, in UTC
+)
+
+// partition is a single partition of the events table
+type partition struct {
+	name     string // Table name, the events table plus a suffix
+	from, to string // Range bounds as SQL literals, from inclusive and to exclusive
+}
+
+// partitionFor returns the partition holding event
+func (s *SQLSink) partitionFor(event sinks.Event) partition {
+	if s.config.PartitionBy == PartitionByMonth {
+		return s.monthPartition(event.Timestamp())
+	}
+
+	size := s.config.PartitionBlocks
+	from := event.BlockNumber / size * size
+	return partition{
+		name: fmt.Sprintf("%s_p%d", s.eventsTable(), from),
+		from: fmt.Sprintf("%d", from),
+		to:   fmt.Sprintf("%d", from+size),
+	}
+}
+
+// monthPartition returns the partition for the month of t
+func (s *SQLSink) monthPartition(t time.Time) partition {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	return partition{
+		name: fmt.Sprintf("%s_%s", s.eventsTable(), start.Format("2006_01")),
+		from: "'" + start.Format(time.RFC3339) + "'",
+		to:   "'" + end.Format(time.RFC3339) + "'",
+	}
+}
+
+// ensurePartitions creates the partitions the current batch is written to,
+// remembering those already created. Partitions are created outside the batch
+// transaction, so a rolled back batch does not leave the cache out of date.
+// Callers must hold batchMutex.
+func (s *SQLSink) ensurePartitions(ctx context.Context) error {
+	if s.config.PartitionBy == PartitionNone {
+		return nil
+	}
+
+	for _, event := range s.eventBatch {
+		if err := s.createPartition(ctx, s.partitionFor(event)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createPartition creates p unless it was already created by this sink
+func (s *SQLSink) createPartition(ctx context.Context, p partition) error {
+	if s.partitions[p.name] {
+		return nil
+	}
+
+	stmt := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM (%s) TO (%s)",
+		p.name, s.eventsTable(), p.from, p.to)
+	if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+		return fmt.Errorf("failed to create partition %s: %w", p.name, err)
+	}
+
+	s.partitions[p.name] = true
+	s.logger.Info("Created events table partition", map[string]interface{}{
+		"partition": p.name,
+		"from":      p.from,
+		"to":        p.to,
+	})
+	return nil
+}
+
+// checkPartitioned returns an error if the events table exists but is not
+// partitioned, e.g. because it was created before SQL_PARTITION_BY was set.
+// Existing tables are never converted.
+func (s *SQLSink) checkPartitioned(ctx context.Context) error {
+	var partitioned bool
+	err := s.db.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM pg_partitioned_table WHERE partrelid = to_regclass($1))",
+		s.eventsTable()).Scan(&partitioned)
+	if err != nil {
+		return fmt.Errorf("failed to inspect table %s: %w", s.eventsTable(), err)
+	}
+	if !partitioned {
+		return fmt.Errorf("table %s is not partitioned; SQL_PARTITION_BY only applies to tables it creates", s.eventsTable())
+	}
+	return nil
+}
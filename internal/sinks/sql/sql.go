@@ -1,187 +1,958 @@
-// Package sql implements a PostgreSQL sink for blockchain events
+// Package sql implements a PostgreSQL/SQLite sink for blockchain events
 package sql
 
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
-	_ "github.com/lib/pq" // PostgreSQL driver
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	_ "github.com/lib/pq"  // PostgreSQL driver
+	_ "modernc.org/sqlite" // Pure Go SQLite driver
+	"usdc-event-tracker/internal/erc20"
+	"usdc-event-tracker/internal/logging"
 	"usdc-event-tracker/internal/sinks"
 )
 
+// Driver identifies the database backend
+type Driver string
+
+const (
+	DriverPostgres Driver = "postgres" // PostgreSQL via lib/pq
+	DriverSQLite   Driver = "sqlite"   // SQLite via modernc.org/sqlite (no cgo)
+)
+
 // Config holds SQL sink configuration
 type Config struct {
-	ConnectionString string        // PostgreSQL connection string
-	TableName       string        // Table name for events
-	BatchSize       int           // Number of events to batch before insert
-	FlushInterval   time.Duration // Maximum time to wait before flushing batch
-	CreateTables    bool          // Whether to auto-create tables
-	SchemaName      string        // Database schema name
+	Driver           Driver        // Database backend (postgres or sqlite)
+	ConnectionString string        // PostgreSQL connection string or SQLite file path (":memory:" for in-memory)
+	TableName        string        // Table name for events
+	BatchSize        int           // Number of events to batch before insert
+	FlushInterval    time.Duration // Maximum time to wait before flushing batch
+	CreateTables     bool          // Whether to auto-create tables
+	SchemaName       string        // Database schema name (PostgreSQL only)
+	NotifyChannel    string        // Channel notified via pg_notify after each batch (PostgreSQL only, optional)
+	MaxPending       int           // Block writes while this many events await a flush, 0 disables the limit
+
+	// Native partitioning of the events table (PostgreSQL only), see Partitioning
+	PartitionBy     Partitioning
+	PartitionBlocks uint64 // Blocks per partition with PartitionByBlock
+
+	// Receipt-level fields kept in raw_data, nil keeps all. The tx_status and
+	// gas_used columns are always populated.
+	ReceiptFields sinks.ReceiptFields
+
+	// Read-only HTTP query API (see startQueryAPI), QueryPort 0 disables it
+	QueryPort  int
+	QueryHost  string // Interface the query API listens on, 127.0.0.1 if empty
+	QueryToken string // Bearer token required by the query API, empty allows any local client
 }
 
-// SQLSink writes events to PostgreSQL database
+// SQLSink writes events to a PostgreSQL or SQLite database
 type SQLSink struct {
 	config Config
 	db     *sql.DB
-	
+	logger *logging.Logger
+
 	// Batch processing
 	eventBatch []sinks.Event
 	batchMutex sync.Mutex
 	lastFlush  time.Time
-	
+
 	// Prepared statements
 	insertStmt    *sql.Stmt
 	insertLogStmt *sql.Stmt
-	
+
+	// Partitions known to exist, guarded by batchMutex
+	partitions map[string]bool
+
+	// Query API server, nil unless QueryPort is set
+	queryServer *http.Server
+
 	// Background processing
 	done chan struct{}
 	wg   sync.WaitGroup
-	
+
 	// Metrics
-	totalEvents int64
-	totalBatches int64
+	totalEvents       int64
+	totalBatches      int64
+	backpressureWaits int64
+}
+
+func init() {
+	sinks.Register("sql", func(opts sinks.Options) (sinks.Sink, error) {
+		config := NewConfig()
+		config.ReceiptFields = opts.ReceiptFields
+		config.MaxPending = opts.MaxPending
+		return New(config), nil
+	})
+}
+
+// NewConfig creates a new SQL sink configuration from environment variables
+func NewConfig() Config {
+	config := Config{
+		Driver:           DriverPostgres,
+		ConnectionString: os.Getenv("SQL_CONNECTION_STRING"),
+		TableName:        os.Getenv("SQL_TABLE_NAME"),
+		SchemaName:       os.Getenv("SQL_SCHEMA_NAME"),
+		NotifyChannel:    os.Getenv("SQL_NOTIFY_CHANNEL"),
+		QueryHost:        os.Getenv("QUERY_HOST"),
+		QueryToken:       os.Getenv("QUERY_TOKEN"),
+		CreateTables:     true,
+	}
+
+	if driver := strings.ToLower(os.Getenv("SQL_DRIVER")); driver == string(DriverSQLite) {
+		config.Driver = DriverSQLite
+	}
+
+	if batchSize := os.Getenv("SQL_BATCH_SIZE"); batchSize != "" {
+		if size, err := strconv.Atoi(batchSize); err == nil && size > 0 {
+			config.BatchSize = size
+		}
+	}
+
+	if queryPort := os.Getenv("QUERY_PORT"); queryPort != "" {
+		if port, err := strconv.Atoi(queryPort); err == nil && port > 0 {
+			config.QueryPort = port
+		}
+	}
+
+	if createTables := os.Getenv("SQL_CREATE_TABLES"); createTables != "" {
+		config.CreateTables = strings.ToLower(createTables) == "true"
+	}
+
+	switch partitionBy := Partitioning(strings.ToLower(os.Getenv("SQL_PARTITION_BY"))); partitionBy {
+	case PartitionByBlock, PartitionByMonth:
+		config.PartitionBy = partitionBy
+	}
+	if blocks := os.Getenv("SQL_PARTITION_BLOCKS"); blocks != "" {
+		if size, err := strconv.ParseUint(blocks, 10, 64); err == nil && size > 0 {
+			config.PartitionBlocks = size
+		}
+	}
+
+	return config
 }
 
 // New creates a new SQL sink with the given configuration
 func New(config Config) *SQLSink {
-	// TODO: Implement
-	// - Set defaults for empty config values
-	// - Initialize SQLSink struct
-	// - Setup batch slice and channels
-	return nil
+	if config.Driver == "" {
+		config.Driver = DriverPostgres
+	}
+	if config.ConnectionString == "" && config.Driver == DriverSQLite {
+		config.ConnectionString = "usdc-events.db"
+	}
+	if config.TableName == "" {
+		config.TableName = "usdc_events"
+	}
+	if config.SchemaName == "" {
+		config.SchemaName = "public"
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 5 * time.Second
+	}
+	if config.PartitionBlocks == 0 {
+		config.PartitionBlocks = 1_000_000
+	}
+
+	return &SQLSink{
+		config:     config,
+		logger:     logging.GetLogger("sql-sink"),
+		eventBatch: make([]sinks.Event, 0, config.BatchSize),
+		lastFlush:  time.Now(),
+		partitions: make(map[string]bool),
+		done:       make(chan struct{}),
+	}
 }
 
 // Name returns "sql" as the sink identifier
 func (s *SQLSink) Name() string {
-	// TODO: Implement
-	return ""
+	return "sql"
 }
 
 // Initialize prepares the SQL sink
-func (s *SQLSink) Initialize() error {
-	// TODO: Implement
-	// - Connect to PostgreSQL database
-	// - Test connection with Ping
-	// - Configure connection pool settings
-	// - Create tables if config.CreateTables is true
-	// - Prepare SQL statements
-	// - Start background batch processor
-	// - Print initialization info
+func (s *SQLSink) Initialize(ctx context.Context) (err error) {
+	if s.config.ConnectionString == "" {
+		return fmt.Errorf("SQL_CONNECTION_STRING environment variable is required for the sql sink")
+	}
+
+	db, err := sql.Open(string(s.config.Driver), s.config.ConnectionString)
+	if err != nil {
+		s.logger.Error("Failed to open database", err, map[string]interface{}{
+			"driver": s.config.Driver,
+		})
+		return fmt.Errorf("failed to open %s database: %w", s.config.Driver, err)
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(pingCtx); err != nil {
+		db.Close()
+		s.logger.Error("Failed to connect to database", err, map[string]interface{}{
+			"driver": s.config.Driver,
+		})
+		return fmt.Errorf("failed to connect to %s database: %w", s.config.Driver, err)
+	}
+
+	if s.config.Driver == DriverSQLite {
+		// SQLite allows a single writer, and every connection to ":memory:" is a separate database
+		db.SetMaxOpenConns(1)
+
+		if s.config.NotifyChannel != "" {
+			s.logger.Warn("SQL_NOTIFY_CHANNEL is only supported on PostgreSQL, ignoring", map[string]interface{}{
+				"channel": s.config.NotifyChannel,
+			})
+			s.config.NotifyChannel = ""
+		}
+		if s.config.PartitionBy != PartitionNone {
+			s.logger.Warn("SQL_PARTITION_BY is only supported on PostgreSQL, ignoring", map[string]interface{}{
+				"partition_by": s.config.PartitionBy,
+			})
+			s.config.PartitionBy = PartitionNone
+		}
+	} else {
+		db.SetMaxOpenConns(10)
+		db.SetMaxIdleConns(5)
+		db.SetConnMaxLifetime(30 * time.Minute)
+	}
+
+	s.db = db
+	// Release the connection pool if a later step fails, Close is not called then
+	defer func() {
+		if err != nil {
+			s.closeDB()
+		}
+	}()
+
+	if s.config.CreateTables {
+		if err := s.createTables(ctx); err != nil {
+			return fmt.Errorf("failed to create tables: %w", err)
+		}
+	}
+
+	if s.config.PartitionBy != PartitionNone {
+		if err := s.checkPartitioned(ctx); err != nil {
+			return err
+		}
+		// Create this month's and next month's partitions up front, later ones as events arrive
+		if s.config.PartitionBy == PartitionByMonth {
+			now := time.Now()
+			for _, month := range []time.Time{now, now.AddDate(0, 1, 1-now.Day())} {
+				if err := s.createPartition(ctx, s.monthPartition(month)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if err := s.prepareStatements(ctx); err != nil {
+		return fmt.Errorf("failed to prepare statements: %w", err)
+	}
+
+	if s.config.QueryPort > 0 {
+		if err := s.startQueryAPI(); err != nil {
+			return err
+		}
+	}
+
+	s.wg.Add(1)
+	go s.batchProcessor()
+
+	s.logger.Info("Connected to SQL database", map[string]interface{}{
+		"driver":         s.config.Driver,
+		"events_table":   s.eventsTable(),
+		"logs_table":     s.logsTable(),
+		"batch_size":     s.config.BatchSize,
+		"flush_interval": s.config.FlushInterval.String(),
+		"notify_channel": s.config.NotifyChannel,
+		"partition_by":   string(s.config.PartitionBy),
+	})
+
 	return nil
 }
 
 // Write adds events to the batch for database insertion
 func (s *SQLSink) Write(ctx context.Context, events []sinks.Event) error {
-	// TODO: Implement
-	// - Lock batch mutex
-	// - Add events to batch
-	// - Check if batch is full and flush if needed
-	// - Unlock mutex
+	if len(events) == 0 {
+		return nil
+	}
+
+	s.batchMutex.Lock()
+	defer s.batchMutex.Unlock()
+
+	if err := s.waitForCapacity(ctx); err != nil {
+		return err
+	}
+
+	s.eventBatch = append(s.eventBatch, events...)
+
+	if len(s.eventBatch) >= s.config.BatchSize {
+		return s.flushBatch(ctx)
+	}
+
 	return nil
 }
 
+// waitForCapacity blocks while MaxPending events are waiting, retrying the flush
+// with backoff so a stalled database slows the tracker down instead of growing
+// the batch without bound. Callers must hold batchMutex; it is released while waiting.
+func (s *SQLSink) waitForCapacity(ctx context.Context) error {
+	if s.config.MaxPending <= 0 {
+		return nil
+	}
+
+	backoff := time.Second
+	for len(s.eventBatch) >= s.config.MaxPending {
+		err := s.flushBatch(ctx)
+		if err == nil {
+			return nil
+		}
+
+		s.backpressureWaits++
+		s.logger.Warn("SQL sink at max pending events, waiting for flush", map[string]interface{}{
+			"pending_events": len(s.eventBatch),
+			"max_pending":    s.config.MaxPending,
+			"retry_in":       backoff.String(),
+			"error":          err.Error(),
+		})
+
+		s.batchMutex.Unlock()
+		select {
+		case <-ctx.Done():
+			s.batchMutex.Lock()
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		s.batchMutex.Lock()
+
+		if backoff *= 2; backoff > 30*time.Second {
+			backoff = 30 * time.Second
+		}
+	}
+
+	return nil
+}
+
+// Flush inserts the pending batch immediately
+func (s *SQLSink) Flush(ctx context.Context) error {
+	s.batchMutex.Lock()
+	defer s.batchMutex.Unlock()
+
+	return s.flushBatch(ctx)
+}
+
 // Close cleanly shuts down the SQL sink
 func (s *SQLSink) Close() error {
-	// TODO: Implement
-	// - Signal shutdown to background processor
-	// - Wait for background goroutine to finish
-	// - Flush any remaining events in batch
-	// - Close prepared statements
-	// - Close database connection
-	// - Print final statistics
-	return nil
+	s.stopQueryAPI()
+	close(s.done)
+	s.wg.Wait()
+
+	if s.db == nil {
+		return nil
+	}
+
+	s.batchMutex.Lock()
+	err := s.flushBatch(context.Background())
+	s.batchMutex.Unlock()
+
+	if closeErr := s.closeDB(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+
+	s.logger.Info("Closing SQL sink", map[string]interface{}{
+		"total_events":  s.totalEvents,
+		"total_batches": s.totalBatches,
+	})
+
+	return err
+}
+
+// closeDB closes the prepared statements and the connection pool
+func (s *SQLSink) closeDB() error {
+	if s.insertStmt != nil {
+		s.insertStmt.Close()
+		s.insertStmt = nil
+	}
+	if s.insertLogStmt != nil {
+		s.insertLogStmt.Close()
+		s.insertLogStmt = nil
+	}
+
+	err := s.db.Close()
+	s.db = nil
+	return err
 }
 
 // createTables creates the necessary database tables
-func (s *SQLSink) createTables() error {
-	// TODO: Implement
-	// - Create main events table with columns:
-	//   - id, timestamp, block_number, tx_hash, tx_status, gas_used, event_count, raw_data
-	// - Create logs table with columns:
-	//   - id, event_id (FK), log_index, event_type, contract_address, topics, data_hex, decoded_data
-	// - Create indexes for performance on commonly queried columns
-	// - Use IF NOT EXISTS to avoid conflicts
+func (s *SQLSink) createTables(ctx context.Context) error {
+	if s.config.Driver == DriverPostgres && s.config.SchemaName != "public" {
+		if _, err := s.db.ExecContext(ctx, fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", s.config.SchemaName)); err != nil {
+			return fmt.Errorf("failed to create schema: %w", err)
+		}
+	}
+
+	for _, stmt := range s.dialect().createTablesDDL(s.eventsTable(), s.logsTable(), s.config.TableName) {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to execute %q: %w", firstLine(stmt), err)
+		}
+	}
+
 	return nil
 }
 
 // prepareStatements prepares SQL statements for better performance
-func (s *SQLSink) prepareStatements() error {
-	// TODO: Implement
-	// - Prepare INSERT statement for main events table with UPSERT (ON CONFLICT)
-	// - Prepare INSERT statement for logs table
-	// - Store statements in struct fields for reuse
+func (s *SQLSink) prepareStatements(ctx context.Context) error {
+	d := s.dialect()
+
+	insertStmt, err := s.db.PrepareContext(ctx, d.insertEventSQL(s.eventsTable()))
+	if err != nil {
+		return fmt.Errorf("failed to prepare event insert: %w", err)
+	}
+	s.insertStmt = insertStmt
+
+	insertLogStmt, err := s.db.PrepareContext(ctx, d.insertLogSQL(s.logsTable()))
+	if err != nil {
+		return fmt.Errorf("failed to prepare log insert: %w", err)
+	}
+	s.insertLogStmt = insertLogStmt
+
 	return nil
 }
 
 // batchProcessor runs in background to flush batches periodically
 func (s *SQLSink) batchProcessor() {
-	// TODO: Implement
-	// - Run in goroutine (called with go keyword)
-	// - Use ticker for periodic flushing
-	// - Check if flush interval has passed
-	// - Flush batch if time limit reached
-	// - Handle shutdown signal
-	// - Call wg.Done() when finished
-}
-
-// flushBatch inserts the current batch of events into the database
-func (s *SQLSink) flushBatch() error {
-	// TODO: Implement
-	// - Start database transaction
-	// - Iterate through event batch
-	// - Insert each event and its logs
-	// - Commit transaction
-	// - Update metrics (totalEvents, totalBatches)
-	// - Clear the batch slice
-	// - Update lastFlush timestamp
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.batchMutex.Lock()
+			if time.Since(s.lastFlush) >= s.config.FlushInterval {
+				if err := s.flushBatch(context.Background()); err != nil {
+					s.logger.Error("Failed to flush SQL batch", err)
+				}
+			}
+			s.batchMutex.Unlock()
+		}
+	}
+}
+
+// flushBatch inserts the current batch of events into the database.
+// Callers must hold batchMutex.
+func (s *SQLSink) flushBatch(ctx context.Context) error {
+	if len(s.eventBatch) == 0 {
+		s.lastFlush = time.Now()
+		return nil
+	}
+
+	start := time.Now()
+
+	if err := s.ensurePartitions(ctx); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	eventStmt := tx.Stmt(s.insertStmt)
+	logStmt := tx.Stmt(s.insertLogStmt)
+
+	for _, event := range s.eventBatch {
+		if err := s.insertEvent(eventStmt, logStmt, event); err != nil {
+			tx.Rollback()
+			s.logger.Error("Failed to insert SQL batch", err, map[string]interface{}{
+				"event_count": len(s.eventBatch),
+			})
+			return err
+		}
+	}
+
+	// Notifications are queued with the transaction, so listeners only hear
+	// about batches that actually commit
+	if s.config.NotifyChannel != "" {
+		if err := s.notify(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.totalEvents += int64(len(s.eventBatch))
+	s.totalBatches++
+
+	s.logger.LogSinkOperation(s.Name(), "flush", len(s.eventBatch), time.Since(start), true)
+
+	s.eventBatch = s.eventBatch[:0]
+	s.lastFlush = time.Now()
+
+	return nil
+}
+
+// BatchNotification is the JSON payload sent on SQL_NOTIFY_CHANNEL for each committed batch
+type BatchNotification struct {
+	Table      string `json:"table"`
+	FromBlock  uint64 `json:"from_block"`
+	ToBlock    uint64 `json:"to_block"`
+	EventCount int    `json:"event_count"`
+	LogCount   int    `json:"log_count"`
+}
+
+// notify issues pg_notify with a summary of the current batch
+func (s *SQLSink) notify(tx *sql.Tx) error {
+	payload := BatchNotification{
+		Table:      s.eventsTable(),
+		FromBlock:  s.eventBatch[0].BlockNumber,
+		ToBlock:    s.eventBatch[0].BlockNumber,
+		EventCount: len(s.eventBatch),
+	}
+	for _, event := range s.eventBatch {
+		if event.BlockNumber < payload.FromBlock {
+			payload.FromBlock = event.BlockNumber
+		}
+		if event.BlockNumber > payload.ToBlock {
+			payload.ToBlock = event.BlockNumber
+		}
+		payload.LogCount += len(event.Logs)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	if _, err := tx.Exec("SELECT pg_notify($1, $2)", s.config.NotifyChannel, string(data)); err != nil {
+		return fmt.Errorf("failed to notify channel %s: %w", s.config.NotifyChannel, err)
+	}
+
 	return nil
 }
 
 // insertEvent inserts a single event and its logs
 func (s *SQLSink) insertEvent(eventStmt, logStmt *sql.Stmt, event sinks.Event) error {
-	// TODO: Implement
-	// - Serialize event data to JSON
-	// - Execute event INSERT statement
-	// - Get returned event ID
-	// - Insert all associated logs with event ID foreign key
+	rawData, err := s.serializeEvent(event)
+	if err != nil {
+		return err
+	}
+
+	var eventID int64
+	err = eventStmt.QueryRow(
+		event.Timestamp().UTC(),
+		event.IngestedAt.UTC(),
+		event.BlockNumber,
+		event.Receipt.TxHash.Hex(),
+		firstLogIndex(event),
+		event.Receipt.Status,
+		event.Receipt.GasUsed,
+		len(event.Logs),
+		event.Variant,
+		string(rawData),
+	).Scan(&eventID)
+	if err != nil {
+		return fmt.Errorf("failed to insert event %s: %w", event.Receipt.TxHash.Hex(), err)
+	}
+
+	for _, log := range event.Logs {
+		if err := s.insertLog(logStmt, eventID, event, log); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-// insertLog inserts a single event log
-func (s *SQLSink) insertLog(stmt *sql.Stmt, eventID int64, log *types.Log) error {
-	// TODO: Implement
-	// - Decode event type from log topics
-	// - Extract and decode event-specific data (Transfer, Approval)
-	// - Prepare topics array (pad with nulls if needed)
-	// - Execute log INSERT statement
+// firstLogIndex returns the lowest log index of event, its position within the
+// transaction, or 0 for an event without logs
+func firstLogIndex(event sinks.Event) uint {
+	if len(event.Logs) == 0 {
+		return 0
+	}
+	first := event.Logs[0].Index
+	for _, log := range event.Logs[1:] {
+		if log.Index < first {
+			first = log.Index
+		}
+	}
+	return first
+}
+
+// insertLog inserts a single event log, one of event's logs
+func (s *SQLSink) insertLog(stmt *sql.Stmt, eventID int64, event sinks.Event, log *types.Log) error {
+	eventType := "Unknown"
+	if len(log.Topics) > 0 {
+		if event, found := erc20.GetEventBySignature(log.Topics[0].Hex()); found {
+			eventType = string(event)
+		}
+	}
+
+	var decoded []byte
+	if data := decodeLog(event, log); data != nil {
+		var err error
+		if decoded, err = json.Marshal(data); err != nil {
+			return fmt.Errorf("failed to marshal decoded data: %w", err)
+		}
+	}
+
+	// Pad topics to four columns
+	topics := make([]interface{}, 4)
+	for i := range topics {
+		if i < len(log.Topics) {
+			topics[i] = log.Topics[i].Hex()
+		}
+	}
+
+	var decodedData interface{}
+	if decoded != nil {
+		decodedData = string(decoded)
+	}
+
+	_, err := stmt.Exec(
+		eventID,
+		log.Index,
+		eventType,
+		log.Address.Hex(),
+		topics[0], topics[1], topics[2], topics[3],
+		common.Bytes2Hex(log.Data),
+		decodedData,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert log %d: %w", log.Index, err)
+	}
+
 	return nil
 }
 
-// serializeEvent converts an event to JSON for storage
+// serializeEvent converts an event to the shared JSON wire format for storage
 func (s *SQLSink) serializeEvent(event sinks.Event) ([]byte, error) {
-	// TODO: Implement
-	// - Convert event logs to serializable format
-	// - Create map with all event fields
-	// - Marshal to JSON bytes
-	return nil, nil
+	data, err := sinks.MarshalEvent(event, s.config.ReceiptFields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize event: %w", err)
+	}
+
+	return data, nil
 }
 
+// eventColumns lists the events table columns read by scanEvents
+const eventColumns = "id, timestamp, ingested_at, block_number, tx_hash, log_index, tx_status, gas_used, event_count, variant, raw_data"
+
 // GetEventsByBlock retrieves events for a specific block number
 func (s *SQLSink) GetEventsByBlock(blockNumber uint64) ([]map[string]interface{}, error) {
-	// TODO: Implement
-	// - Query events table for specific block number
-	// - Scan results into map structures
-	// - Parse JSON raw data if present
-	// - Return slice of event maps
-	return nil, nil
+	query := fmt.Sprintf(
+		"SELECT %s FROM %s WHERE block_number = %s ORDER BY id",
+		eventColumns, s.eventsTable(), s.dialect().placeholder(1),
+	)
+
+	rows, err := s.db.Query(query, blockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events for block %d: %w", blockNumber, err)
+	}
+	defer rows.Close()
+
+	return scanEvents(rows)
+}
+
+// GetEventsByRange retrieves up to limit events with block numbers in [from, to],
+// ordered by id. Pass the returned cursor as afterID to fetch the next page;
+// a cursor of 0 means there are no more events.
+func (s *SQLSink) GetEventsByRange(from, to uint64, limit, afterID int64) ([]map[string]interface{}, int64, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	d := s.dialect()
+	query := fmt.Sprintf(
+		"SELECT %s FROM %s WHERE block_number >= %s AND block_number <= %s AND id > %s ORDER BY id LIMIT %s",
+		eventColumns, s.eventsTable(), d.placeholder(1), d.placeholder(2), d.placeholder(3), d.placeholder(4),
+	)
+
+	// Fetch one extra row to find out whether another page exists
+	rows, err := s.db.Query(query, from, to, afterID, limit+1)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query events for blocks %d-%d: %w", from, to, err)
+	}
+	defer rows.Close()
+
+	events, err := scanEvents(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var nextCursor int64
+	if int64(len(events)) > limit {
+		events = events[:limit]
+		nextCursor = events[limit-1]["id"].(int64)
+	}
+
+	return events, nextCursor, nil
+}
+
+// PersistedBlocks implements sinks.BlockLister
+func (s *SQLSink) PersistedBlocks(ctx context.Context, from, to uint64) ([]uint64, error) {
+	d := s.dialect()
+	query := fmt.Sprintf(
+		"SELECT DISTINCT block_number FROM %s WHERE block_number >= %s AND block_number <= %s ORDER BY block_number",
+		s.eventsTable(), d.placeholder(1), d.placeholder(2),
+	)
+
+	rows, err := s.db.QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query blocks %d-%d: %w", from, to, err)
+	}
+	defer rows.Close()
+
+	blocks := make([]uint64, 0)
+	for rows.Next() {
+		var number int64
+		if err := rows.Scan(&number); err != nil {
+			return nil, fmt.Errorf("failed to scan block number: %w", err)
+		}
+		blocks = append(blocks, uint64(number))
+	}
+
+	return blocks, rows.Err()
+}
+
+// logColumns lists the columns of the logs table l joined with the events table e read by scanLogs
+const logColumns = "l.id, l.event_id, e.block_number, e.tx_hash, l.log_index, l.event_type, l.contract_address, l.topic0, l.topic1, l.topic2, l.topic3, l.data_hex, l.decoded_data"
+
+// GetLogsByEventType retrieves logs of the given event type with pagination,
+// most recent first
+func (s *SQLSink) GetLogsByEventType(eventType string, limit, offset int) ([]map[string]interface{}, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	d := s.dialect()
+	query := fmt.Sprintf(
+		`SELECT %s
+FROM %s l JOIN %s e ON e.id = l.event_id
+WHERE l.event_type = %s
+ORDER BY l.id DESC
+LIMIT %s OFFSET %s`,
+		logColumns, s.logsTable(), s.eventsTable(), d.placeholder(1), d.placeholder(2), d.placeholder(3),
+	)
+
+	rows, err := s.db.Query(query, eventType, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s logs: %w", eventType, err)
+	}
+	defer rows.Close()
+
+	return scanLogs(rows)
+}
+
+// GetLogsByTx retrieves the logs of a transaction, given as a lowercase
+// 0x-prefixed hash, in log order
+func (s *SQLSink) GetLogsByTx(txHash string) ([]map[string]interface{}, error) {
+	query := fmt.Sprintf(
+		`SELECT %s
+FROM %s l JOIN %s e ON e.id = l.event_id
+WHERE e.tx_hash = %s
+ORDER BY l.log_index, l.id`,
+		logColumns, s.logsTable(), s.eventsTable(), s.dialect().placeholder(1),
+	)
+
+	rows, err := s.db.Query(query, txHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query logs of transaction %s: %w", txHash, err)
+	}
+	defer rows.Close()
+
+	return scanLogs(rows)
+}
+
+// scanLogs reads rows selected with logColumns
+func scanLogs(rows *sql.Rows) ([]map[string]interface{}, error) {
+	logs := make([]map[string]interface{}, 0)
+	for rows.Next() {
+		var (
+			id, eventID, number            int64
+			logIndex                       int
+			txHash, logType, contract      string
+			topic0, topic1, topic2, topic3 sql.NullString
+			dataHex, decodedData           sql.NullString
+		)
+		if err := rows.Scan(&id, &eventID, &number, &txHash, &logIndex, &logType, &contract,
+			&topic0, &topic1, &topic2, &topic3, &dataHex, &decodedData); err != nil {
+			return nil, fmt.Errorf("failed to scan log: %w", err)
+		}
+
+		topics := make([]string, 0, 4)
+		for _, topic := range []sql.NullString{topic0, topic1, topic2, topic3} {
+			if topic.Valid {
+				topics = append(topics, topic.String)
+			}
+		}
+
+		log := map[string]interface{}{
+			"id":               id,
+			"event_id":         eventID,
+			"block_number":     number,
+			"tx_hash":          txHash,
+			"log_index":        logIndex,
+			"event_type":       logType,
+			"contract_address": contract,
+			"topics":           topics,
+			"data_hex":         dataHex.String,
+		}
+		if decodedData.Valid {
+			var decoded map[string]interface{}
+			if err := json.Unmarshal([]byte(decodedData.String), &decoded); err == nil {
+				log["decoded_data"] = decoded
+			}
+		}
+		logs = append(logs, log)
+	}
+
+	return logs, rows.Err()
+}
+
+// scanEvents reads rows selected with eventColumns
+func scanEvents(rows *sql.Rows) ([]map[string]interface{}, error) {
+	events := make([]map[string]interface{}, 0)
+	for rows.Next() {
+		var (
+			id, gasUsed, txStatus int64
+			number                int64
+			logIndex, eventCount  int
+			timestamp, ingestedAt time.Time
+			txHash, variant       string
+			rawData               sql.NullString
+		)
+		if err := rows.Scan(&id, &timestamp, &ingestedAt, &number, &txHash, &logIndex, &txStatus, &gasUsed, &eventCount, &variant, &rawData); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+
+		event := map[string]interface{}{
+			"id":           id,
+			"timestamp":    timestamp,
+			"ingested_at":  ingestedAt,
+			"block_number": number,
+			"tx_hash":      txHash,
+			"log_index":    logIndex,
+			"tx_status":    txStatus,
+			"gas_used":     gasUsed,
+			"event_count":  eventCount,
+			"variant":      variant,
+		}
+		if rawData.Valid {
+			var raw map[string]interface{}
+			if err := json.Unmarshal([]byte(rawData.String), &raw); err == nil {
+				event["raw_data"] = raw
+			}
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// Stats implements sinks.StatReporter
+func (s *SQLSink) Stats() map[string]interface{} {
+	return s.GetStatistics()
 }
 
 // GetStatistics returns sink statistics
 func (s *SQLSink) GetStatistics() map[string]interface{} {
-	// TODO: Implement
-	// - Return map with totalEvents, totalBatches, batchSize, pendingEvents
-	return nil
-}
\ No newline at end of file
+	s.batchMutex.Lock()
+	defer s.batchMutex.Unlock()
+
+	return map[string]interface{}{
+		"driver":             s.config.Driver,
+		"total_events":       s.totalEvents,
+		"total_batches":      s.totalBatches,
+		"batch_size":         s.config.BatchSize,
+		"pending_events":     len(s.eventBatch),
+		"max_pending":        s.config.MaxPending,
+		"backpressure_waits": s.backpressureWaits,
+	}
+}
+
+// eventsTable returns the qualified events table name
+func (s *SQLSink) eventsTable() string {
+	if s.config.Driver == DriverSQLite {
+		return s.config.TableName
+	}
+	return s.config.SchemaName + "." + s.config.TableName
+}
+
+// logsTable returns the qualified logs table name
+func (s *SQLSink) logsTable() string {
+	return s.eventsTable() + "_logs"
+}
+
+// dialect returns the SQL dialect for the configured driver
+func (s *SQLSink) dialect() dialect {
+	if s.config.Driver == DriverSQLite {
+		return sqliteDialect{}
+	}
+	return postgresDialect{partitionBy: s.config.PartitionBy}
+}
+
+// decodeLog returns the decoded parameters stored in decoded_data, or nil if the
+// log is not recognized or too malformed to carry any
+func decodeLog(event sinks.Event, log *types.Log) map[string]interface{} {
+	decoded, found := event.Decode(log)
+	if !found {
+		return nil
+	}
+	if decoded.Fields != nil {
+		return decoded.Fields
+	}
+
+	data := make(map[string]interface{})
+	for key, value := range map[string]string{
+		"from":           decoded.From,
+		"to":             decoded.To,
+		"owner":          decoded.Owner,
+		"spender":        decoded.Spender,
+		"implementation": decoded.Implementation,
+	} {
+		if value != "" {
+			data[key] = value
+		}
+	}
+
+	if decoded.Value != nil {
+		data["value"] = decoded.Value.String()
+		if decoded.Event == erc20.Approval && erc20.IsInfiniteApproval(decoded.Value) {
+			data["value"] = erc20.InfiniteApprovalValue
+			data["infinite_approval"] = true
+		}
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+	return data
+}
+
+// firstLine returns the first line of a statement for error messages
+func firstLine(stmt string) string {
+	stmt = strings.TrimSpace(stmt)
+	if i := strings.IndexByte(stmt, '\n'); i >= 0 {
+		return stmt[:i]
+	}
+	return stmt
+}
@@ -0,0 +1,109 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"usdc-event-tracker/internal/sinks"
+)
+
+// recordingDriver hands out connections that cannot prepare statements and
+// counts how many of them are still open
+type recordingDriver struct {
+	open atomic.Int64
+}
+
+func (d *recordingDriver) Open(name string) (driver.Conn, error) {
+	d.open.Add(1)
+	return &recordingConn{driver: d}, nil
+}
+
+type recordingConn struct {
+	driver *recordingDriver
+}
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+
+func (c *recordingConn) Close() error {
+	c.driver.open.Add(-1)
+	return nil
+}
+
+func (c *recordingConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions not supported")
+}
+
+var testDriver = &recordingDriver{}
+
+func init() {
+	sql.Register("recording", testDriver)
+}
+
+func TestInitializeClosesDatabaseOnError(t *testing.T) {
+	s := New(Config{Driver: "recording", ConnectionString: "test"})
+
+	err := s.Initialize(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "failed to prepare statements") {
+		t.Fatalf("Initialize = %v, want a prepare error", err)
+	}
+	if s.db != nil {
+		t.Errorf("Initialize kept the database handle after failing")
+	}
+	if open := testDriver.open.Load(); open != 0 {
+		t.Errorf("%d connections still open after a failed Initialize", open)
+	}
+}
+
+func TestFirstLogIndex(t *testing.T) {
+	tests := []struct {
+		name string
+		logs []uint
+		want uint
+	}{
+		{name: "no logs", want: 0},
+		{name: "single log", logs: []uint{7}, want: 7},
+		{name: "unordered logs", logs: []uint{9, 4, 6}, want: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := sinks.Event{}
+			for _, index := range tt.logs {
+				event.Logs = append(event.Logs, &types.Log{Index: index})
+			}
+			if got := firstLogIndex(event); got != tt.want {
+				t.Errorf("firstLogIndex = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventKeyIncludesLogIndex(t *testing.T) {
+	dialects := map[string]dialect{
+		"postgres":             postgresDialect{},
+		"postgres partitioned": postgresDialect{partitionBy: PartitionByMonth},
+		"sqlite":               sqliteDialect{},
+	}
+
+	for name, d := range dialects {
+		t.Run(name, func(t *testing.T) {
+			// Two events of one transaction must not collapse into one row
+			ddl := d.createTablesDDL("usdc_events", "usdc_events_logs", "usdc_events")[0]
+			if !strings.Contains(ddl, "block_number, tx_hash, variant, log_index") {
+				t.Errorf("events table unique key lacks log_index:\n%s", ddl)
+			}
+			if insert := d.insertEventSQL("usdc_events"); !strings.Contains(insert, "ON CONFLICT (block_number, tx_hash, variant, log_index") {
+				t.Errorf("event upsert conflict target lacks log_index:\n%s", insert)
+			}
+		})
+	}
+}
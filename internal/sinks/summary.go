@@ -0,0 +1,32 @@
+package sinks
+
+import "context"
+
+// BlockSummary is a rollup of a block's USDC activity, written instead of the
+// individual events by the aggregate sink. Raw values are decimal strings in token
+// base units; the *USDC fields are the same amounts in whole tokens for charting
+// (see TrackedToken), named for the default USDC mode.
+// Value statistics cover transfers only and are empty when a block has none.
+type BlockSummary struct {
+	BlockNumber     uint64  `json:"block_number"`
+	Timestamp       string  `json:"timestamp"`
+	TransferCount   int     `json:"transfer_count"`
+	ApprovalCount   int     `json:"approval_count"`
+	UniqueSenders   int     `json:"unique_senders"`
+	UniqueReceivers int     `json:"unique_receivers"`
+	TotalValue      string  `json:"total_value"`
+	MinValue        string  `json:"min_value,omitempty"`
+	MaxValue        string  `json:"max_value,omitempty"`
+	AvgValue        string  `json:"avg_value,omitempty"`
+	TotalUSDC       float64 `json:"total_usdc"`
+	MinUSDC         float64 `json:"min_usdc"`
+	MaxUSDC         float64 `json:"max_usdc"`
+	AvgUSDC         float64 `json:"avg_usdc"`
+}
+
+// SummaryWriter is implemented by sinks that can store block summaries, which
+// makes them usable as the inner sink of the aggregate sink.
+type SummaryWriter interface {
+	// WriteSummaries stores summaries, one per block in ascending block order
+	WriteSummaries(ctx context.Context, summaries []BlockSummary) error
+}
@@ -0,0 +1,39 @@
+package sinks
+
+import (
+	"math/big"
+
+	"usdc-event-tracker/internal/erc20"
+	"usdc-event-tracker/internal/usdc"
+)
+
+// Token describes how sinks present amounts of the tracked token for humans.
+// Raw values in the wire format are always base units and unaffected.
+type Token struct {
+	Symbol   string // Shown next to amounts, empty for none
+	Decimals int    // Decimal places of the token, 0 shows base units
+}
+
+// TrackedToken is the token sinks format amounts for. It is set once at startup
+// from TRACK_MODE, TOKEN_SYMBOL and TOKEN_DECIMALS, before any events are processed.
+var TrackedToken = Token{Symbol: "USDC", Decimals: usdc.Decimals}
+
+// Name returns the token symbol, or "Token" if it has none
+func (t Token) Name() string {
+	if t.Symbol == "" {
+		return "Token"
+	}
+	return t.Symbol
+}
+
+// Format formats a base-unit amount with thousands separators and the symbol, e.g. "1,250.00 USDC"
+func (t Token) Format(value *big.Int) string {
+	return erc20.FormatAmount(value, t.Decimals, t.Symbol)
+}
+
+// Float converts a base-unit amount to whole tokens, for charting
+func (t Token) Float(value *big.Int) float64 {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(t.Decimals)), nil)
+	f, _ := new(big.Rat).SetFrac(value, scale).Float64()
+	return f
+}
@@ -0,0 +1,238 @@
+package sinks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"usdc-event-tracker/internal/logging"
+)
+
+// ErrWriteTimeout is returned for writes abandoned because a sink exceeded the write timeout
+var ErrWriteTimeout = errors.New("sink write timed out")
+
+// timeoutSink bounds how long a single Write may take. A write that exceeds the
+// timeout is abandoned: its context is canceled, its events are dead-lettered and
+// Write returns, so a slow sink cannot stall the pipeline. Until an abandoned write
+// actually returns, later writes to the same sink are dead-lettered as well rather
+// than run concurrently with it.
+type timeoutSink struct {
+	sink       Sink
+	timeout    time.Duration
+	deadLetter *DeadLetterFile
+	logger     *logging.Logger
+
+	// Holds a token while a write, possibly abandoned, is still running
+	busy chan struct{}
+
+	// Metrics
+	mu           sync.Mutex
+	timeouts     int64
+	deadLettered int64
+}
+
+// newTimeoutSink wraps sink so each Write is bounded by timeout. deadLetter may be nil.
+func newTimeoutSink(sink Sink, timeout time.Duration, deadLetter *DeadLetterFile) *timeoutSink {
+	return &timeoutSink{
+		sink:       sink,
+		timeout:    timeout,
+		deadLetter: deadLetter,
+		logger:     logging.GetLogger("sink-manager"),
+		busy:       make(chan struct{}, 1),
+	}
+}
+
+// Name returns the name of the wrapped sink.
+func (s *timeoutSink) Name() string {
+	return s.sink.Name()
+}
+
+// Initialize prepares the wrapped sink for use.
+func (s *timeoutSink) Initialize(ctx context.Context) error {
+	return s.sink.Initialize(ctx)
+}
+
+// Write forwards events, giving up after the timeout.
+func (s *timeoutSink) Write(ctx context.Context, events []Event) error {
+	select {
+	case s.busy <- struct{}{}:
+	default:
+		s.abandon(events, "previous write still running")
+		return fmt.Errorf("%w: previous write to %s still running", ErrWriteTimeout, s.sink.Name())
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	done := make(chan error, 1)
+	go func() {
+		defer func() { <-s.busy }()
+		defer cancel()
+		done <- safeWrite(writeCtx, s.sink, events)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-writeCtx.Done():
+	}
+
+	// The caller gave up, e.g. on shutdown; that is not the sink's fault
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	s.mu.Lock()
+	s.timeouts++
+	s.mu.Unlock()
+	s.abandon(events, "timeout")
+	return fmt.Errorf("%w after %s", ErrWriteTimeout, s.timeout)
+}
+
+// Close waits for a running write, then cleans up the wrapped sink.
+func (s *timeoutSink) Close() error {
+	s.busy <- struct{}{}
+	defer func() { <-s.busy }()
+	return s.sink.Close()
+}
+
+// Flush waits for a running write, then flushes the wrapped sink if it buffers events.
+func (s *timeoutSink) Flush(ctx context.Context) error {
+	select {
+	case s.busy <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-s.busy }()
+	return flush(ctx, s.sink)
+}
+
+// WriteHeartbeat waits for a running write, then forwards a block heartbeat to
+// the wrapped sink if it stores heartbeats.
+func (s *timeoutSink) WriteHeartbeat(ctx context.Context, heartbeat BlockHeartbeat) error {
+	select {
+	case s.busy <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-s.busy }()
+	return writeHeartbeat(ctx, s.sink, heartbeat)
+}
+
+// WriteBalances waits for a running write, then forwards balance snapshots to the
+// wrapped sink if it stores them.
+func (s *timeoutSink) WriteBalances(ctx context.Context, snapshots []BalanceSnapshot) error {
+	select {
+	case s.busy <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-s.busy }()
+	return writeBalances(ctx, s.sink, snapshots)
+}
+
+// Stats returns the wrapped sink's metrics plus the timeout counts.
+func (s *timeoutSink) Stats() map[string]interface{} {
+	collected := make(map[string]interface{})
+	for key, value := range stats(s.sink) {
+		collected[key] = value
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	collected["write_timeouts"] = s.timeouts
+	collected["dead_lettered_events"] = s.deadLettered
+	return collected
+}
+
+// abandon logs a write that was given up on and hands its events to the dead-letter file
+func (s *timeoutSink) abandon(events []Event, reason string) {
+	s.mu.Lock()
+	s.deadLettered += int64(len(events))
+	s.mu.Unlock()
+
+	fields := map[string]interface{}{
+		"sink_name":   s.sink.Name(),
+		"reason":      reason,
+		"timeout":     s.timeout.String(),
+		"event_count": len(events),
+	}
+	if len(events) > 0 {
+		fields["first_block"] = events[0].BlockNumber
+		fields["last_block"] = events[len(events)-1].BlockNumber
+	}
+	s.logger.Warn("Sink write timed out, events dead-lettered", fields)
+
+	if err := s.deadLetter.Add(s.sink.Name(), reason, events); err != nil {
+		s.logger.Error("Failed to write dead-lettered events", err, map[string]interface{}{
+			"sink_name":   s.sink.Name(),
+			"event_count": len(events),
+		})
+	}
+}
+
+// DeadLetterFile appends events a sink failed to accept to a JSON Lines file, one
+// record per event, so they can be inspected and replayed later.
+// A nil *DeadLetterFile is valid and discards events.
+type DeadLetterFile struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// deadLetterRecord is a single line of the dead-letter file
+type deadLetterRecord struct {
+	Sink           string    `json:"sink"`
+	Reason         string    `json:"reason"`
+	DeadLetteredAt string    `json:"dead_lettered_at"`
+	Event          EventJSON `json:"event"`
+}
+
+// OpenDeadLetterFile opens path for appending, creating it if needed
+func OpenDeadLetterFile(path string) (*DeadLetterFile, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dead-letter file %s: %w", path, err)
+	}
+	return &DeadLetterFile{file: file}, nil
+}
+
+// Add appends events that sinkName did not accept, with the reason they were dropped
+func (d *DeadLetterFile) Add(sinkName, reason string, events []Event) error {
+	if d == nil {
+		return nil
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	var buf []byte
+	for _, event := range events {
+		line, err := json.Marshal(deadLetterRecord{
+			Sink:           sinkName,
+			Reason:         reason,
+			DeadLetteredAt: now,
+			Event:          NewEventJSON(event, nil),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal dead-lettered event: %w", err)
+		}
+		buf = append(append(buf, line...), '\n')
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, err := d.file.Write(buf); err != nil {
+		return fmt.Errorf("failed to append to dead-letter file: %w", err)
+	}
+	return nil
+}
+
+// Close closes the dead-letter file
+func (d *DeadLetterFile) Close() error {
+	if d == nil {
+		return nil
+	}
+	return d.file.Close()
+}
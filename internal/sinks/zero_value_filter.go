@@ -0,0 +1,103 @@
+package sinks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"usdc-event-tracker/internal/erc20"
+)
+
+// ZeroValueFilterSink wraps another sink and drops Transfer logs whose decoded
+// value is exactly zero, as emitted by spam and approval-via-transfer patterns.
+// Transfers whose value cannot be decoded and all other event types pass.
+// Events left without any kept logs are dropped.
+type ZeroValueFilterSink struct {
+	sink Sink
+
+	mu      sync.Mutex
+	dropped int64
+}
+
+// NewZeroValueFilterSink wraps sink so it never receives zero-value Transfers.
+func NewZeroValueFilterSink(sink Sink) *ZeroValueFilterSink {
+	return &ZeroValueFilterSink{sink: sink}
+}
+
+// Name returns the name of the wrapped sink.
+func (z *ZeroValueFilterSink) Name() string {
+	return z.sink.Name()
+}
+
+// Initialize prepares the wrapped sink for use.
+func (z *ZeroValueFilterSink) Initialize(ctx context.Context) error {
+	return z.sink.Initialize(ctx)
+}
+
+// Write forwards all logs except zero-value Transfers.
+func (z *ZeroValueFilterSink) Write(ctx context.Context, events []Event) error {
+	filtered := make([]Event, 0, len(events))
+	var dropped int64
+
+	for _, event := range events {
+		matched := event
+		matched.Logs = matched.Logs[:0:0]
+		for _, log := range event.Logs {
+			if isZeroValueTransfer(event, log) {
+				dropped++
+				continue
+			}
+			matched.Logs = append(matched.Logs, log)
+		}
+
+		if len(matched.Logs) > 0 {
+			filtered = append(filtered, matched)
+		}
+	}
+
+	z.mu.Lock()
+	z.dropped += dropped
+	z.mu.Unlock()
+
+	return z.sink.Write(ctx, filtered)
+}
+
+// Close cleans up the wrapped sink.
+func (z *ZeroValueFilterSink) Close() error {
+	return z.sink.Close()
+}
+
+// Flush flushes the wrapped sink if it buffers events.
+func (z *ZeroValueFilterSink) Flush(ctx context.Context) error {
+	return flush(ctx, z.sink)
+}
+
+// WriteHeartbeat forwards a block heartbeat to the wrapped sink if it stores heartbeats.
+func (z *ZeroValueFilterSink) WriteHeartbeat(ctx context.Context, heartbeat BlockHeartbeat) error {
+	return writeHeartbeat(ctx, z.sink, heartbeat)
+}
+
+// WriteBalances forwards balance snapshots to the wrapped sink if it stores them.
+func (z *ZeroValueFilterSink) WriteBalances(ctx context.Context, snapshots []BalanceSnapshot) error {
+	return writeBalances(ctx, z.sink, snapshots)
+}
+
+// Stats returns the wrapped sink's metrics plus the number of dropped Transfers.
+func (z *ZeroValueFilterSink) Stats() map[string]interface{} {
+	collected := make(map[string]interface{})
+	for key, value := range stats(z.sink) {
+		collected[key] = value
+	}
+
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	collected["zero_value_transfers_dropped"] = z.dropped
+	return collected
+}
+
+// isZeroValueTransfer reports whether log, one of event.Logs, is a Transfer of zero tokens
+func isZeroValueTransfer(event Event, log *types.Log) bool {
+	decoded, found := event.Decode(log)
+	return found && decoded.Event == erc20.Transfer && decoded.Value != nil && decoded.Value.Sign() == 0
+}
@@ -0,0 +1,61 @@
+package tracker
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"usdc-event-tracker/internal/erc20"
+	"usdc-event-tracker/internal/sinks"
+)
+
+// snapshotBalances reads the balance of every WATCH_ADDRESSES entry on every
+// tracked contract at blockNumber, if it is a multiple of BALANCE_SNAPSHOT_INTERVAL.
+// A failed call is logged and its snapshot left out rather than retried, so a node
+// that no longer has the block's state cannot stall block processing.
+func (t *Tracker) snapshotBalances(ctx context.Context, blockNumber uint64) []sinks.BalanceSnapshot {
+	interval := t.config.BalanceInterval
+	if interval == 0 || blockNumber%interval != 0 {
+		return nil
+	}
+
+	timestamp := time.Now()
+	if blockTime, err := t.blockTime(ctx, blockNumber); err == nil {
+		timestamp = blockTime
+	}
+
+	var snapshots []sinks.BalanceSnapshot
+	for _, contract := range t.config.USDCContracts {
+		token := common.HexToAddress(contract.Address)
+		for _, watched := range t.config.WatchAddresses {
+			account := common.HexToAddress(watched)
+
+			if err := t.limiter.Wait(ctx); err != nil {
+				return snapshots
+			}
+			start := time.Now()
+			balance, err := erc20.BalanceOf(ctx, t.client, token, account, blockNumber)
+			t.observeRPC(err)
+			t.rpcMetrics.Observe("eth_call", start, err)
+			if err != nil {
+				t.logger.Warn("Failed to read balance, skipping snapshot", map[string]interface{}{
+					"block_number":     blockNumber,
+					"contract_address": token.Hex(),
+					"address":          account.Hex(),
+					"error":            err.Error(),
+				})
+				continue
+			}
+
+			snapshots = append(snapshots, sinks.BalanceSnapshot{
+				BlockNumber: blockNumber,
+				Timestamp:   timestamp.UTC().Format(time.RFC3339Nano),
+				Contract:    token.Hex(),
+				Variant:     contract.Variant,
+				Address:     account.Hex(),
+				Balance:     balance.String(),
+			})
+		}
+	}
+	return snapshots
+}
@@ -0,0 +1,86 @@
+package tracker
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// blockTimeCacheSize bounds the block timestamps kept in memory, enough to cover
+// overlapping backfill ranges and re-processing after a reorg or restart
+const blockTimeCacheSize = 4096
+
+// blockTimeCache is a thread-safe LRU of block number to block timestamp, shared
+// by the block workers so a block's header is fetched only once. A reorg can
+// replace a block with one a few seconds apart; the cached time is kept, which
+// is accurate enough for event timestamps.
+type blockTimeCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // Front is the most recently used
+	entries  map[uint64]*list.Element
+
+	hits   int64
+	misses int64
+}
+
+// blockTimeEntry is an element of blockTimeCache.order
+type blockTimeEntry struct {
+	blockNumber uint64
+	time        time.Time
+}
+
+// newBlockTimeCache creates a cache holding up to capacity timestamps
+func newBlockTimeCache(capacity int) *blockTimeCache {
+	return &blockTimeCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[uint64]*list.Element, capacity),
+	}
+}
+
+// get returns the cached timestamp of blockNumber
+func (c *blockTimeCache) get(blockNumber uint64) (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[blockNumber]
+	if !ok {
+		c.misses++
+		return time.Time{}, false
+	}
+	c.hits++
+	c.order.MoveToFront(element)
+	return element.Value.(*blockTimeEntry).time, true
+}
+
+// add caches the timestamp of blockNumber, evicting the least recently used entry if full
+func (c *blockTimeCache) add(blockNumber uint64, blockTime time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[blockNumber]; ok {
+		element.Value.(*blockTimeEntry).time = blockTime
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[blockNumber] = c.order.PushFront(&blockTimeEntry{blockNumber: blockNumber, time: blockTime})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*blockTimeEntry).blockNumber)
+	}
+}
+
+// stats returns the cache hit and miss counts
+func (c *blockTimeCache) stats() map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return map[string]interface{}{
+		"block_time_cache_size":   c.order.Len(),
+		"block_time_cache_hits":   c.hits,
+		"block_time_cache_misses": c.misses,
+	}
+}
@@ -0,0 +1,85 @@
+package tracker
+
+import "time"
+
+// catchUpReportInterval is how often progress is logged while catching up
+const catchUpReportInterval = 30 * time.Second
+
+// beginCatchUp starts tracking the catch-up from start to the chain head, logging
+// how far behind the tracker is if it resumes from an older cursor
+func (t *Tracker) beginCatchUp(start, head uint64) {
+	t.catchUpFrom = start
+	t.catchUpStarted = time.Now()
+	t.lastCatchUpReport = time.Now()
+	if start >= head {
+		return
+	}
+
+	t.logger.Info("Catching up", map[string]interface{}{
+		"event_type":    "catch_up",
+		"start_block":   start,
+		"head":          head,
+		"blocks_behind": head - start + 1,
+	})
+}
+
+// trackCatchUp is called by the writer after writing blockNumber. Until the
+// tracker is caught up it periodically logs progress, and it marks the tracker
+// caught up once blockNumber reaches the latest known head.
+func (t *Tracker) trackCatchUp(blockNumber uint64) {
+	if t.caughtUp.Load() {
+		return
+	}
+
+	head := t.head.Load()
+	if blockNumber >= head {
+		t.markCaughtUp(blockNumber)
+		return
+	}
+	if time.Since(t.lastCatchUpReport) < catchUpReportInterval {
+		return
+	}
+	t.lastCatchUpReport = time.Now()
+
+	done := blockNumber - t.catchUpFrom + 1
+	rate := float64(done) / time.Since(t.catchUpStarted).Seconds()
+	fields := map[string]interface{}{
+		"event_type":        "catch_up_progress",
+		"block_number":      blockNumber,
+		"head":              head,
+		"blocks_behind":     head - blockNumber,
+		"blocks_done":       done,
+		"blocks_per_second": rate,
+	}
+	if rate > 0 {
+		eta := time.Duration(float64(head-blockNumber) / rate * float64(time.Second))
+		fields["eta"] = eta.Round(time.Second).String()
+	}
+	t.logger.Info("Catching up", fields)
+}
+
+// markCaughtUp records that every block up to the head was written, once, and
+// stops the extra CATCH_UP_WORKERS
+func (t *Tracker) markCaughtUp(blockNumber uint64) {
+	if !t.caughtUp.CompareAndSwap(false, true) {
+		return
+	}
+	close(t.caughtUpCh)
+
+	duration := time.Since(t.catchUpStarted)
+	t.logger.Info("Caught up, now following head", map[string]interface{}{
+		"event_type":   "caught_up",
+		"block_number": blockNumber,
+		"head":         t.head.Load(),
+		"blocks":       blockNumber - t.catchUpFrom + 1,
+		"duration":     duration.Round(time.Millisecond).String(),
+		"duration_ms":  duration.Milliseconds(),
+	})
+}
+
+// CaughtUp reports whether the tracker has written every block up to the chain
+// head it saw, i.e. finished any catch-up from the cursor and is following the
+// head. Readiness probes can wait for it. It is safe to call from any goroutine.
+func (t *Tracker) CaughtUp() bool {
+	return t.caughtUp.Load()
+}
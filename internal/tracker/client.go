@@ -0,0 +1,33 @@
+package tracker
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"usdc-event-tracker/internal/tx"
+	"usdc-event-tracker/internal/ws"
+)
+
+// ChainClient is the part of *ethclient.Client the tracker uses, so it can run
+// against another implementation, such as a fake chain in tests
+type ChainClient interface {
+	bind.ContractCaller  // CodeAt and balanceOf calls
+	ethereum.LogFilterer // Log subscription mode
+	tx.ReceiptClient
+
+	ChainID(ctx context.Context) (*big.Int, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+}
+
+// supportsSubscriptions reports whether client can use eth_subscribe. Only an
+// *ethclient.Client connected over WebSocket or IPC can; other clients are polled.
+func supportsSubscriptions(client ChainClient) bool {
+	ethClient, ok := client.(*ethclient.Client)
+	return ok && ws.SupportsSubscriptions(ethClient)
+}
@@ -0,0 +1,136 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// loadCursor reads the last checkpointed block from path.
+// It returns false if no cursor has been written yet.
+func loadCursor(path string) (uint64, bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read cursor file: %w", err)
+	}
+
+	block, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid cursor file %s: %w", path, err)
+	}
+	return block, true, nil
+}
+
+// saveCursor atomically replaces the cursor file with block
+func saveCursor(path string, block uint64) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create cursor file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(strconv.FormatUint(block, 10) + "\n"); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cursor file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync cursor file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close cursor file: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// resumeBlock returns the block to start from: the one after the checkpointed
+// cursor if there is one, otherwise head
+func (t *Tracker) resumeBlock(head uint64) uint64 {
+	if t.config.CursorFile == "" {
+		return head
+	}
+
+	cursor, ok, err := loadCursor(t.config.CursorFile)
+	if err != nil {
+		t.logger.Error("Failed to load cursor, starting from chain head", err, map[string]interface{}{
+			"cursor_file": t.config.CursorFile,
+		})
+		return head
+	}
+	if !ok || cursor >= head {
+		return head
+	}
+
+	t.logger.Info("Resuming from checkpointed cursor", map[string]interface{}{
+		"cursor_file": t.config.CursorFile,
+		"cursor":      cursor,
+		"head":        head,
+	})
+	return cursor + 1
+}
+
+// checkpoint records blockNumber as the cursor once every sink has durably
+// persisted it. Sinks are flushed first, so the cursor never runs ahead of the
+// data and blocks after it are re-delivered after a restart (at-least-once).
+// Blocks up to the cursor are also committed in the write-ahead log.
+// Unless force is set, checkpoints are taken at most once per CheckpointInterval.
+func (t *Tracker) checkpoint(blockNumber uint64, force bool) {
+	if (t.config.CursorFile == "" && t.wal == nil) || t.config.DryRun || t.cursorHeld {
+		return
+	}
+	if !force && time.Since(t.lastCheckpoint) < t.config.CheckpointInterval {
+		return
+	}
+	t.lastCheckpoint = time.Now()
+
+	// Confirmation sinks have not written the latest blocks yet, so they are delivered again after a restart
+	if blockNumber < t.maxConfirmations {
+		return
+	}
+	blockNumber -= t.maxConfirmations
+
+	// Not derived from the tracker context, so the final checkpoint still runs during shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := t.sinkManager.Flush(ctx); err != nil {
+		t.logger.Error("Failed to flush sinks, cursor not advanced", err, map[string]interface{}{
+			"block_number": blockNumber,
+		})
+		return
+	}
+	if err := t.wal.commit(blockNumber); err != nil {
+		t.logger.Error("Failed to commit write-ahead log", err, map[string]interface{}{
+			"block_number": blockNumber,
+		})
+	}
+	if t.config.CursorFile == "" {
+		return
+	}
+	if err := saveCursor(t.config.CursorFile, blockNumber); err != nil {
+		t.logger.Error("Failed to save cursor", err, map[string]interface{}{
+			"block_number": blockNumber,
+		})
+	}
+}
+
+// holdCursor stops the cursor and the write-ahead log commits from advancing for
+// the rest of the run, so a block that failed to write is processed again after
+// a restart
+func (t *Tracker) holdCursor(blockNumber uint64) {
+	if (t.config.CursorFile == "" && t.wal == nil) || t.cursorHeld {
+		return
+	}
+	t.cursorHeld = true
+	t.logger.Warn("Block write failed, cursor held until restart", map[string]interface{}{
+		"block_number": blockNumber,
+	})
+}
@@ -0,0 +1,101 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"usdc-event-tracker/internal/config"
+	"usdc-event-tracker/internal/logging"
+	"usdc-event-tracker/internal/sinks"
+)
+
+// errSinkDown is returned by failingSink
+var errSinkDown = errors.New("sink down")
+
+// failingSink fails every write of a block in failBlocks and accepts the rest
+type failingSink struct {
+	*sinks.MemorySink
+	failBlocks map[uint64]bool
+}
+
+func (s *failingSink) Name() string {
+	return "failing"
+}
+
+func (s *failingSink) Write(ctx context.Context, events []sinks.Event) error {
+	for _, event := range events {
+		if s.failBlocks[event.BlockNumber] {
+			return errSinkDown
+		}
+	}
+	return s.MemorySink.Write(ctx, events)
+}
+
+// newTestTracker returns a tracker writing to the given sinks, without an RPC client
+func newTestTracker(t *testing.T, cfg *config.Config, sinkList ...sinks.Sink) *Tracker {
+	t.Helper()
+
+	manager := sinks.NewManager()
+	for _, sink := range sinkList {
+		manager.AddSink(sink)
+	}
+	if err := manager.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize sinks: %v", err)
+	}
+	t.Cleanup(func() { manager.Close() })
+
+	return &Tracker{
+		config:      cfg,
+		sinkManager: manager,
+		logger:      logging.GetLogger("tracker"),
+		blockTimes:  newBlockTimeCache(blockTimeCacheSize),
+		caughtUpCh:  make(chan struct{}),
+		drain:       make(chan struct{}),
+	}
+}
+
+// writeBlocks delivers one event per block through the in-order writer
+func writeBlocks(tr *Tracker, blocks ...uint64) {
+	results := make(chan blockResult, len(blocks))
+	for _, block := range blocks {
+		results <- blockResult{blockNumber: block, events: []sinks.Event{{BlockNumber: block}}}
+	}
+	close(results)
+	tr.writeInOrder(context.Background(), blocks[0], results, nil)
+}
+
+func TestFailedSinkWriteHoldsCursor(t *testing.T) {
+	cfg := &config.Config{CursorFile: filepath.Join(t.TempDir(), "cursor")}
+	memory := sinks.NewMemorySink()
+	failing := &failingSink{MemorySink: sinks.NewMemorySink(), failBlocks: map[uint64]bool{11: true}}
+	tr := newTestTracker(t, cfg, memory, failing)
+	tr.head.Store(100)
+
+	writeBlocks(tr, 10, 11, 12)
+
+	cursor, ok, err := loadCursor(cfg.CursorFile)
+	if err != nil || !ok {
+		t.Fatalf("loadCursor = %d, %v, %v; want a cursor", cursor, ok, err)
+	}
+	if cursor != 10 {
+		t.Errorf("cursor = %d, want 10, the last block before the failed one", cursor)
+	}
+	if got := memory.Count(); got != 3 {
+		t.Errorf("healthy sink got %d events, want 3", got)
+	}
+}
+
+func TestCursorAdvancesWhenSinksSucceed(t *testing.T) {
+	cfg := &config.Config{CursorFile: filepath.Join(t.TempDir(), "cursor")}
+	tr := newTestTracker(t, cfg, sinks.NewMemorySink())
+	tr.head.Store(100)
+
+	writeBlocks(tr, 10, 11, 12)
+
+	cursor, ok, err := loadCursor(cfg.CursorFile)
+	if err != nil || !ok || cursor != 12 {
+		t.Errorf("loadCursor = %d, %v, %v; want 12", cursor, ok, err)
+	}
+}
@@ -0,0 +1,39 @@
+package tracker
+
+import "context"
+
+// Drain stops the tracker from taking on new blocks. Blocks already being fetched
+// or written are finished, the sinks are flushed and the cursor is checkpointed,
+// then Start returns nil. Canceling Start's context instead stops immediately,
+// possibly in the middle of a block. It is safe to call from any goroutine and
+// more than once, also before Start.
+func (t *Tracker) Drain() {
+	t.drainOnce.Do(func() {
+		t.logger.Info("Draining, no new blocks are started")
+		close(t.drain)
+	})
+}
+
+// draining reports whether Drain was called
+func (t *Tracker) draining() bool {
+	select {
+	case <-t.drain:
+		return true
+	default:
+		return false
+	}
+}
+
+// intake returns a context for taking on new blocks, canceled by Drain or when ctx
+// is canceled. Work on blocks already taken on keeps using ctx, so it can finish.
+func (t *Tracker) intake(ctx context.Context) (context.Context, context.CancelFunc) {
+	intake, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-t.drain:
+			cancel()
+		case <-intake.Done():
+		}
+	}()
+	return intake, cancel
+}
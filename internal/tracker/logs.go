@@ -0,0 +1,315 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"usdc-event-tracker/internal/erc20"
+	"usdc-event-tracker/internal/ws"
+)
+
+// followLogs is the USE_LOG_SUBSCRIPTION alternative to monitorBlocks. Instead of
+// fetching every receipt of every block, it subscribes to Transfer and Approval logs
+// of the tracked contracts and writes them grouped by block and transaction.
+// Blocks produced before the subscription was (re)established are backfilled with
+// eth_getLogs, so reconnects never skip events.
+func (t *Tracker) followLogs(ctx context.Context) error {
+	intake, stopIntake := t.intake(ctx)
+	defer stopIntake()
+
+	head, err := t.waitForHead(intake)
+	if err != nil {
+		// Nil if drained before the first block
+		return ctx.Err()
+	}
+	start := t.resumeBlock(head)
+	next := start
+	t.beginCatchUp(start, head)
+
+	t.logger.Info("Log subscription started", map[string]interface{}{
+		"start_block": start,
+		"contracts":   t.trackedContracts(),
+	})
+
+	backoff := minResubscribeBackoff
+	attempt := 0
+
+	for intake.Err() == nil {
+		logs, sub, err := ws.SubscribeUSDCLogs(t.client, ctx, t.trackedContracts(), t.logTopics())
+		if err != nil {
+			attempt++
+			if !t.waitToResubscribe(intake, "Log", attempt, &backoff, err) {
+				break
+			}
+			continue
+		}
+
+		// The subscription only delivers new logs, catch up on anything before it.
+		// Without the backfill there would be a gap, so a failure counts as a failed attempt.
+		if next, err = t.backfillLogs(ctx, next); err != nil {
+			sub.Unsubscribe()
+			attempt++
+			if !t.waitToResubscribe(intake, "Log", attempt, &backoff, err) {
+				break
+			}
+			continue
+		}
+
+		t.markCaughtUp(next - 1)
+
+		if attempt > 0 {
+			t.logger.Info("Log subscription re-established", map[string]interface{}{
+				"attempts":   attempt,
+				"next_block": next,
+			})
+		}
+		backoff = minResubscribeBackoff
+		attempt = 0
+
+		next, err = t.consumeLogs(ctx, sub, logs, next)
+		sub.Unsubscribe()
+		if intake.Err() != nil {
+			break
+		}
+
+		t.logger.Warn("Log subscription dropped, resubscribing", map[string]interface{}{
+			"next_block": next,
+			"error":      fmt.Sprint(err),
+		})
+	}
+
+	// Record everything written before shutdown
+	if next > start {
+		t.checkpoint(next-1, true)
+	}
+	return ctx.Err()
+}
+
+// logsBackfillRange is the number of blocks requested per eth_getLogs call, kept
+// below the range limits common RPC providers enforce
+const logsBackfillRange = 2000
+
+// backfillLogs fetches and writes the tracked logs of blocks next..head with
+// eth_getLogs and returns the block after the last one backfilled. After Drain it
+// finishes the range being written and stops.
+func (t *Tracker) backfillLogs(ctx context.Context, next uint64) (uint64, error) {
+	head, err := t.latestBlockNumber(ctx)
+	if err != nil {
+		return next, err
+	}
+
+	query := ws.USDCLogsQuery(t.trackedContracts(), t.logTopics())
+	for next <= head && !t.draining() {
+		to := next + logsBackfillRange - 1
+		if to > head {
+			to = head
+		}
+		query.FromBlock = new(big.Int).SetUint64(next)
+		query.ToBlock = new(big.Int).SetUint64(to)
+
+		if err := t.limiter.Wait(ctx); err != nil {
+			return next, err
+		}
+		start := time.Now()
+		logs, err := t.client.FilterLogs(ctx, query)
+		t.observeRPC(err)
+		t.rpcMetrics.Observe("eth_getLogs", start, err)
+		if err != nil {
+			return next, fmt.Errorf("failed to get logs for blocks %d-%d: %w", next, to, err)
+		}
+
+		t.logger.Info("Backfilled logs", map[string]interface{}{
+			"from_block": next,
+			"to_block":   to,
+			"log_count":  len(logs),
+		})
+
+		// eth_getLogs returns logs in block order, so blocks can be cut as they end
+		for len(logs) > 0 {
+			end := 1
+			for end < len(logs) && logs[end].BlockNumber == logs[0].BlockNumber {
+				end++
+			}
+			t.writeLogBlock(ctx, logs[0].BlockNumber, logs[:end])
+			logs = logs[end:]
+		}
+
+		next = to + 1
+		t.trackCatchUp(to)
+	}
+
+	return next, nil
+}
+
+// consumeLogs writes logs delivered by sub block by block until the subscription
+// fails, ctx is canceled or Drain is called. A block is written once a log of a
+// later block arrives or no further log arrives within the block interval. It
+// returns the next block to process and the subscription error.
+func (t *Tracker) consumeLogs(ctx context.Context, sub ethereum.Subscription, logs <-chan types.Log, next uint64) (uint64, error) {
+	var (
+		current uint64
+		batch   []types.Log
+	)
+	writePending := func() {
+		if len(batch) == 0 {
+			return
+		}
+		t.writeLogBlock(ctx, current, batch)
+		next = current + 1
+		batch = nil
+	}
+
+	idle := time.NewTimer(t.blockInterval)
+	defer idle.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			writePending()
+			return next, ctx.Err()
+		case <-t.drain:
+			writePending()
+			return next, nil
+		case err := <-sub.Err():
+			writePending()
+			return next, err
+		case <-idle.C:
+			writePending()
+			t.logRateLimitStats()
+			t.logRPCStats()
+			t.logSinkStats()
+			idle.Reset(t.blockInterval)
+		case log := <-logs:
+			if log.Removed {
+				t.removedLogs.Add(1)
+				t.logger.Warn("Log removed by chain reorganization", map[string]interface{}{
+					"block_number": log.BlockNumber,
+					"tx_hash":      log.TxHash.Hex(),
+					"log_index":    log.Index,
+				})
+				continue
+			}
+			if log.BlockNumber < next {
+				// Already written, e.g. by the backfill that overlaps the subscription
+				continue
+			}
+			if len(batch) > 0 && log.BlockNumber != current {
+				writePending()
+			}
+			current = log.BlockNumber
+			batch = append(batch, log)
+
+			if !idle.Stop() {
+				select {
+				case <-idle.C:
+				default:
+				}
+			}
+			idle.Reset(t.blockInterval)
+		}
+	}
+}
+
+// writeLogBlock groups a block's logs by transaction, converts them to sink
+// events and writes them, advancing the cursor on success
+func (t *Tracker) writeLogBlock(ctx context.Context, blockNumber uint64, logs []types.Log) {
+	// A missing block time falls back to the ingestion time (see sinks.Event.Timestamp)
+	blockTime, _ := t.blockTime(ctx, blockNumber)
+
+	receipts := t.logReceipts(ctx, logs)
+	result := blockResult{
+		blockNumber: blockNumber,
+		events:      t.convertToEvents(receipts, blockNumber, blockTime),
+	}
+
+	if err := t.writeBlock(ctx, result); err != nil {
+		t.logger.Error("Error processing block", err, map[string]interface{}{
+			"block_number": blockNumber,
+		})
+		t.holdCursor(blockNumber)
+		return
+	}
+	t.checkpoint(blockNumber, false)
+}
+
+// logReceipts returns one receipt per transaction in logs, in log order. Full
+// receipts are only fetched when RECEIPT_FIELDS selects fields that logs do not
+// carry; otherwise a receipt is assembled from the logs themselves.
+func (t *Tracker) logReceipts(ctx context.Context, logs []types.Log) []*types.Receipt {
+	receipts := make([]*types.Receipt, 0, len(logs))
+	assembled := make(map[common.Hash]*types.Receipt)
+	fetched := make(map[common.Hash]bool)
+
+	for i := range logs {
+		log := &logs[i]
+		if fetched[log.TxHash] {
+			// The full receipt already holds every log of the transaction
+			continue
+		}
+		if receipt, ok := assembled[log.TxHash]; ok {
+			receipt.Logs = append(receipt.Logs, log)
+			continue
+		}
+
+		receipt, ok := t.fetchLogReceipt(ctx, log)
+		if ok {
+			fetched[log.TxHash] = true
+		} else {
+			assembled[log.TxHash] = receipt
+		}
+		receipts = append(receipts, receipt)
+	}
+
+	return receipts
+}
+
+// fetchLogReceipt returns the full receipt of log's transaction when receipt-level
+// fields are needed, reporting true. Otherwise, or if the fetch fails, it returns
+// a receipt assembled from the log and false.
+func (t *Tracker) fetchLogReceipt(ctx context.Context, log *types.Log) (*types.Receipt, bool) {
+	if t.config.ReceiptFields.NeedsReceipt() {
+		if err := t.limiter.Wait(ctx); err == nil {
+			start := time.Now()
+			receipt, err := t.client.TransactionReceipt(ctx, log.TxHash)
+			t.observeRPC(err)
+			t.rpcMetrics.Observe("eth_getTransactionReceipt", start, err)
+			if err == nil {
+				return receipt, true
+			}
+			t.logger.Warn("Failed to get receipt, using log fields only", map[string]interface{}{
+				"tx_hash": log.TxHash.Hex(),
+				"error":   err.Error(),
+			})
+		}
+	}
+
+	// Only successful transactions emit logs
+	return &types.Receipt{
+		Status:           types.ReceiptStatusSuccessful,
+		TxHash:           log.TxHash,
+		BlockHash:        log.BlockHash,
+		BlockNumber:      new(big.Int).SetUint64(log.BlockNumber),
+		TransactionIndex: log.TxIndex,
+		Logs:             []*types.Log{log},
+	}, false
+}
+
+// logTopics returns the event signatures the log subscription filters on:
+// TRACK_TOPICS if set, otherwise every known event, including those loaded from
+// CUSTOM_EVENTS_FILE
+func (t *Tracker) logTopics() []common.Hash {
+	if len(t.config.TrackTopics) > 0 {
+		return t.config.TrackTopics
+	}
+
+	topics := make([]common.Hash, 0, len(erc20.EventSignatures))
+	for _, signature := range erc20.EventSignatures {
+		topics = append(topics, common.HexToHash(signature))
+	}
+	return topics
+}
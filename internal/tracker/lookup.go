@@ -0,0 +1,82 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"usdc-event-tracker/internal/sinks"
+	"usdc-event-tracker/internal/usdc"
+)
+
+// ProcessTxHash runs a single transaction through the same filter and decode path
+// as block processing and writes its events to the configured sinks, without
+// scanning any blocks. It initializes the sinks, flushes and closes them before
+// returning, and reports how many events were written, which is 0 if the
+// transaction did not touch a tracked contract.
+func (t *Tracker) ProcessTxHash(ctx context.Context, hash common.Hash) (written int, err error) {
+	if err := t.sinkManager.Initialize(ctx); err != nil {
+		return 0, fmt.Errorf("failed to initialize sinks: %w", err)
+	}
+	t.sinksReady.Store(true)
+	defer func() {
+		if closeErr := t.sinkManager.CloseTimeout(t.config.ShutdownTimeout); errors.Is(closeErr, sinks.ErrCloseTimeout) && err == nil {
+			err = fmt.Errorf("sinks did not close within %s: %w", t.config.ShutdownTimeout, closeErr)
+		}
+	}()
+
+	if err := t.limiter.Wait(ctx); err != nil {
+		return 0, err
+	}
+	start := time.Now()
+	receipt, err := t.client.TransactionReceipt(ctx, hash)
+	t.observeRPC(err)
+	t.rpcMetrics.Observe("eth_getTransactionReceipt", start, err)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get receipt for tx %s: %w", hash.Hex(), err)
+	}
+
+	blockNumber := receipt.BlockNumber.Uint64()
+	tracked := usdc.FilterByAddresses([]*types.Receipt{receipt}, t.trackedContracts())
+	if len(tracked) == 0 {
+		t.logger.Info("Transaction has no logs from tracked contracts", map[string]interface{}{
+			"tx_hash":      hash.Hex(),
+			"block_number": blockNumber,
+			"log_count":    len(receipt.Logs),
+		})
+		return 0, nil
+	}
+
+	blockTime, err := t.blockTime(ctx, blockNumber)
+	if err != nil {
+		return 0, err
+	}
+
+	events := t.convertToEvents(tracked, blockNumber, blockTime)
+	if len(events) == 0 {
+		// Filtered out, e.g. a reverted transaction with INCLUDE_FAILED_TX=false
+		t.logger.Info("Transaction produced no events", map[string]interface{}{
+			"tx_hash":      hash.Hex(),
+			"block_number": blockNumber,
+			"status":       receipt.Status,
+		})
+		return 0, nil
+	}
+
+	if err := t.sinkManager.Write(ctx, events); err != nil {
+		return 0, fmt.Errorf("failed to write to sinks: %w", err)
+	}
+	if err := t.sinkManager.Flush(ctx); err != nil {
+		return 0, fmt.Errorf("failed to flush sinks: %w", err)
+	}
+
+	t.logger.Info("Transaction processed", map[string]interface{}{
+		"tx_hash":      hash.Hex(),
+		"block_number": blockNumber,
+		"event_count":  len(events),
+	})
+	return len(events), nil
+}
@@ -0,0 +1,160 @@
+package tracker
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// reorgDepthBuckets are the upper bounds, in blocks, of the reorg depth histogram
+var reorgDepthBuckets = [...]uint64{1, 2, 3, 5, 10, 20, 50, 100}
+
+// reorgMetrics counts chain reorganizations as a counter named reorgs_total and
+// their depth as a histogram named reorg_depth. The zero value is ready to use,
+// and it is safe for concurrent use.
+type reorgMetrics struct {
+	mu      sync.Mutex
+	total   int64
+	blocks  uint64                        // Sum of all depths
+	buckets [len(reorgDepthBuckets)]int64 // Reorgs per bound, not cumulative
+}
+
+// observe records a reorg that replaced depth blocks
+func (m *reorgMetrics) observe(depth uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.total++
+	m.blocks += depth
+	for i, bound := range reorgDepthBuckets {
+		if depth <= bound {
+			m.buckets[i]++
+			break
+		}
+	}
+}
+
+// stats returns reorgs_total and reorg_depth with the count, sum and cumulative
+// histogram buckets of the depths
+func (m *reorgMetrics) stats() map[string]interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	buckets := make(map[string]int64, len(reorgDepthBuckets)+1)
+	var cumulative int64
+	for i, bound := range reorgDepthBuckets {
+		cumulative += m.buckets[i]
+		buckets[strconv.FormatUint(bound, 10)] = cumulative
+	}
+	buckets["+Inf"] = m.total
+
+	return map[string]interface{}{
+		"reorgs_total": m.total,
+		"reorg_depth": map[string]interface{}{
+			"count":   m.total,
+			"sum":     m.blocks,
+			"buckets": buckets,
+		},
+	}
+}
+
+// checkReorg compares the parent hash of result, the next block to be written,
+// with the hash of the block written before it. If they differ, the chain was
+// reorganized and the blocks replaced since the common ancestor are written
+// again before result (see handleReorg). Blocks without a hash, i.e. without
+// REORG_WINDOW or replayed from the write-ahead log, are not checked.
+func (t *Tracker) checkReorg(ctx context.Context, result blockResult) {
+	if t.config.ReorgWindow == 0 || result.hash == (common.Hash{}) {
+		return
+	}
+
+	if previous, ok := t.blockHashes[result.blockNumber-1]; ok && previous != result.parentHash {
+		t.handleReorg(ctx, result.blockNumber-1)
+	}
+	t.rememberBlock(result.blockNumber, result.hash)
+}
+
+// handleReorg walks back from tip, the last block written, to the common ancestor
+// of the written blocks and the canonical chain, then fetches the blocks after the
+// ancestor again and writes them to the sinks. Events of the replaced blocks that
+// sinks already stored are not retracted; the WARN line reports the replaced range.
+func (t *Tracker) handleReorg(ctx context.Context, tip uint64) {
+	ancestor, found := t.commonAncestor(ctx, tip)
+	if ctx.Err() != nil {
+		return
+	}
+
+	depth := tip - ancestor
+	t.reorgs.observe(depth)
+
+	fields := map[string]interface{}{
+		"event_type":      "reorg",
+		"common_ancestor": ancestor,
+		"from_block":      ancestor + 1,
+		"to_block":        tip,
+		"depth":           depth,
+	}
+	if !found {
+		// Deeper than REORG_WINDOW, blocks before the window may have been replaced as well
+		fields["reorg_window"] = t.config.ReorgWindow
+		fields["window_exceeded"] = true
+	}
+	t.logger.Warn("Chain reorganization detected, replacing blocks", fields)
+
+	for blockNumber := ancestor + 1; blockNumber <= tip; blockNumber++ {
+		result, ok := t.fetchBlockRetrying(ctx, blockNumber)
+		if !ok {
+			return
+		}
+		if err := t.writeBlock(ctx, result); err != nil {
+			t.logger.Error("Error processing block", err, map[string]interface{}{
+				"block_number": blockNumber,
+			})
+			t.holdCursor(blockNumber)
+		}
+		t.rememberBlock(blockNumber, result.hash)
+	}
+}
+
+// commonAncestor returns the newest block up to tip whose remembered hash matches
+// the canonical chain. It returns false if no remembered block matches, in which
+// case the block before the oldest remembered one is returned.
+func (t *Tracker) commonAncestor(ctx context.Context, tip uint64) (uint64, bool) {
+	for blockNumber := tip; ; blockNumber-- {
+		hash, ok := t.blockHashes[blockNumber]
+		if !ok {
+			return blockNumber, false
+		}
+
+		header, err := t.blockHeader(ctx, blockNumber)
+		for err != nil {
+			select {
+			case <-ctx.Done():
+				return blockNumber, false
+			case <-time.After(t.blockInterval):
+			}
+			header, err = t.blockHeader(ctx, blockNumber)
+		}
+		if header.Hash() == hash {
+			return blockNumber, true
+		}
+		if blockNumber == 0 {
+			return 0, false
+		}
+	}
+}
+
+// rememberBlock records the hash of a written block, forgetting the block that
+// fell out of REORG_WINDOW
+func (t *Tracker) rememberBlock(blockNumber uint64, hash common.Hash) {
+	if t.blockHashes == nil {
+		t.blockHashes = make(map[uint64]common.Hash, t.config.ReorgWindow)
+	}
+	t.blockHashes[blockNumber] = hash
+	if blockNumber >= t.config.ReorgWindow {
+		delete(t.blockHashes, blockNumber-t.config.ReorgWindow)
+	}
+}
@@ -0,0 +1,117 @@
+package tracker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"usdc-event-tracker/internal/config"
+	"usdc-event-tracker/internal/sinks"
+)
+
+// fetchAndWrite fetches blocks from the chain and delivers them through the in-order writer
+func fetchAndWrite(t *testing.T, tr *Tracker, blocks ...uint64) {
+	t.Helper()
+
+	results := make(chan blockResult, len(blocks))
+	for _, block := range blocks {
+		result, err := tr.fetchBlock(context.Background(), block)
+		if err != nil {
+			t.Fatalf("fetchBlock(%d): %v", block, err)
+		}
+		results <- result
+	}
+	close(results)
+	tr.writeInOrder(context.Background(), blocks[0], results, nil)
+}
+
+func TestReorgReplacesBlocksSinceCommonAncestor(t *testing.T) {
+	token := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	alice := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	bob := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	chain := &fakeChain{
+		head: 13,
+		receipts: map[uint64][]*types.Receipt{
+			10: {transferReceipt(token, 10, 0, alice, bob, 100)},
+			11: {transferReceipt(token, 11, 0, alice, bob, 110)},
+			12: {transferReceipt(token, 12, 0, alice, bob, 120)},
+		},
+	}
+	cfg := &config.Config{
+		USDCContracts: []config.USDCContract{{Address: token.Hex(), Variant: "native"}},
+		BlockInterval: 10 * time.Millisecond,
+		SampleRate:    1,
+		ReorgWindow:   8,
+	}
+
+	tr := New(chain, cfg, nil)
+	memory := sinks.NewMemorySink()
+	tr.addSink("memory", memory)
+	if err := tr.sinkManager.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize sinks: %v", err)
+	}
+	defer tr.sinkManager.Close()
+
+	fetchAndWrite(t, tr, 10, 11, 12)
+
+	// Blocks 11 and 12 are replaced, the new block 11 moving a different amount
+	chain.reorgFrom = 11
+	chain.receipts[11] = []*types.Receipt{transferReceipt(token, 11, 0, bob, alice, 111)}
+	chain.receipts[13] = []*types.Receipt{transferReceipt(token, 13, 0, alice, bob, 130)}
+	fetchAndWrite(t, tr, 13)
+
+	var blocks []uint64
+	for _, event := range memory.Events() {
+		blocks = append(blocks, event.BlockNumber)
+	}
+	want := []uint64{10, 11, 12, 11, 12, 13}
+	if len(blocks) != len(want) {
+		t.Fatalf("sinks got blocks %v, want %v", blocks, want)
+	}
+	for i := range want {
+		if blocks[i] != want[i] {
+			t.Fatalf("sinks got blocks %v, want %v", blocks, want)
+		}
+	}
+
+	replaced := memory.Events()[3]
+	if decoded, ok := replaced.Decode(replaced.Logs[0]); !ok || decoded.Value.Int64() != 111 {
+		t.Errorf("replaced block 11 carries %v, want the new fork's transfer of 111", decoded.Value)
+	}
+
+	stats := tr.reorgs.stats()
+	if total := stats["reorgs_total"]; total != int64(1) {
+		t.Errorf("reorgs_total = %v, want 1", total)
+	}
+	depth := stats["reorg_depth"].(map[string]interface{})
+	if sum := depth["sum"]; sum != uint64(2) {
+		t.Errorf("reorg_depth sum = %v, want 2", sum)
+	}
+	buckets := depth["buckets"].(map[string]int64)
+	if buckets["1"] != 0 || buckets["2"] != 1 || buckets["+Inf"] != 1 {
+		t.Errorf("reorg_depth buckets = %v, want the reorg in the 2 bucket", buckets)
+	}
+}
+
+func TestNoReorgOnCanonicalChain(t *testing.T) {
+	token := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	chain := &fakeChain{head: 20}
+	cfg := &config.Config{
+		USDCContracts: []config.USDCContract{{Address: token.Hex(), Variant: "native"}},
+		SampleRate:    1,
+		ReorgWindow:   4,
+	}
+
+	tr := New(chain, cfg, nil)
+	fetchAndWrite(t, tr, 10, 11, 12, 13, 14, 15)
+
+	if total := tr.reorgs.stats()["reorgs_total"]; total != int64(0) {
+		t.Errorf("reorgs_total = %v, want 0", total)
+	}
+	if len(tr.blockHashes) != 4 {
+		t.Errorf("remembered %d block hashes, want REORG_WINDOW = 4", len(tr.blockHashes))
+	}
+}
@@ -2,222 +2,778 @@ package tracker
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
+	"math/big"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"usdc-event-tracker/internal/config"
+	"usdc-event-tracker/internal/erc20"
 	"usdc-event-tracker/internal/logging"
 	"usdc-event-tracker/internal/sinks"
-	"usdc-event-tracker/internal/sinks/console"
-	"usdc-event-tracker/internal/sinks/elasticsearch"
-	"usdc-event-tracker/internal/sinks/fs"
 	"usdc-event-tracker/internal/tx"
 	"usdc-event-tracker/internal/usdc"
 )
 
 // Tracker monitors blockchain for USDC events
 type Tracker struct {
-	client        *ethclient.Client
+	client        ChainClient
 	config        *config.Config
 	blockInterval time.Duration
+	pollInterval  time.Duration
 	sinkManager   *sinks.Manager
 	logger        *logging.Logger
+
+	// RPC throttling
+	limiter            *tx.RateLimiter
+	lastRateLimitStats time.Time
+
+	// RPC latency and errors by method
+	rpcMetrics   *tx.RPCMetrics
+	lastRPCStats time.Time
+
+	// Receipt retrieval, falling back to per-transaction calls if needed
+	receipts *tx.ReceiptFetcher
+
+	// Block timestamps already fetched, shared by the block workers
+	blockTimes *blockTimeCache
+
+	lastSinkStats time.Time
+
+	// Progress, read by DumpStats from other goroutines
+	blocksProcessed atomic.Uint64
+	eventsEmitted   atomic.Uint64
+	lastBlock       atomic.Uint64
+	removedLogs     atomic.Uint64
+	sinksReady      atomic.Bool
+
+	// Latest chain head seen, read by confirmation sinks
+	head atomic.Uint64
+
+	// Catch-up from the cursor to the head; caughtUpCh is closed once caughtUp is set.
+	// The rest is only touched by the in-order writer.
+	caughtUp          atomic.Bool
+	caughtUpCh        chan struct{}
+	catchUpFrom       uint64
+	catchUpStarted    time.Time
+	lastCatchUpReport time.Time
+
+	// Deepest per-sink confirmation depth; the cursor stays this far behind
+	maxConfirmations uint64
+
+	// Cursor checkpointing, only touched by the in-order writer
+	lastCheckpoint time.Time
+	cursorHeld     bool
+
+	// Blocks recorded before they are written to the sinks, nil unless WAL_ENABLED
+	wal *writeAheadLog
+
+	// Hashes of the last REORG_WINDOW blocks written, only touched by the in-order writer
+	blockHashes map[uint64]common.Hash
+	reorgs      reorgMetrics
+
+	// Closed by Drain to stop taking on new blocks
+	drain     chan struct{}
+	drainOnce sync.Once
 }
 
-// New creates a new Tracker instance
-func New(client *ethclient.Client, cfg *config.Config) *Tracker {
+// New creates a new Tracker instance.
+// The limiter throttles RPC calls made by the tracker and may be nil.
+func New(client ChainClient, cfg *config.Config, limiter *tx.RateLimiter) *Tracker {
+	rpcMetrics := tx.NewRPCMetrics()
 	t := &Tracker{
 		client:        client,
 		config:        cfg,
 		blockInterval: cfg.BlockInterval,
+		pollInterval:  cfg.PollInterval,
 		sinkManager:   sinks.NewManager(),
 		logger:        logging.GetLogger("tracker"),
+		limiter:       limiter,
+		rpcMetrics:    rpcMetrics,
+		receipts:      tx.NewReceiptFetcher(client, limiter, rpcMetrics),
+		blockTimes:    newBlockTimeCache(blockTimeCacheSize),
+		caughtUpCh:    make(chan struct{}),
+		drain:         make(chan struct{}),
 	}
 	
 	// Initialize sinks based on configuration
 	t.initializeSinks(cfg)
+	t.sinkManager.SetFlushEveryWrite(cfg.FlushEveryBlock)
+	t.sinkManager.SetOrderWindow(cfg.SinkOrderWindow)
+	t.sinkManager.SetInitTimeout(cfg.SinkInitTimeout)
 	
 	return t
 }
 
-// initializeSinks sets up configured sinks
+// addSink registers a sink with the manager, wrapping it with the configured
+// per-sink confirmation depth, per-sink event type filters, address watchlist,
+// sampling and deduplication, in that order (see sinks.Chain). In dry-run
+// mode the sink itself is replaced with a no-op that only counts what would have
+// been written.
+func (t *Tracker) addSink(name string, sink sinks.Sink) {
+	if t.config.DryRun {
+		sink = sinks.NewDryRunSink(sink.Name())
+	}
+
+	var decorators []func(sinks.Sink) sinks.Sink
+	if depth := t.config.SinkConfirmations[name]; depth > 0 {
+		decorators = append(decorators, func(s sinks.Sink) sinks.Sink {
+			return sinks.NewConfirmationSink(s, depth, t.head.Load)
+		})
+		t.maxConfirmations = max(t.maxConfirmations, depth)
+	}
+	if eventTypes := t.config.SinkEventTypes[name]; len(eventTypes) > 0 {
+		decorators = append(decorators, func(s sinks.Sink) sinks.Sink {
+			return sinks.NewEventTypeFilterSink(s, eventTypes)
+		})
+	}
+	if len(t.config.WatchAddresses) > 0 {
+		decorators = append(decorators, func(s sinks.Sink) sinks.Sink {
+			return sinks.NewAddressFilterSink(s, t.config.WatchAddresses)
+		})
+	}
+	if t.config.DropZeroValue {
+		decorators = append(decorators, func(s sinks.Sink) sinks.Sink {
+			return sinks.NewZeroValueFilterSink(s)
+		})
+	}
+	if t.config.SampleRate < 1 {
+		decorators = append(decorators, func(s sinks.Sink) sinks.Sink {
+			return sinks.NewSamplingSink(s, t.config.SampleRate, t.config.SampleMode, t.config.MinValue)
+		})
+	}
+	if t.config.DedupeEnabled {
+		decorators = append(decorators, func(s sinks.Sink) sinks.Sink {
+			return sinks.NewDedupeSink(s, t.config.DedupeCacheSize)
+		})
+	}
+
+	if t.config.SinkInitOptional[name] {
+		t.sinkManager.AddOptionalSink(sinks.Chain(sink, decorators...))
+		return
+	}
+	t.sinkManager.AddSink(sinks.Chain(sink, decorators...))
+}
+
+// initializeSinks creates the configured sinks from the sink registry
 func (t *Tracker) initializeSinks(cfg *config.Config) {
+	opts := sinks.Options{
+		USDCAddress:   cfg.USDCAddress,
+		ReceiptFields: cfg.ReceiptFields,
+		MaxPending:    cfg.SinkMaxPending,
+		Location:      cfg.RotationTimezone,
+		ChainID:       cfg.ChainID,
+	}
+
 	for _, sinkName := range cfg.Sink {
-		switch sinkName {
-		case "console":
-			t.sinkManager.AddSink(console.New(cfg.USDCAddress))
-		case "sql":
-			// TODO: Add SQL sink implementation
-			t.logger.Warn("SQL sink not yet implemented", map[string]interface{}{"sink": "sql"})
-		case "mongodb":
-			// TODO: Add MongoDB sink implementation
-			t.logger.Warn("MongoDB sink not yet implemented", map[string]interface{}{"sink": "mongodb"})
-		case "kafka":
-			// TODO: Add Kafka sink implementation
-			t.logger.Warn("Kafka sink not yet implemented", map[string]interface{}{"sink": "kafka"})
-		case "elasticsearch":
-			esConfig := elasticsearch.NewConfig()
-			t.sinkManager.AddSink(elasticsearch.New(esConfig))
-		case "filesystem":
-			// Configure filesystem sink from environment
-			fsConfig := fs.Config{
-				OutputDir:  os.Getenv("FS_OUTPUT_DIR"),
-				FilePrefix: os.Getenv("FS_FILE_PREFIX"),
-				MaxEvents:  0, // Could be made configurable
-			}
-			
-			// Set format from environment
-			format := os.Getenv("FS_FORMAT")
-			switch format {
-			case "csv":
-				fsConfig.Format = fs.FormatCSV
-			case "text":
-				fsConfig.Format = fs.FormatText
-			case "jsonl":
-				fsConfig.Format = fs.FormatJSONL
-			default:
-				fsConfig.Format = fs.FormatJSON
-			}
-			
-			t.sinkManager.AddSink(fs.New(fsConfig))
+		sink, err := sinks.Create(sinkName, opts)
+		if err != nil {
+			t.logger.Warn("Sink not available, skipping", map[string]interface{}{
+				"sink":  sinkName,
+				"error": err.Error(),
+			})
+			continue
 		}
+		t.addSink(sinkName, sink)
 	}
 }
 
-// Start begins tracking blockchain events
-func (t *Tracker) Start(ctx context.Context) error {
+// Start begins tracking blockchain events until ctx is canceled or, after finishing
+// the blocks in flight, Drain is called. When it returns, sinks have been closed;
+// if they did not close within SHUTDOWN_TIMEOUT the error wraps sinks.ErrCloseTimeout.
+func (t *Tracker) Start(ctx context.Context) (err error) {
+	started := time.Now()
+	defer func() { t.logRunSummary(started, err) }()
+
 	if err := t.printConnectionInfo(ctx); err != nil {
 		return fmt.Errorf("failed to get connection info: %w", err)
 	}
+
+	if err := t.validateContracts(ctx); err != nil {
+		return err
+	}
+
+	if err := t.checkFollowMode(); err != nil {
+		return err
+	}
 	
+	deadLetterUsed := t.config.SinkWriteTimeout > 0 ||
+		(t.config.SinkBufferSize > 0 && t.config.SinkBufferPolicy == sinks.BufferDeadLetter)
+	var deadLetter *sinks.DeadLetterFile
+	if deadLetterUsed && t.config.DeadLetterFile != "" {
+		var err error
+		if deadLetter, err = sinks.OpenDeadLetterFile(t.config.DeadLetterFile); err != nil {
+			return err
+		}
+	}
+	if t.config.SinkWriteTimeout > 0 {
+		t.sinkManager.SetWriteTimeout(t.config.SinkWriteTimeout, deadLetter)
+	}
+	if t.config.SinkBufferSize > 0 {
+		t.sinkManager.SetBuffer(t.config.SinkBufferSize, t.config.SinkBufferPolicy, deadLetter)
+	}
+
 	// Initialize all sinks
-	if err := t.sinkManager.Initialize(); err != nil {
+	if err := t.sinkManager.Initialize(ctx); err != nil {
 		return fmt.Errorf("failed to initialize sinks: %w", err)
 	}
+	t.sinksReady.Store(true)
 	
 	// Print active sinks
 	t.printActiveSinks()
 	
-	defer t.sinkManager.Close()
+	defer func() {
+		if closeErr := t.sinkManager.CloseTimeout(t.config.ShutdownTimeout); errors.Is(closeErr, sinks.ErrCloseTimeout) {
+			err = fmt.Errorf("sinks did not close within %s: %w", t.config.ShutdownTimeout, closeErr)
+		}
+	}()
+
+	if err := t.replayWAL(ctx); err != nil {
+		return err
+	}
+	defer t.wal.close()
 	
+	if t.config.UseLogSubscription {
+		return t.followLogs(ctx)
+	}
 	return t.monitorBlocks(ctx)
 }
 
-// printConnectionInfo displays network connection details
+// checkFollowMode logs how the tracker follows the chain and returns an error if
+// that needs subscriptions but the client, e.g. over HTTP, cannot subscribe.
+// Config already requires WebSocket endpoints for the subscription modes; this
+// catches clients it did not create and fails before any sink is opened.
+func (t *Tracker) checkFollowMode() error {
+	mode, setting := "polling", ""
+	switch {
+	case t.config.UseLogSubscription:
+		mode, setting = "log_subscription", "USE_LOG_SUBSCRIPTION"
+	case t.config.HeadSubscription:
+		mode, setting = "head_subscription", "HEAD_SUBSCRIPTION"
+	}
+
+	subscriptions := supportsSubscriptions(t.client)
+	if setting != "" && !subscriptions {
+		return fmt.Errorf("%s requires a WebSocket RPC endpoint, the connected endpoint does not support subscriptions", setting)
+	}
+
+	transport := "http"
+	if subscriptions {
+		transport = "websocket"
+	}
+	t.logger.Info("Chain follow mode selected", map[string]interface{}{
+		"mode":      mode,
+		"transport": transport,
+	})
+	return nil
+}
+
+// printConnectionInfo displays network connection details and verifies that the
+// RPC endpoint is on the configured network
 func (t *Tracker) printConnectionInfo(ctx context.Context) error {
-	chainID, err := t.client.NetworkID(ctx)
+	chainID, err := t.client.ChainID(ctx)
 	if err != nil {
-		t.logger.Error("Failed to get network ID", err)
-		return fmt.Errorf("failed to get network ID: %w", err)
+		t.logger.Error("Failed to get chain ID", err)
+		return fmt.Errorf("failed to get chain ID: %w", err)
+	}
+
+	t.logger.LogConnection(t.config.Network, chainID.String(), t.config.USDCAddress, config.RedactURL(t.config.WebhookURL))
+
+	return t.verifyChainID(chainID)
+}
+
+// verifyChainID returns an error if the live chain ID does not match the configured network
+func (t *Tracker) verifyChainID(chainID *big.Int) error {
+	if t.config.ChainID == 0 {
+		return nil
+	}
+
+	if !chainID.IsUint64() || chainID.Uint64() != t.config.ChainID {
+		t.logger.Error("Chain ID mismatch", nil, map[string]interface{}{
+			"network":           t.config.Network,
+			"expected_chain_id": t.config.ChainID,
+			"chain_id":          chainID.String(),
+		})
+		return fmt.Errorf("RPC endpoint reports chain ID %s but network %q expects %d; check WEBHOOK_URL and NETWORK",
+			chainID.String(), t.config.Network, t.config.ChainID)
 	}
 
-	t.logger.LogConnection(t.config.Network, chainID.String(), t.config.USDCAddress, t.config.WebhookURL)
+	return nil
+}
+
+// validateContracts verifies that every tracked contract has code on the connected chain.
+// An empty result usually means the RPC endpoint belongs to a different network than configured.
+func (t *Tracker) validateContracts(ctx context.Context) error {
+	for _, address := range t.trackedContracts() {
+		code, err := t.client.CodeAt(ctx, common.HexToAddress(address), nil)
+		if err != nil {
+			t.logger.Error("Failed to get contract code", err, map[string]interface{}{
+				"contract_address": address,
+			})
+			return fmt.Errorf("failed to get code for contract %s: %w", address, err)
+		}
+
+		if len(code) == 0 {
+			return fmt.Errorf("no contract code found at %s on network %q; check WEBHOOK_URL and NETWORK",
+				address, t.config.Network)
+		}
+	}
 
 	return nil
 }
 
+// trackedContracts returns the contract addresses the tracker filters logs for
+func (t *Tracker) trackedContracts() []string {
+	addresses := make([]string, 0, len(t.config.USDCContracts))
+	for _, contract := range t.config.USDCContracts {
+		addresses = append(addresses, contract.Address)
+	}
+	return addresses
+}
+
 // printActiveSinks displays configured sinks
 func (t *Tracker) printActiveSinks() {
-	t.logger.Info("Active sinks initialized", map[string]interface{}{
+	fields := map[string]interface{}{
 		"sink_count": len(t.config.Sink),
 		"sinks":      t.config.Sink,
-	})
-}
-
-// monitorBlocks continuously monitors new blocks
-func (t *Tracker) monitorBlocks(ctx context.Context) error {
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			if err := t.processCurrentBlock(ctx); err != nil {
-				t.logger.Error("Error processing block", err)
-			}
-			time.Sleep(t.blockInterval)
-		}
 	}
+	if skipped := t.sinkManager.Skipped(); len(skipped) > 0 {
+		fields["sink_count"] = len(t.config.Sink) - len(skipped)
+		fields["skipped_sinks"] = skipped
+	}
+	t.logger.Info("Active sinks initialized", fields)
 }
 
-// processCurrentBlock processes the latest block for USDC events
-func (t *Tracker) processCurrentBlock(ctx context.Context) error {
+// latestBlockNumber returns the current chain head
+func (t *Tracker) latestBlockNumber(ctx context.Context) (uint64, error) {
+	if err := t.limiter.Wait(ctx); err != nil {
+		return 0, err
+	}
+	start := time.Now()
 	blockNumber, err := t.client.BlockNumber(ctx)
+	t.observeRPC(err)
+	t.rpcMetrics.Observe("eth_blockNumber", start, err)
 	if err != nil {
 		t.logger.Error("Failed to get block number", err)
-		return fmt.Errorf("failed to get block number: %w", err)
+		return 0, fmt.Errorf("failed to get block number: %w", err)
 	}
+	t.observeHead(blockNumber)
+	return blockNumber, nil
+}
 
-	receipts, err := tx.GetAllTransactionInBlock(t.client, ctx, blockNumber)
+// fetchBlock retrieves a block's receipts and converts its USDC activity to sink
+// events. With REORG_WINDOW, the result also carries the block's hash and parent
+// hash, which the in-order writer uses to detect reorgs (see checkReorg).
+func (t *Tracker) fetchBlock(ctx context.Context, blockNumber uint64) (blockResult, error) {
+	if t.config.ReorgWindow == 0 {
+		return t.fetchBlockEvents(ctx, blockNumber)
+	}
+
+	header, err := t.blockHeader(ctx, blockNumber)
+	if err != nil {
+		return blockResult{}, err
+	}
+	result, err := t.fetchBlockEvents(ctx, blockNumber)
+	if err != nil {
+		return blockResult{}, err
+	}
+	// Receipts from another block than the header mean the block was replaced in between
+	for _, event := range result.events {
+		if event.Receipt.BlockHash != (common.Hash{}) && event.Receipt.BlockHash != header.Hash() {
+			return blockResult{}, fmt.Errorf("block %d changed while it was fetched, hash %s then %s",
+				blockNumber, header.Hash().Hex(), event.Receipt.BlockHash.Hex())
+		}
+	}
+
+	result.hash = header.Hash()
+	result.parentHash = header.ParentHash
+	return result, nil
+}
+
+// fetchBlockEvents retrieves a block's receipts and converts its USDC activity to sink events
+func (t *Tracker) fetchBlockEvents(ctx context.Context, blockNumber uint64) (blockResult, error) {
+	if err := t.limiter.Wait(ctx); err != nil {
+		return blockResult{}, err
+	}
+	receipts, err := t.receipts.BlockReceipts(ctx, blockNumber)
+	t.observeRPC(err)
+	if errors.Is(err, tx.ErrReceiptsUnavailable) {
+		// The node answered but has no receipts for this block yet; retried by the worker
+		t.logger.Warn("Receipts not available for block", map[string]interface{}{
+			"block_number": blockNumber,
+		})
+		return blockResult{}, err
+	}
 	if err != nil {
 		t.logger.Error("Failed to get receipts for block", err, map[string]interface{}{
 			"block_number": blockNumber,
 		})
-		return fmt.Errorf("failed to get receipts for block %d: %w", blockNumber, err)
+		return blockResult{}, fmt.Errorf("failed to get receipts for block %d: %w", blockNumber, err)
 	}
 
 	t.logger.LogBlockProcessing(blockNumber, len(receipts))
 
 	if len(receipts) == 0 {
-		return nil
+		t.logger.Info("Block has no transactions, skipping sink write", map[string]interface{}{
+			"block_number": blockNumber,
+		})
+		return t.emptyBlock(ctx, blockNumber, 0), nil
 	}
 
 	// Filter for USDC transactions
-	usdcTxs := usdc.MapUSDCTxs(receipts, t.config.USDCAddress)
-	
-	// Log USDC transactions found
-	if len(usdcTxs) > 0 {
-		t.logger.Info("USDC transactions found", map[string]interface{}{
-			"block_number":    blockNumber,
-			"total_txs":       len(receipts),
-			"usdc_txs":        len(usdcTxs),
-			"usdc_percentage": float64(len(usdcTxs)) / float64(len(receipts)) * 100,
-		})
+	usdcTxs := usdc.FilterByAddresses(receipts, t.trackedContracts())
+
+	// Blocks without USDC activity are not written, so sinks only see blocks with events
+	if len(usdcTxs) == 0 {
+		return t.emptyBlock(ctx, blockNumber, len(receipts)), nil
 	}
-	
+
+	t.logger.Info("USDC transactions found", map[string]interface{}{
+		"block_number":    blockNumber,
+		"total_txs":       len(receipts),
+		"usdc_txs":        len(usdcTxs),
+		"usdc_percentage": float64(len(usdcTxs)) / float64(len(receipts)) * 100,
+	})
+
+	// Only pay for the header lookup when there is something to timestamp
+	blockTime, err := t.blockTime(ctx, blockNumber)
+	if err != nil {
+		return blockResult{}, err
+	}
+
 	// Convert to sink events
-	events := t.convertToEvents(usdcTxs, blockNumber)
-	
-	// Send to all configured sinks
+	return blockResult{
+		blockNumber: blockNumber,
+		events:      t.convertToEvents(usdcTxs, blockNumber, blockTime),
+	}, nil
+}
+
+// emptyBlock returns the result for a block without USDC activity. With
+// EMIT_EMPTY_BLOCKS it carries what the block's heartbeat needs; a block time
+// that cannot be fetched falls back to the ingestion time.
+func (t *Tracker) emptyBlock(ctx context.Context, blockNumber uint64, txCount int) blockResult {
+	result := blockResult{blockNumber: blockNumber, empty: true}
+	if !t.config.EmitEmptyBlocks {
+		return result
+	}
+
+	result.txCount = txCount
+	if blockTime, err := t.blockTime(ctx, blockNumber); err == nil {
+		result.blockTime = blockTime
+	} else {
+		result.blockTime = time.Now()
+	}
+	return result
+}
+
+// blockTime returns the block's timestamp in UTC, fetching its header unless cached
+func (t *Tracker) blockTime(ctx context.Context, blockNumber uint64) (time.Time, error) {
+	if cached, ok := t.blockTimes.get(blockNumber); ok {
+		return cached, nil
+	}
+
+	header, err := t.blockHeader(ctx, blockNumber)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(header.Time), 0).UTC(), nil
+}
+
+// blockHeader fetches the canonical header of blockNumber and caches its timestamp
+func (t *Tracker) blockHeader(ctx context.Context, blockNumber uint64) (*types.Header, error) {
+	if err := t.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
 	start := time.Now()
-	if err := t.sinkManager.Write(ctx, events); err != nil {
-		t.logger.Error("Failed to write to sinks", err, map[string]interface{}{
+	header, err := t.client.HeaderByNumber(ctx, new(big.Int).SetUint64(blockNumber))
+	t.observeRPC(err)
+	t.rpcMetrics.Observe("eth_getBlockByNumber", start, err)
+	if err != nil {
+		t.logger.Error("Failed to get block header", err, map[string]interface{}{
 			"block_number": blockNumber,
-			"event_count":  len(events),
+		})
+		return nil, fmt.Errorf("failed to get header for block %d: %w", blockNumber, err)
+	}
+
+	t.blockTimes.add(blockNumber, time.Unix(int64(header.Time), 0).UTC())
+	return header, nil
+}
+
+// writeBlock sends a block's events to all configured sinks
+func (t *Tracker) writeBlock(ctx context.Context, result blockResult) error {
+	if result.empty {
+		if t.config.EmitEmptyBlocks {
+			t.sinkManager.WriteHeartbeat(ctx, sinks.BlockHeartbeat{
+				BlockNumber: result.blockNumber,
+				Timestamp:   result.blockTime.UTC().Format(time.RFC3339Nano),
+				TxCount:     result.txCount,
+			})
+		}
+		t.sinkManager.WriteBalances(ctx, result.balances)
+		t.recordProgress(result.blockNumber)
+		return nil
+	}
+
+	if err := t.wal.append(result.blockNumber, result.events); err != nil {
+		return fmt.Errorf("failed to record block in write-ahead log: %w", err)
+	}
+
+	start := time.Now()
+	if err := t.sinkManager.Write(ctx, result.events); err != nil {
+		t.logger.Error("Failed to write to sinks", err, map[string]interface{}{
+			"block_number": result.blockNumber,
+			"event_count":  len(result.events),
 		})
 		return fmt.Errorf("failed to write to sinks: %w", err)
 	}
-	
+	t.eventsEmitted.Add(uint64(len(result.events)))
+	t.sinkManager.WriteBalances(ctx, result.balances)
+
 	t.logger.Info("Sink write completed", map[string]interface{}{
-		"block_number": blockNumber,
-		"event_count":  len(events),
+		"block_number": result.blockNumber,
+		"event_count":  len(result.events),
 		"duration_ms":  time.Since(start).Milliseconds(),
 	})
 
+	t.recordProgress(result.blockNumber)
 	return nil
 }
 
-// convertToEvents converts receipts to sink events
-func (t *Tracker) convertToEvents(receipts []*types.Receipt, blockNumber uint64) []sinks.Event {
+// recordProgress counts a block as processed
+func (t *Tracker) recordProgress(blockNumber uint64) {
+	t.blocksProcessed.Add(1)
+	t.lastBlock.Store(blockNumber)
+}
+
+// DumpStats logs the tracker's progress and the current stats of every sink.
+// It is safe to call from any goroutine while the tracker is running; sink stats
+// are left out until the sinks have been initialized.
+func (t *Tracker) DumpStats() {
+	fields := map[string]interface{}{
+		"event_type":       "tracker_stats",
+		"blocks_processed": t.blocksProcessed.Load(),
+		"events_emitted":   t.eventsEmitted.Load(),
+		"last_block":       t.lastBlock.Load(),
+		"removed_logs":     t.removedLogs.Load(),
+		"caught_up":        t.caughtUp.Load(),
+	}
+	for key, value := range t.blockTimes.stats() {
+		fields[key] = value
+	}
+	if t.config.ReorgWindow > 0 {
+		for key, value := range t.reorgs.stats() {
+			fields[key] = value
+		}
+	}
+	t.logger.Info("Tracker statistics", fields)
+
+	if t.limiter != nil {
+		t.logger.LogRateLimit("RPC rate limiter status", t.limiter.Stats())
+	}
+	t.logger.Info("RPC call statistics", t.rpcStatsFields())
+
+	// The manager's sink list is still being set up until Initialize returns
+	if !t.sinksReady.Load() {
+		return
+	}
+	for name, stats := range t.sinkManager.CollectStats() {
+		t.logger.LogSinkStats(name, stats)
+	}
+}
+
+// logRunSummary logs what the run did once Start returns: blocks processed,
+// events written, the final stats of every sink and how long it ran
+func (t *Tracker) logRunSummary(started time.Time, err error) {
+	duration := time.Since(started)
+	fields := map[string]interface{}{
+		"event_type":       "run_summary",
+		"blocks_processed": t.blocksProcessed.Load(),
+		"events_emitted":   t.eventsEmitted.Load(),
+		"last_block":       t.lastBlock.Load(),
+		"duration":         duration.Round(time.Millisecond).String(),
+		"duration_ms":      duration.Milliseconds(),
+	}
+	if t.sinksReady.Load() {
+		fields["sinks"] = t.sinkManager.CollectStats()
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+	t.logger.Info("Tracker run summary", fields)
+}
+
+// observeHead records a chain head, ignoring heads older than one already seen
+func (t *Tracker) observeHead(head uint64) {
+	for {
+		seen := t.head.Load()
+		if head <= seen || t.head.CompareAndSwap(seen, head) {
+			return
+		}
+	}
+}
+
+// observeRPC feeds an RPC result to the rate limiter and reports throttling
+func (t *Tracker) observeRPC(err error) {
+	if t.limiter.Observe(err) {
+		t.logger.LogRateLimit("RPC provider rate limit hit, backing off", t.limiter.Stats())
+	}
+}
+
+// logRateLimitStats periodically reports the rate limiter state
+func (t *Tracker) logRateLimitStats() {
+	if t.limiter == nil || time.Since(t.lastRateLimitStats) < time.Minute {
+		return
+	}
+	t.lastRateLimitStats = time.Now()
+	t.logger.LogRateLimit("RPC rate limiter status", t.limiter.Stats())
+}
+
+// logRPCStats periodically reports RPC call latency and errors by method
+func (t *Tracker) logRPCStats() {
+	if time.Since(t.lastRPCStats) < time.Minute {
+		return
+	}
+	t.lastRPCStats = time.Now()
+	t.logger.Info("RPC call statistics", t.rpcStatsFields())
+}
+
+// rpcStatsFields returns the RPC metrics as log fields
+func (t *Tracker) rpcStatsFields() map[string]interface{} {
+	fields := map[string]interface{}{
+		"event_type": "rpc_stats",
+	}
+	for key, value := range t.rpcMetrics.Stats() {
+		fields[key] = value
+	}
+	return fields
+}
+
+// logSinkStats periodically reports throughput and pending counts of every sink
+func (t *Tracker) logSinkStats() {
+	if time.Since(t.lastSinkStats) < time.Minute {
+		return
+	}
+	t.lastSinkStats = time.Now()
+
+	for name, stats := range t.sinkManager.CollectStats() {
+		t.logger.LogSinkStats(name, stats)
+	}
+}
+
+// convertToEvents converts receipts to sink events.
+// A receipt touching several tracked contracts yields one event per contract.
+func (t *Tracker) convertToEvents(receipts []*types.Receipt, blockNumber uint64, blockTime time.Time) []sinks.Event {
 	events := make([]sinks.Event, 0, len(receipts))
-	
+	ingestedAt := time.Now().UTC()
+
 	for _, receipt := range receipts {
-		// Filter logs for USDC address only
-		usdcLogs := make([]*types.Log, 0)
-		for _, log := range receipt.Logs {
-			if log.Address.Hex() == t.config.USDCAddress {
-				usdcLogs = append(usdcLogs, log)
+		if !t.config.IncludeFailedTx && receipt.Status == types.ReceiptStatusFailed {
+			continue
+		}
+
+		for _, contract := range t.config.USDCContracts {
+			// Filter logs for this contract address only
+			address := common.HexToAddress(contract.Address)
+			usdcLogs := make([]*types.Log, 0)
+			for _, log := range receipt.Logs {
+				if log.Address == address && t.tracksTopic(log) {
+					usdcLogs = append(usdcLogs, log)
+				}
 			}
+
+			if len(usdcLogs) == 0 {
+				continue
+			}
+			t.alertUpgrades(usdcLogs)
+
+			// Decoded once here and shared by every sink
+			decoded := sinks.DecodeLogs(usdcLogs)
+			usdcLogs, suspect := t.checkValues(usdcLogs, decoded)
+			if len(usdcLogs) == 0 {
+				continue
+			}
+
+			events = append(events, sinks.Event{
+				BlockNumber:  blockNumber,
+				Receipt:      receipt,
+				Logs:         usdcLogs,
+				Variant:      contract.Variant,
+				BlockTime:    blockTime,
+				IngestedAt:   ingestedAt,
+				SuspectValue: suspect,
+				Decoded:      decoded,
+			})
 		}
-		
-		events = append(events, sinks.Event{
-			BlockNumber: blockNumber,
-			Receipt:     receipt,
-			Logs:        usdcLogs,
-		})
 	}
-	
+
 	return events
-}
\ No newline at end of file
+}
+
+// checkValues warns about Transfers whose value exceeds MAX_SANE_VALUE, which no
+// legitimate transfer approaches, so they most likely come from a decoding bug.
+// Depending on MAX_SANE_VALUE_ACTION, such logs are dropped or reported as suspect.
+func (t *Tracker) checkValues(logs []*types.Log, decodedLogs map[*types.Log]erc20.Decoded) ([]*types.Log, bool) {
+	if t.config.MaxSaneValue == nil {
+		return logs, false
+	}
+
+	kept := logs[:0:0]
+	suspect := false
+	for _, log := range logs {
+		decoded, found := decodedLogs[log]
+		if !found || decoded.Event != erc20.Transfer || decoded.Value == nil || decoded.Value.Cmp(t.config.MaxSaneValue) <= 0 {
+			kept = append(kept, log)
+			continue
+		}
+
+		t.logger.Warn("Transfer value exceeds MAX_SANE_VALUE, possible decoding bug", map[string]interface{}{
+			"tx_hash":        log.TxHash.Hex(),
+			"log_index":      log.Index,
+			"block_number":   log.BlockNumber,
+			"value":          decoded.Value.String(),
+			"max_sane_value": t.config.MaxSaneValue.String(),
+			"action":         t.config.MaxSaneValueAction,
+		})
+		switch t.config.MaxSaneValueAction {
+		case config.SaneValueDrop:
+			continue
+		case config.SaneValueFlag:
+			suspect = true
+		}
+		kept = append(kept, log)
+	}
+	return kept, suspect
+}
+
+// tracksTopic reports whether log's event signature is selected by TRACK_TOPICS
+func (t *Tracker) tracksTopic(log *types.Log) bool {
+	if len(t.config.TrackTopics) == 0 {
+		return true
+	}
+	if len(log.Topics) == 0 {
+		return false
+	}
+	for _, topic := range t.config.TrackTopics {
+		if log.Topics[0] == topic {
+			return true
+		}
+	}
+	return false
+}
+
+// alertUpgrades raises an alert for every Upgraded log, i.e. every time a tracked
+// proxy contract switches to a new implementation
+func (t *Tracker) alertUpgrades(logs []*types.Log) {
+	upgraded := common.HexToHash(erc20.EventSignatures[erc20.Upgraded])
+	for _, log := range logs {
+		if len(log.Topics) == 0 || log.Topics[0] != upgraded {
+			continue
+		}
+		implementation, _ := erc20.DecodeImplementation(log.Topics, log.Data)
+		t.logger.LogContractUpgrade(log.Address.Hex(), implementation, log.TxHash.Hex(), log.BlockNumber)
+	}
+}
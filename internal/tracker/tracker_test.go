@@ -0,0 +1,292 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"usdc-event-tracker/internal/config"
+	"usdc-event-tracker/internal/erc20"
+	"usdc-event-tracker/internal/sinks"
+	"usdc-event-tracker/internal/tx"
+)
+
+// errNotSupported is returned by fakeChain for calls it does not serve
+var errNotSupported = errors.New("not supported by fake chain")
+
+// fakeChain serves a fixed chain head and per-block receipts. A block mapped to
+// nil receipts is answered with null, and a block in errs with that error.
+// Headers are chained by parent hash; blocks from reorgFrom on, if set, belong
+// to a fork with different hashes.
+type fakeChain struct {
+	head      uint64
+	receipts  map[uint64][]*types.Receipt
+	errs      map[uint64]error
+	reorgFrom uint64
+}
+
+// header returns the canonical header of blockNumber
+func (c *fakeChain) header(blockNumber uint64) *types.Header {
+	var parent common.Hash
+	for n := uint64(0); ; n++ {
+		header := &types.Header{
+			Number:     new(big.Int).SetUint64(n),
+			ParentHash: parent,
+			Time:       1_700_000_000 + n*12,
+		}
+		if c.reorgFrom > 0 && n >= c.reorgFrom {
+			header.Extra = []byte("fork")
+		}
+		if n == blockNumber {
+			return header
+		}
+		parent = header.Hash()
+	}
+}
+
+func (c *fakeChain) ChainID(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(1), nil
+}
+
+func (c *fakeChain) BlockNumber(ctx context.Context) (uint64, error) {
+	return c.head, nil
+}
+
+func (c *fakeChain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return c.header(number.Uint64()), nil
+}
+
+func (c *fakeChain) BlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error) {
+	blockNumber, _ := blockNrOrHash.Number()
+	if err, ok := c.errs[uint64(blockNumber)]; ok {
+		return nil, err
+	}
+	if receipts, ok := c.receipts[uint64(blockNumber)]; ok {
+		return receipts, nil
+	}
+	return []*types.Receipt{}, nil
+}
+
+func (c *fakeChain) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return []byte{0x60, 0x80}, nil
+}
+
+func (c *fakeChain) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return nil, errNotSupported
+}
+
+func (c *fakeChain) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	return nil, errNotSupported
+}
+
+func (c *fakeChain) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return nil, errNotSupported
+}
+
+func (c *fakeChain) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	return nil, errNotSupported
+}
+
+func (c *fakeChain) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return nil, errNotSupported
+}
+
+func (c *fakeChain) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return nil, errNotSupported
+}
+
+// transferReceipt returns a successful receipt with a single Transfer log of token
+func transferReceipt(token common.Address, blockNumber uint64, txIndex uint, from, to common.Address, value int64) *types.Receipt {
+	txHash := common.BigToHash(big.NewInt(int64(blockNumber)<<16 | int64(txIndex)))
+	return &types.Receipt{
+		Status:           types.ReceiptStatusSuccessful,
+		TxHash:           txHash,
+		BlockNumber:      new(big.Int).SetUint64(blockNumber),
+		TransactionIndex: txIndex,
+		Logs: []*types.Log{{
+			Address: token,
+			Topics: []common.Hash{
+				common.HexToHash(erc20.EventSignatures[erc20.Transfer]),
+				common.BytesToHash(from.Bytes()),
+				common.BytesToHash(to.Bytes()),
+			},
+			Data:        common.BigToHash(big.NewInt(value)).Bytes(),
+			BlockNumber: blockNumber,
+			TxHash:      txHash,
+			TxIndex:     txIndex,
+			Index:       txIndex,
+		}},
+	}
+}
+
+func TestTrackerWritesTransfersToSinks(t *testing.T) {
+	token := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	alice := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	bob := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	chain := &fakeChain{
+		head: 100,
+		receipts: map[uint64][]*types.Receipt{
+			100: {
+				transferReceipt(token, 100, 0, alice, bob, 1_500_000),
+				transferReceipt(token, 100, 1, bob, alice, 250_000),
+			},
+		},
+	}
+	cfg := &config.Config{
+		USDCAddress:   token.Hex(),
+		USDCContracts: []config.USDCContract{{Address: token.Hex(), Variant: "native"}},
+		BlockInterval: 10 * time.Millisecond,
+		PollInterval:  10 * time.Millisecond,
+		SampleRate:    1,
+	}
+
+	tr := New(chain, cfg, nil)
+	memory := sinks.NewMemorySink()
+	tr.addSink("memory", memory)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- tr.Start(ctx) }()
+
+	for memory.Count() < 2 && ctx.Err() == nil {
+		time.Sleep(10 * time.Millisecond)
+	}
+	tr.Drain()
+	if err := <-done; err != nil {
+		t.Fatalf("Start = %v, want nil after Drain", err)
+	}
+
+	events := memory.Events()
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	want := []struct {
+		from, to common.Address
+		value    int64
+	}{
+		{alice, bob, 1_500_000},
+		{bob, alice, 250_000},
+	}
+	for i, event := range events {
+		if event.BlockNumber != 100 || event.Variant != "native" || len(event.Logs) != 1 {
+			t.Fatalf("event %d = block %d, variant %q, %d logs; want block 100, native, 1 log",
+				i, event.BlockNumber, event.Variant, len(event.Logs))
+		}
+		decoded, ok := event.Decode(event.Logs[0])
+		if !ok || decoded.Event != erc20.Transfer {
+			t.Fatalf("event %d decoded as %v, %v; want a Transfer", i, decoded.Event, ok)
+		}
+		if decoded.From != want[i].from.Hex() || decoded.To != want[i].to.Hex() || decoded.Value.Int64() != want[i].value {
+			t.Errorf("event %d = %s -> %s %v, want %s -> %s %d", i,
+				decoded.From, decoded.To, decoded.Value, want[i].from.Hex(), want[i].to.Hex(), want[i].value)
+		}
+		if got := event.Timestamp(); !got.Equal(time.Unix(1_700_001_200, 0)) {
+			t.Errorf("event %d timestamp = %v, want the block header time", i, got)
+		}
+	}
+}
+
+func TestFetchBlockEmptyVersusUnavailable(t *testing.T) {
+	token := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	other := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	errTimeout := errors.New("i/o timeout")
+
+	chain := &fakeChain{
+		head: 100,
+		receipts: map[uint64][]*types.Receipt{
+			10: {},
+			11: nil,
+			12: {transferReceipt(other, 12, 0, common.Address{}, common.Address{}, 1)},
+		},
+		errs: map[uint64]error{
+			13: ethereum.NotFound,
+			14: errTimeout,
+		},
+	}
+	cfg := &config.Config{
+		USDCContracts: []config.USDCContract{{Address: token.Hex(), Variant: "native"}},
+	}
+	tr := New(chain, cfg, nil)
+	ctx := context.Background()
+
+	for _, block := range []uint64{10, 12} {
+		result, err := tr.fetchBlock(ctx, block)
+		if err != nil || !result.empty {
+			t.Errorf("fetchBlock(%d) = empty %v, %v; want an empty block", block, result.empty, err)
+		}
+	}
+	for _, block := range []uint64{11, 13} {
+		if _, err := tr.fetchBlock(ctx, block); !errors.Is(err, tx.ErrReceiptsUnavailable) {
+			t.Errorf("fetchBlock(%d) = %v, want %v", block, err, tx.ErrReceiptsUnavailable)
+		}
+	}
+	_, err := tr.fetchBlock(ctx, 14)
+	if !errors.Is(err, errTimeout) || errors.Is(err, tx.ErrReceiptsUnavailable) {
+		t.Errorf("fetchBlock(14) = %v, want the RPC error", err)
+	}
+}
+
+// fetchCountingChain records the highest block whose receipts were requested
+type fetchCountingChain struct {
+	*fakeChain
+	highest atomic.Uint64
+}
+
+func (c *fetchCountingChain) BlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error) {
+	blockNumber, _ := blockNrOrHash.Number()
+	for {
+		highest := c.highest.Load()
+		if uint64(blockNumber) <= highest || c.highest.CompareAndSwap(highest, uint64(blockNumber)) {
+			break
+		}
+	}
+	return c.fakeChain.BlockReceipts(ctx, blockNrOrHash)
+}
+
+func TestFailingBlockBoundsBlocksFetchedAhead(t *testing.T) {
+	const workers = 4
+	chain := &fetchCountingChain{fakeChain: &fakeChain{
+		head: 10_000,
+		errs: map[uint64]error{5: errors.New("i/o timeout")},
+	}}
+	cfg := &config.Config{
+		USDCContracts: []config.USDCContract{{Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", Variant: "native"}},
+		BlockWorkers:  workers,
+		BlockInterval: 5 * time.Millisecond,
+		PollInterval:  10 * time.Millisecond,
+		CursorFile:    filepath.Join(t.TempDir(), "cursor"),
+		SampleRate:    1,
+	}
+	if err := saveCursor(cfg.CursorFile, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	tr := New(chain, cfg, nil)
+	tr.addSink("memory", sinks.NewMemorySink())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- tr.Start(ctx) }()
+
+	// Block 5 keeps failing while the other workers would run ahead to the head
+	time.Sleep(300 * time.Millisecond)
+	cancel()
+	<-done
+
+	if got := tr.lastBlock.Load(); got != 4 {
+		t.Errorf("last block written = %d, want 4, the block before the failing one", got)
+	}
+	if limit := uint64(5 + workers*pendingBlocksPerWorker); chain.highest.Load() > limit {
+		t.Errorf("fetched up to block %d while block 5 failed, want at most %d", chain.highest.Load(), limit)
+	}
+}
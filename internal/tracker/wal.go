@@ -0,0 +1,347 @@
+package tracker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"usdc-event-tracker/internal/sinks"
+)
+
+// walFileName is the write-ahead log file inside WAL_DIR
+const walFileName = "blocks.wal"
+
+// walCompactSize is the file size above which committed records are dropped
+// from the write-ahead log, while later blocks are still uncommitted
+const walCompactSize = 64 << 20
+
+// Write-ahead log record types
+const (
+	walBlock  = "block"  // A block's events, appended before they are written to the sinks
+	walCommit = "commit" // Every block up to and including BlockNumber is persisted by all sinks
+)
+
+// walRecord is a single line of the write-ahead log
+type walRecord struct {
+	Type        string     `json:"type"`
+	BlockNumber uint64     `json:"block_number"`
+	Events      []walEvent `json:"events,omitempty"`
+}
+
+// walEvent is a sinks.Event as stored in the write-ahead log. Decoded values
+// are not stored; they are decoded again on replay.
+type walEvent struct {
+	Receipt      *types.Receipt `json:"receipt"`
+	Logs         []*types.Log   `json:"logs"`
+	Variant      string         `json:"variant,omitempty"`
+	BlockTime    time.Time      `json:"block_time"`
+	IngestedAt   time.Time      `json:"ingested_at"`
+	SuspectValue bool           `json:"suspect_value,omitempty"`
+}
+
+// writeAheadLog records each block's events before they are written to the sinks
+// and marks them committed once every sink has durably persisted them, so blocks
+// lost to a crash in between are replayed on the next start. A nil
+// *writeAheadLog records nothing. It is only used by the in-order writer.
+type writeAheadLog struct {
+	path         string
+	file         *os.File
+	size         int64
+	lastAppended uint64 // Highest block appended
+	committed    uint64 // Highest block committed
+}
+
+// openWAL opens the write-ahead log in dir, creating it if needed, and returns
+// the blocks it holds that were never committed, in ascending block order
+func openWAL(dir string) (*writeAheadLog, []walRecord, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create WAL directory %s: %w", dir, err)
+	}
+	path := filepath.Join(dir, walFileName)
+
+	pending, committed, valid, err := readWAL(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Drop a torn last line, so later records are appended after a complete one
+	if err := os.Truncate(path, valid); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, fmt.Errorf("failed to truncate WAL file: %w", err)
+	}
+
+	w := &writeAheadLog{path: path, committed: committed}
+	if len(pending) > 0 {
+		w.lastAppended = pending[len(pending)-1].BlockNumber
+	}
+	if err := w.open(); err != nil {
+		return nil, nil, err
+	}
+	return w, pending, nil
+}
+
+// readWAL returns the uncommitted blocks in the write-ahead log at path, keeping
+// the latest record of blocks appended more than once, the highest committed
+// block and the size of the complete lines. A torn last line, left by a crash
+// while appending, is ignored: its block was never written to the sinks.
+func readWAL(path string) ([]walRecord, uint64, int64, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, 0, nil
+	}
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to open WAL file: %w", err)
+	}
+	defer file.Close()
+
+	blocks := make(map[uint64]walRecord)
+	var committed uint64
+	var valid int64
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("failed to read WAL file: %w", err)
+		}
+		valid += int64(len(line))
+
+		var record walRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, 0, 0, fmt.Errorf("invalid WAL file %s: %w", path, err)
+		}
+		switch record.Type {
+		case walBlock:
+			blocks[record.BlockNumber] = record
+		case walCommit:
+			committed = max(committed, record.BlockNumber)
+		}
+	}
+
+	pending := make([]walRecord, 0, len(blocks))
+	for blockNumber, record := range blocks {
+		if blockNumber > committed {
+			pending = append(pending, record)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].BlockNumber < pending[j].BlockNumber })
+	return pending, committed, valid, nil
+}
+
+// open opens the log file for appending
+func (w *writeAheadLog) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open WAL file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat WAL file: %w", err)
+	}
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+// append durably records a block's events before they are written to the sinks
+func (w *writeAheadLog) append(blockNumber uint64, events []sinks.Event) error {
+	if w == nil {
+		return nil
+	}
+
+	stored := make([]walEvent, 0, len(events))
+	for _, event := range events {
+		stored = append(stored, walEvent{
+			Receipt:      event.Receipt,
+			Logs:         event.Logs,
+			Variant:      event.Variant,
+			BlockTime:    event.BlockTime,
+			IngestedAt:   event.IngestedAt,
+			SuspectValue: event.SuspectValue,
+		})
+	}
+	if err := w.write(walRecord{Type: walBlock, BlockNumber: blockNumber, Events: stored}); err != nil {
+		return err
+	}
+	w.lastAppended = max(w.lastAppended, blockNumber)
+	return nil
+}
+
+// commit marks every block up to and including blockNumber as persisted by all
+// sinks. Once nothing is left uncommitted the log is truncated; otherwise a
+// commit record is appended and the log compacted when it grows too large.
+func (w *writeAheadLog) commit(blockNumber uint64) error {
+	if w == nil || blockNumber <= w.committed {
+		return nil
+	}
+	w.committed = blockNumber
+
+	if blockNumber >= w.lastAppended {
+		if err := w.file.Truncate(0); err != nil {
+			return fmt.Errorf("failed to truncate WAL file: %w", err)
+		}
+		w.size = 0
+		return nil
+	}
+
+	if err := w.write(walRecord{Type: walCommit, BlockNumber: blockNumber}); err != nil {
+		return err
+	}
+	if w.size > walCompactSize {
+		return w.compact()
+	}
+	return nil
+}
+
+// write appends record as a line and syncs it to disk
+func (w *writeAheadLog) write(record walRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal WAL record: %w", err)
+	}
+	line = append(line, '\n')
+
+	if _, err := w.file.Write(line); err != nil {
+		return fmt.Errorf("failed to append to WAL file: %w", err)
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync WAL file: %w", err)
+	}
+	w.size += int64(len(line))
+	return nil
+}
+
+// compact atomically replaces the log with its uncommitted blocks
+func (w *writeAheadLog) compact() error {
+	pending, _, _, err := readWAL(w.path)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, record := range pending {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to marshal WAL record: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(w.path), walFileName+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create WAL file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write WAL file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync WAL file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close WAL file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), w.path); err != nil {
+		return fmt.Errorf("failed to replace WAL file: %w", err)
+	}
+
+	w.file.Close()
+	return w.open()
+}
+
+// close closes the log file
+func (w *writeAheadLog) close() error {
+	if w == nil {
+		return nil
+	}
+	return w.file.Close()
+}
+
+// events converts a block record back to sink events
+func (r walRecord) events() []sinks.Event {
+	events := make([]sinks.Event, 0, len(r.Events))
+	for _, stored := range r.Events {
+		events = append(events, sinks.Event{
+			BlockNumber:  r.BlockNumber,
+			Receipt:      stored.Receipt,
+			Logs:         stored.Logs,
+			Variant:      stored.Variant,
+			BlockTime:    stored.BlockTime,
+			IngestedAt:   stored.IngestedAt,
+			SuspectValue: stored.SuspectValue,
+			Decoded:      sinks.DecodeLogs(stored.Logs),
+		})
+	}
+	return events
+}
+
+// replayWAL opens the write-ahead log if WAL_ENABLED is set and replays the
+// blocks it holds that were never committed into the sinks, in block order.
+// Replayed blocks are checkpointed like any other, so the cursor resumes after
+// them. If a replayed block fails to write, the rest stay in the log and the
+// cursor is held, so they are replayed again on the next start.
+func (t *Tracker) replayWAL(ctx context.Context) error {
+	if t.config.WALDir == "" || t.config.DryRun {
+		return nil
+	}
+
+	wal, pending, err := openWAL(t.config.WALDir)
+	if err != nil {
+		return err
+	}
+	t.wal = wal
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	t.logger.Info("Replaying write-ahead log", map[string]interface{}{
+		"wal_dir":    t.config.WALDir,
+		"blocks":     len(pending),
+		"from_block": pending[0].BlockNumber,
+		"to_block":   pending[len(pending)-1].BlockNumber,
+	})
+
+	var replayed, events int
+	var failed *walRecord
+	for i, record := range pending {
+		blockEvents := record.events()
+		if err := t.sinkManager.Write(ctx, blockEvents); err != nil {
+			t.logger.Error("Failed to replay block from write-ahead log", err, map[string]interface{}{
+				"block_number": record.BlockNumber,
+			})
+			failed = &pending[i]
+			break
+		}
+		replayed++
+		events += len(blockEvents)
+	}
+	// Commit the blocks every sink persisted before holding the rest
+	if replayed > 0 {
+		t.checkpoint(pending[replayed-1].BlockNumber, true)
+	}
+	if failed != nil {
+		t.holdCursor(failed.BlockNumber)
+	}
+
+	t.logger.Info("Write-ahead log replayed", map[string]interface{}{
+		"blocks":       replayed,
+		"event_count":  events,
+		"failed_block": len(pending) > replayed,
+	})
+	return nil
+}
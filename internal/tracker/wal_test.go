@@ -0,0 +1,111 @@
+package tracker
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"usdc-event-tracker/internal/config"
+	"usdc-event-tracker/internal/sinks"
+)
+
+func TestWALReplaysBlocksAfterFailedWrite(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		CursorFile: filepath.Join(dir, "cursor"),
+		WALDir:     filepath.Join(dir, "wal"),
+	}
+	ctx := context.Background()
+
+	// First run: block 11 fails to write, so neither it nor block 12 is committed
+	failing := &failingSink{MemorySink: sinks.NewMemorySink(), failBlocks: map[uint64]bool{11: true}}
+	first := newTestTracker(t, cfg, failing)
+	first.head.Store(100)
+	if err := first.replayWAL(ctx); err != nil {
+		t.Fatalf("replayWAL: %v", err)
+	}
+	writeBlocks(first, 10, 11, 12)
+	first.wal.close()
+
+	pending, committed, _, err := readWAL(filepath.Join(cfg.WALDir, walFileName))
+	if err != nil {
+		t.Fatalf("readWAL: %v", err)
+	}
+	if committed > 10 {
+		t.Errorf("committed = %d, want at most 10", committed)
+	}
+	if len(pending) != 2 || pending[0].BlockNumber != 11 || pending[1].BlockNumber != 12 {
+		t.Fatalf("pending = %v, want blocks 11 and 12", walBlockNumbers(pending))
+	}
+
+	// Second run: the uncommitted blocks are replayed into the sinks and committed
+	memory := sinks.NewMemorySink()
+	second := newTestTracker(t, cfg, memory)
+	second.head.Store(100)
+	if err := second.replayWAL(ctx); err != nil {
+		t.Fatalf("replayWAL: %v", err)
+	}
+	defer second.wal.close()
+
+	events := memory.Events()
+	if len(events) != 2 || events[0].BlockNumber != 11 || events[1].BlockNumber != 12 {
+		t.Fatalf("replayed %d events, want blocks 11 and 12", len(events))
+	}
+	if cursor, ok, err := loadCursor(cfg.CursorFile); err != nil || !ok || cursor != 12 {
+		t.Errorf("loadCursor = %d, %v, %v; want 12", cursor, ok, err)
+	}
+	if pending, _, _, err := readWAL(filepath.Join(cfg.WALDir, walFileName)); err != nil || len(pending) != 0 {
+		t.Errorf("readWAL after replay = %v, %v; want nothing pending", walBlockNumbers(pending), err)
+	}
+}
+
+func TestWALReplayHoldsFailedBlock(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		CursorFile: filepath.Join(dir, "cursor"),
+		WALDir:     filepath.Join(dir, "wal"),
+	}
+	ctx := context.Background()
+
+	wal, _, err := openWAL(cfg.WALDir)
+	if err != nil {
+		t.Fatalf("openWAL: %v", err)
+	}
+	for block := uint64(20); block <= 22; block++ {
+		if err := wal.append(block, []sinks.Event{{BlockNumber: block}}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+	wal.close()
+
+	// Block 21 fails on replay: block 20 is committed, 21 and 22 stay in the log
+	failing := &failingSink{MemorySink: sinks.NewMemorySink(), failBlocks: map[uint64]bool{21: true}}
+	tr := newTestTracker(t, cfg, failing)
+	tr.head.Store(100)
+	if err := tr.replayWAL(ctx); err != nil {
+		t.Fatalf("replayWAL: %v", err)
+	}
+	tr.wal.close()
+
+	if !tr.cursorHeld {
+		t.Error("cursor not held after a failed replay")
+	}
+	if cursor, ok, err := loadCursor(cfg.CursorFile); err != nil || !ok || cursor != 20 {
+		t.Errorf("loadCursor = %d, %v, %v; want 20", cursor, ok, err)
+	}
+	pending, _, _, err := readWAL(filepath.Join(cfg.WALDir, walFileName))
+	if err != nil {
+		t.Fatalf("readWAL: %v", err)
+	}
+	if len(pending) != 2 || pending[0].BlockNumber != 21 || pending[1].BlockNumber != 22 {
+		t.Errorf("pending = %v, want blocks 21 and 22", walBlockNumbers(pending))
+	}
+}
+
+func walBlockNumbers(records []walRecord) []uint64 {
+	numbers := make([]uint64, 0, len(records))
+	for _, record := range records {
+		numbers = append(numbers, record.BlockNumber)
+	}
+	return numbers
+}
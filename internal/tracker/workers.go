@@ -0,0 +1,390 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"usdc-event-tracker/internal/sinks"
+)
+
+// blockResult is the outcome of fetching and filtering a single block
+type blockResult struct {
+	blockNumber uint64
+	events      []sinks.Event
+	empty       bool // Block has no USDC activity, nothing is written to sinks
+
+	// Heartbeat details of an empty block, only set with EMIT_EMPTY_BLOCKS
+	txCount   int
+	blockTime time.Time
+
+	// Balances of the watched addresses, only set every BALANCE_SNAPSHOT_INTERVAL blocks
+	balances []sinks.BalanceSnapshot
+
+	// Hash of the block and its parent, only set with REORG_WINDOW
+	hash       common.Hash
+	parentHash common.Hash
+}
+
+// pendingBlocksPerWorker bounds, per worker, the blocks handed out ahead of the
+// in-order writer. Results held back behind a block that keeps failing to fetch
+// would otherwise pile up without bound.
+const pendingBlocksPerWorker = 4
+
+// monitorBlocks continuously monitors new blocks.
+// A head watcher enqueues every new block number, a pool of BLOCK_WORKERS workers
+// fetches and filters blocks concurrently, and a single writer delivers results to
+// the sinks strictly in block order. Sinks therefore never see blocks out of order
+// and are never written to concurrently, regardless of the number of workers.
+func (t *Tracker) monitorBlocks(ctx context.Context) error {
+	// Only the head watcher stops on Drain; queued blocks are still fetched and written
+	intake, stopIntake := t.intake(ctx)
+	defer stopIntake()
+
+	head, err := t.waitForHead(intake)
+	if err != nil {
+		// Nil if drained before the first block
+		return ctx.Err()
+	}
+	start := t.resumeBlock(head)
+	t.beginCatchUp(start, head)
+
+	workers := t.config.BlockWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	// Extra workers only help while catching up and stop once caught up
+	catchUpWorkers := max(t.config.CatchUpWorkers, workers)
+
+	queued := make(chan uint64, catchUpWorkers*2)
+	jobs := make(chan uint64)
+	results := make(chan blockResult, catchUpWorkers*2)
+
+	// Each block handed out takes a slot in the window until it is written
+	window := make(chan struct{}, catchUpWorkers*pendingBlocksPerWorker)
+	go handOut(ctx, queued, jobs, window)
+
+	var wg sync.WaitGroup
+	for i := 0; i < catchUpWorkers; i++ {
+		var stop <-chan struct{}
+		if i >= workers {
+			stop = t.caughtUpCh
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			t.blockWorker(ctx, jobs, results, stop)
+		}()
+	}
+
+	writerDone := make(chan struct{})
+	next := start
+	go func() {
+		defer close(writerDone)
+		next = t.writeInOrder(ctx, start, results, window)
+	}()
+
+	t.logger.Info("Block monitoring started", map[string]interface{}{
+		"start_block":      start,
+		"block_workers":    workers,
+		"catch_up_workers": catchUpWorkers,
+		"block_interval":   t.blockInterval.String(),
+		"poll_interval":    t.pollInterval.String(),
+	})
+
+	// Runs until the context is canceled or Drain is called, then lets the pipeline drain
+	if t.config.HeadSubscription {
+		t.subscribeHeads(intake, start, queued)
+	} else {
+		t.watchHead(intake, start, queued)
+	}
+
+	close(queued)
+	wg.Wait()
+	close(results)
+	<-writerDone
+
+	// Record everything written before shutdown
+	if next > start {
+		t.checkpoint(next-1, true)
+	}
+
+	return ctx.Err()
+}
+
+// waitForHead returns the current chain head, retrying until it succeeds or ctx is canceled
+func (t *Tracker) waitForHead(ctx context.Context) (uint64, error) {
+	for {
+		head, err := t.latestBlockNumber(ctx)
+		if err == nil {
+			return head, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(t.pollInterval):
+		}
+	}
+}
+
+// watchHead polls the chain head and enqueues every block from next onwards
+func (t *Tracker) watchHead(ctx context.Context, next uint64, jobs chan<- uint64) {
+	for {
+		head, err := t.latestBlockNumber(ctx)
+		if err == nil {
+			var ok bool
+			if next, ok = enqueueThrough(ctx, next, head, jobs); !ok {
+				return
+			}
+		}
+
+		t.logRateLimitStats()
+		t.logRPCStats()
+		t.logSinkStats()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(t.pollInterval):
+		}
+	}
+}
+
+// Bounds of the jittered exponential backoff between head subscription attempts
+const (
+	minResubscribeBackoff = 1 * time.Second
+	maxResubscribeBackoff = 60 * time.Second
+)
+
+// subscribeHeads follows the chain head over an eth_subscribe("newHeads") subscription
+// and enqueues every block from next onwards. When the subscription fails or is dropped,
+// e.g. because the node restarted, it resubscribes with jittered exponential backoff and
+// then enqueues any blocks produced while it was disconnected, so none are skipped.
+func (t *Tracker) subscribeHeads(ctx context.Context, next uint64, jobs chan<- uint64) {
+	backoff := minResubscribeBackoff
+	attempt := 0
+
+	for {
+		headers := make(chan *types.Header, 16)
+		sub, err := t.client.SubscribeNewHead(ctx, headers)
+		if err != nil {
+			attempt++
+			if !t.waitToResubscribe(ctx, "Head", attempt, &backoff, err) {
+				return
+			}
+			continue
+		}
+
+		if attempt > 0 {
+			t.logger.Info("Head subscription re-established", map[string]interface{}{
+				"attempts":   attempt,
+				"next_block": next,
+			})
+		}
+		backoff = minResubscribeBackoff
+		attempt = 0
+
+		// Catch up on blocks missed since the last header, the subscription only delivers new ones
+		if head, err := t.latestBlockNumber(ctx); err == nil {
+			var ok bool
+			if next, ok = enqueueThrough(ctx, next, head, jobs); !ok {
+				sub.Unsubscribe()
+				return
+			}
+		}
+
+		next, err = t.consumeHeads(ctx, sub, headers, next, jobs)
+		sub.Unsubscribe()
+		if ctx.Err() != nil {
+			return
+		}
+
+		t.logger.Warn("Head subscription dropped, resubscribing", map[string]interface{}{
+			"next_block": next,
+			"error":      fmt.Sprint(err),
+		})
+	}
+}
+
+// waitToResubscribe logs a failed subscription attempt and sleeps for a jittered
+// backoff, doubling it up to maxResubscribeBackoff. It returns false if ctx was
+// canceled, in which case the caller should stop resubscribing.
+func (t *Tracker) waitToResubscribe(ctx context.Context, kind string, attempt int, backoff *time.Duration, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	wait := jitter(*backoff)
+	t.logger.Warn(kind+" subscription failed, retrying", map[string]interface{}{
+		"attempt":  attempt,
+		"retry_in": wait.String(),
+		"error":    err.Error(),
+	})
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(wait):
+	}
+
+	if *backoff *= 2; *backoff > maxResubscribeBackoff {
+		*backoff = maxResubscribeBackoff
+	}
+	return true
+}
+
+// consumeHeads enqueues blocks announced by sub until the subscription fails or ctx
+// is canceled. It returns the next block to enqueue and the subscription error.
+func (t *Tracker) consumeHeads(ctx context.Context, sub ethereum.Subscription, headers <-chan *types.Header, next uint64, jobs chan<- uint64) (uint64, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return next, ctx.Err()
+		case err := <-sub.Err():
+			return next, err
+		case header := <-headers:
+			t.observeHead(header.Number.Uint64())
+			var ok bool
+			if next, ok = enqueueThrough(ctx, next, header.Number.Uint64(), jobs); !ok {
+				return next, ctx.Err()
+			}
+
+			t.logRateLimitStats()
+			t.logRPCStats()
+			t.logSinkStats()
+		}
+	}
+}
+
+// enqueueThrough enqueues blocks next..head and returns the next block to enqueue.
+// It returns false if ctx was canceled before every block was queued.
+func enqueueThrough(ctx context.Context, next, head uint64, jobs chan<- uint64) (uint64, bool) {
+	for ; next <= head; next++ {
+		select {
+		case jobs <- next:
+		case <-ctx.Done():
+			return next, false
+		}
+	}
+	return next, true
+}
+
+// handOut passes queued blocks on to the workers in order, each once it got a
+// slot in window. It closes jobs once queued is closed or ctx is canceled.
+func handOut(ctx context.Context, queued <-chan uint64, jobs chan<- uint64, window chan<- struct{}) {
+	defer close(jobs)
+
+	for blockNumber := range queued {
+		select {
+		case window <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		select {
+		case jobs <- blockNumber:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// jitter returns a random duration in [d/2, d) so that many trackers reconnecting
+// to the same node do not retry in lockstep
+func jitter(d time.Duration) time.Duration {
+	half := d / 2
+	return half + time.Duration(rand.Int64N(int64(half)))
+}
+
+// blockWorker fetches queued blocks until the queue is closed, ctx is canceled or,
+// between blocks, stop is closed; a nil stop never is.
+// Failed fetches are retried so that the in-order writer is never left waiting on a gap.
+func (t *Tracker) blockWorker(ctx context.Context, jobs <-chan uint64, results chan<- blockResult, stop <-chan struct{}) {
+	for {
+		var blockNumber uint64
+		select {
+		case <-stop:
+			return
+		case next, ok := <-jobs:
+			if !ok {
+				return
+			}
+			blockNumber = next
+		}
+
+		result, ok := t.fetchBlockRetrying(ctx, blockNumber)
+		if !ok {
+			return
+		}
+		select {
+		case results <- result:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// fetchBlockRetrying fetches a block and its balance snapshots, retrying failed
+// fetches every block interval. It returns false if ctx was canceled first.
+func (t *Tracker) fetchBlockRetrying(ctx context.Context, blockNumber uint64) (blockResult, bool) {
+	for {
+		result, err := t.fetchBlock(ctx, blockNumber)
+		if err == nil {
+			result.balances = t.snapshotBalances(ctx, blockNumber)
+			return result, true
+		}
+
+		t.logger.Error("Error processing block", err, map[string]interface{}{
+			"block_number": blockNumber,
+		})
+
+		select {
+		case <-ctx.Done():
+			return blockResult{}, false
+		case <-time.After(t.blockInterval):
+		}
+	}
+}
+
+// writeInOrder delivers fetched blocks to the sinks in ascending block order,
+// holding back results that arrive ahead of the next expected block, and
+// checkpoints the cursor as blocks are written. Each block frees its slot in
+// window once it is up for writing, unless window is nil. It returns the next
+// block that was not written.
+func (t *Tracker) writeInOrder(ctx context.Context, next uint64, results <-chan blockResult, window <-chan struct{}) uint64 {
+	pending := make(map[uint64]blockResult)
+
+	for result := range results {
+		pending[result.blockNumber] = result
+
+		for {
+			ready, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			if window != nil {
+				<-window
+			}
+
+			t.checkReorg(ctx, ready)
+			if err := t.writeBlock(ctx, ready); err != nil {
+				t.logger.Error("Error processing block", err, map[string]interface{}{
+					"block_number": ready.blockNumber,
+				})
+				t.holdCursor(ready.blockNumber)
+				continue
+			}
+			t.checkpoint(ready.blockNumber, false)
+			t.trackCatchUp(ready.blockNumber)
+		}
+	}
+
+	return next
+}
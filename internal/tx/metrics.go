@@ -0,0 +1,100 @@
+package tx
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rpcLatencyBuckets are the upper bounds, in seconds, of the RPC latency histogram
+var rpcLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// RPCMetrics records the latency and errors of RPC calls by JSON-RPC method, as
+// a histogram named rpc_call_duration_seconds and a counter named rpc_errors_total.
+// A nil *RPCMetrics is valid and records nothing. It is safe for concurrent use.
+type RPCMetrics struct {
+	mu      sync.Mutex
+	methods map[string]*methodMetrics
+}
+
+// methodMetrics are the recorded calls of one method
+type methodMetrics struct {
+	calls   int64
+	errors  int64
+	total   time.Duration
+	buckets []int64 // Calls per rpcLatencyBuckets bound, not cumulative
+}
+
+// NewRPCMetrics creates an empty set of RPC metrics
+func NewRPCMetrics() *RPCMetrics {
+	return &RPCMetrics{methods: make(map[string]*methodMetrics)}
+}
+
+// Observe records a call to method that started at start and returned err.
+// Calls canceled by their context, e.g. on shutdown, are not counted as errors.
+func (m *RPCMetrics) Observe(method string, start time.Time, err error) {
+	if m == nil {
+		return
+	}
+	elapsed := time.Since(start)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mm, ok := m.methods[method]
+	if !ok {
+		mm = &methodMetrics{buckets: make([]int64, len(rpcLatencyBuckets))}
+		m.methods[method] = mm
+	}
+
+	mm.calls++
+	mm.total += elapsed
+	for i, bound := range rpcLatencyBuckets {
+		if elapsed.Seconds() <= bound {
+			mm.buckets[i]++
+			break
+		}
+	}
+	if err != nil && !errors.Is(err, context.Canceled) {
+		mm.errors++
+	}
+}
+
+// Stats returns rpc_call_duration_seconds with the call count, total and average
+// duration and cumulative histogram buckets of each method, and rpc_errors_total
+// with the error count of each method
+func (m *RPCMetrics) Stats() map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	durations := make(map[string]interface{}, len(m.methods))
+	errorCounts := make(map[string]int64, len(m.methods))
+	for method, mm := range m.methods {
+		buckets := make(map[string]int64, len(rpcLatencyBuckets)+1)
+		var cumulative int64
+		for i, bound := range rpcLatencyBuckets {
+			cumulative += mm.buckets[i]
+			buckets[strconv.FormatFloat(bound, 'f', -1, 64)] = cumulative
+		}
+		buckets["+Inf"] = mm.calls
+
+		durations[method] = map[string]interface{}{
+			"count":   mm.calls,
+			"sum":     mm.total.Seconds(),
+			"avg_ms":  mm.total.Milliseconds() / mm.calls,
+			"buckets": buckets,
+		}
+		errorCounts[method] = mm.errors
+	}
+
+	return map[string]interface{}{
+		"rpc_call_duration_seconds": durations,
+		"rpc_errors_total":          errorCounts,
+	}
+}
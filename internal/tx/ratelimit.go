@@ -0,0 +1,167 @@
+package tx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// Backoff bounds used when a 429 response carries no Retry-After header
+	minBackoff = 1 * time.Second
+	maxBackoff = 30 * time.Second
+)
+
+// RateLimiter throttles RPC calls with a token bucket and backs off when the
+// provider responds with HTTP 429 Too Many Requests.
+// A nil *RateLimiter is valid and never throttles.
+type RateLimiter struct {
+	limiter *rate.Limiter
+
+	mu           sync.Mutex
+	blockedUntil time.Time
+	backoff      time.Duration
+
+	// Metrics
+	totalRequests  int64
+	totalWaits     int64
+	totalWaitTime  time.Duration
+	totalThrottled int64
+}
+
+// NewRateLimiter creates a limiter allowing requestsPerSecond calls with a burst of
+// the same size (at least 1). Returns nil if requestsPerSecond is not positive.
+func NewRateLimiter(requestsPerSecond float64) *RateLimiter {
+	if requestsPerSecond <= 0 {
+		return nil
+	}
+
+	burst := int(requestsPerSecond)
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &RateLimiter{
+		limiter: rate.NewLimiter(rate.Limit(requestsPerSecond), burst),
+		backoff: minBackoff,
+	}
+}
+
+// Wait blocks until an RPC call is allowed or the context is canceled.
+func (r *RateLimiter) Wait(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+
+	start := time.Now()
+
+	r.mu.Lock()
+	delay := time.Until(r.blockedUntil)
+	r.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if err := r.limiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	waited := time.Since(start)
+
+	r.mu.Lock()
+	r.totalRequests++
+	if waited > time.Millisecond {
+		r.totalWaits++
+		r.totalWaitTime += waited
+	}
+	r.mu.Unlock()
+
+	return nil
+}
+
+// Observe inspects the result of an RPC call. A 429 response pauses all calls
+// with exponential backoff; any other result resets the backoff.
+// Returns true if the call was rate limited.
+func (r *RateLimiter) Observe(err error) bool {
+	if r == nil {
+		return false
+	}
+
+	if !IsRateLimited(err) {
+		r.mu.Lock()
+		r.backoff = minBackoff
+		r.mu.Unlock()
+		return false
+	}
+
+	r.mu.Lock()
+	r.totalThrottled++
+	delay := r.backoff
+	r.backoff *= 2
+	if r.backoff > maxBackoff {
+		r.backoff = maxBackoff
+	}
+	r.mu.Unlock()
+
+	r.BackoffFor(delay)
+	return true
+}
+
+// BackoffFor pauses all calls for at least d, e.g. as requested by a Retry-After header.
+func (r *RateLimiter) BackoffFor(d time.Duration) {
+	if r == nil || d <= 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if until := time.Now().Add(d); until.After(r.blockedUntil) {
+		r.blockedUntil = until
+	}
+}
+
+// Stats returns the current rate-limit state for metrics
+func (r *RateLimiter) Stats() map[string]interface{} {
+	if r == nil {
+		return map[string]interface{}{"enabled": false}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	backoffRemaining := time.Until(r.blockedUntil)
+	if backoffRemaining < 0 {
+		backoffRemaining = 0
+	}
+
+	return map[string]interface{}{
+		"enabled":              true,
+		"limit_rps":            float64(r.limiter.Limit()),
+		"burst":                r.limiter.Burst(),
+		"available_tokens":     r.limiter.Tokens(),
+		"total_requests":       r.totalRequests,
+		"total_waits":          r.totalWaits,
+		"total_wait_ms":        r.totalWaitTime.Milliseconds(),
+		"total_throttled":      r.totalThrottled,
+		"backoff_remaining_ms": backoffRemaining.Milliseconds(),
+	}
+}
+
+// IsRateLimited reports whether err is an HTTP 429 response from the RPC provider.
+func IsRateLimited(err error) bool {
+	var httpErr rpc.HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusTooManyRequests
+}
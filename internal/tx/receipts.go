@@ -0,0 +1,134 @@
+package tx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"usdc-event-tracker/internal/logging"
+)
+
+// methodNotFoundCode is the JSON-RPC 2.0 error code for an unknown method
+const methodNotFoundCode = -32601
+
+// ReceiptFetcher retrieves block receipts with eth_getBlockReceipts and falls back
+// to fetching the block and one eth_getTransactionReceipt per transaction when the
+// endpoint does not support it. Support is probed on the first block and cached, so
+// an unsupported endpoint is not asked again for every block.
+type ReceiptFetcher struct {
+	client  ReceiptClient
+	limiter *RateLimiter
+	metrics *RPCMetrics
+	logger  *logging.Logger
+
+	perTransaction atomic.Bool // eth_getBlockReceipts is unsupported
+}
+
+// NewReceiptFetcher creates a fetcher for client. The limiter throttles the extra
+// calls made by the per-transaction fallback and metrics records every call; both
+// may be nil.
+func NewReceiptFetcher(client ReceiptClient, limiter *RateLimiter, metrics *RPCMetrics) *ReceiptFetcher {
+	return &ReceiptFetcher{
+		client:  client,
+		limiter: limiter,
+		metrics: metrics,
+		logger:  logging.GetLogger("receipts"),
+	}
+}
+
+// BlockReceipts returns all receipts of a block, with the same semantics as
+// GetAllTransactionInBlock regardless of which method the endpoint supports.
+func (f *ReceiptFetcher) BlockReceipts(ctx context.Context, blockNumber uint64) ([]*types.Receipt, error) {
+	if f.perTransaction.Load() {
+		return f.receiptsByTransaction(ctx, blockNumber)
+	}
+
+	start := time.Now()
+	receipts, err := GetAllTransactionInBlock(f.client, ctx, blockNumber)
+	f.metrics.Observe("eth_getBlockReceipts", start, err)
+	if !IsMethodNotFound(err) {
+		return receipts, err
+	}
+
+	if f.perTransaction.CompareAndSwap(false, true) {
+		f.logger.Warn("RPC endpoint does not support eth_getBlockReceipts, fetching receipts per transaction", map[string]interface{}{
+			"block_number": blockNumber,
+			"error":        err.Error(),
+		})
+	}
+	return f.receiptsByTransaction(ctx, blockNumber)
+}
+
+// PerTransaction reports whether the fetcher fell back to per-transaction receipts
+func (f *ReceiptFetcher) PerTransaction() bool {
+	return f.perTransaction.Load()
+}
+
+// receiptsByTransaction fetches the block and then the receipt of each of its
+// transactions. The caller has already waited on the limiter for the first call.
+func (f *ReceiptFetcher) receiptsByTransaction(ctx context.Context, blockNumber uint64) ([]*types.Receipt, error) {
+	start := time.Now()
+	block, err := f.client.BlockByNumber(ctx, new(big.Int).SetUint64(blockNumber))
+	f.metrics.Observe("eth_getBlockByNumber", start, err)
+	if errors.Is(err, ethereum.NotFound) {
+		return nil, fmt.Errorf("failed to get block %d: %w", blockNumber, ErrReceiptsUnavailable)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block %d: %w", blockNumber, err)
+	}
+
+	receipts := make([]*types.Receipt, 0, len(block.Transactions()))
+	for _, transaction := range block.Transactions() {
+		if err := f.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		start := time.Now()
+		receipt, err := f.client.TransactionReceipt(ctx, transaction.Hash())
+		f.limiter.Observe(err)
+		f.metrics.Observe("eth_getTransactionReceipt", start, err)
+		if errors.Is(err, ethereum.NotFound) {
+			return nil, fmt.Errorf("failed to get receipt for tx %s in block %d: %w", transaction.Hash().Hex(), blockNumber, ErrReceiptsUnavailable)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get receipt for tx %s in block %d: %w", transaction.Hash().Hex(), blockNumber, err)
+		}
+		receipts = append(receipts, receipt)
+	}
+
+	return receipts, nil
+}
+
+// IsMethodNotFound reports whether err means the endpoint does not implement the
+// called method. Providers do not agree on an error code, so besides the standard
+// JSON-RPC code the most common messages are matched as well.
+func IsMethodNotFound(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == methodNotFoundCode {
+		return true
+	}
+
+	message := strings.ToLower(err.Error())
+	for _, hint := range []string{
+		"method not found",
+		"does not exist/is not available",
+		"method not supported",
+		"unsupported method",
+		"not whitelisted",
+	} {
+		if strings.Contains(message, hint) {
+			return true
+		}
+	}
+	return false
+}
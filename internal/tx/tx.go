@@ -3,23 +3,97 @@ package tx
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/big"
 
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
+// ErrReceiptsUnavailable is returned when the node answers a receipts request with null,
+// typically because it has not imported the block yet or has pruned it. This is distinct
+// from a block that exists but has no transactions, which yields an empty slice.
+var ErrReceiptsUnavailable = errors.New("receipts unavailable")
+
+// ReceiptClient is the part of *ethclient.Client used to retrieve receipts
+type ReceiptClient interface {
+	BlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error)
+	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
 // GetAllTransactionInBlock retrieves all transaction receipts for a given block number.
 // It uses the BlockReceipts method for efficient batch retrieval.
-// Returns an empty slice if the block contains no transactions.
-func GetAllTransactionInBlock(client *ethclient.Client, ctx context.Context, blockNumber uint64) ([]*types.Receipt, error) {
+// Returns an empty slice if the block contains no transactions, and an error wrapping
+// ErrReceiptsUnavailable if the node returned no receipts at all.
+func GetAllTransactionInBlock(client ReceiptClient, ctx context.Context, blockNumber uint64) ([]*types.Receipt, error) {
 	blockNum := rpc.BlockNumber(blockNumber)
 	
 	receipts, err := client.BlockReceipts(ctx, rpc.BlockNumberOrHashWithNumber(blockNum))
+	if errors.Is(err, ethereum.NotFound) || (err == nil && receipts == nil) {
+		return nil, fmt.Errorf("failed to get receipts for block %d: %w", blockNumber, ErrReceiptsUnavailable)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get receipts for block %d: %w", blockNumber, err)
 	}
 	
-	return receipts, nil
+	return dropNilReceipts(receipts), nil
+}
+
+// dropNilReceipts removes null entries some providers return for receipts they
+// failed to load, so callers can dereference every receipt safely.
+func dropNilReceipts(receipts []*types.Receipt) []*types.Receipt {
+	filtered := receipts[:0]
+	for _, receipt := range receipts {
+		if receipt != nil {
+			filtered = append(filtered, receipt)
+		}
+	}
+	return filtered
+}
+
+// ReceiptsBatchSize is the maximum number of eth_getBlockReceipts calls bundled into
+// a single JSON-RPC batch request by GetReceiptsBatch.
+var ReceiptsBatchSize = 50
+
+// GetReceiptsBatch retrieves the receipts for many blocks using JSON-RPC batch requests,
+// bundling up to ReceiptsBatchSize eth_getBlockReceipts calls per round-trip.
+// The returned slice is aligned with blockNumbers. An error is returned if any call fails.
+func GetReceiptsBatch(rpcClient *rpc.Client, ctx context.Context, blockNumbers []uint64) ([][]*types.Receipt, error) {
+	results := make([][]*types.Receipt, len(blockNumbers))
+
+	for start := 0; start < len(blockNumbers); start += ReceiptsBatchSize {
+		end := start + ReceiptsBatchSize
+		if end > len(blockNumbers) {
+			end = len(blockNumbers)
+		}
+
+		batch := make([]rpc.BatchElem, 0, end-start)
+		for i := start; i < end; i++ {
+			batch = append(batch, rpc.BatchElem{
+				Method: "eth_getBlockReceipts",
+				Args:   []interface{}{rpc.BlockNumber(blockNumbers[i])},
+				Result: &results[i],
+			})
+		}
+
+		if err := rpcClient.BatchCallContext(ctx, batch); err != nil {
+			return nil, fmt.Errorf("failed to get receipts for blocks %d-%d: %w", blockNumbers[start], blockNumbers[end-1], err)
+		}
+
+		for i, elem := range batch {
+			if elem.Error != nil {
+				return nil, fmt.Errorf("failed to get receipts for block %d: %w", blockNumbers[start+i], elem.Error)
+			}
+			if results[start+i] == nil {
+				return nil, fmt.Errorf("failed to get receipts for block %d: %w", blockNumbers[start+i], ErrReceiptsUnavailable)
+			}
+			results[start+i] = dropNilReceipts(results[start+i])
+		}
+	}
+
+	return results, nil
 }
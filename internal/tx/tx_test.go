@@ -0,0 +1,101 @@
+package tx
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// stubReceiptClient answers eth_getBlockReceipts with fixed results
+type stubReceiptClient struct {
+	receipts []*types.Receipt
+	err      error
+}
+
+func (c *stubReceiptClient) BlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error) {
+	return c.receipts, c.err
+}
+
+func (c *stubReceiptClient) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *stubReceiptClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestGetAllTransactionInBlock(t *testing.T) {
+	receipt := &types.Receipt{TxHash: common.HexToHash("0x01")}
+	errTimeout := errors.New("i/o timeout")
+
+	tests := []struct {
+		name        string
+		client      *stubReceiptClient
+		want        int
+		unavailable bool
+		err         error
+	}{
+		{
+			name:   "empty block",
+			client: &stubReceiptClient{receipts: []*types.Receipt{}},
+			want:   0,
+		},
+		{
+			name:   "block with receipts",
+			client: &stubReceiptClient{receipts: []*types.Receipt{receipt, receipt}},
+			want:   2,
+		},
+		{
+			name:   "null entries are dropped",
+			client: &stubReceiptClient{receipts: []*types.Receipt{nil, receipt, nil}},
+			want:   1,
+		},
+		{
+			name:        "null result",
+			client:      &stubReceiptClient{},
+			unavailable: true,
+		},
+		{
+			name:        "not found",
+			client:      &stubReceiptClient{err: ethereum.NotFound},
+			unavailable: true,
+		},
+		{
+			name:   "rpc error",
+			client: &stubReceiptClient{err: errTimeout},
+			err:    errTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receipts, err := GetAllTransactionInBlock(tt.client, context.Background(), 10)
+
+			if got := errors.Is(err, ErrReceiptsUnavailable); got != tt.unavailable {
+				t.Fatalf("error = %v, unavailable = %v; want unavailable = %v", err, got, tt.unavailable)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if tt.unavailable || tt.err != nil {
+				if receipts != nil {
+					t.Errorf("receipts = %v, want nil on error", receipts)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error = %v, want nil", err)
+			}
+			if receipts == nil || len(receipts) != tt.want {
+				t.Errorf("got %d receipts (nil = %v), want a non-nil slice of %d", len(receipts), receipts == nil, tt.want)
+			}
+		})
+	}
+}
@@ -7,6 +7,9 @@ import (
 	"usdc-event-tracker/internal/erc20"
 )
 
+// Decimals is the number of decimal places used by USDC amounts on all supported networks
+const Decimals = 6
+
 // NewAddress converts a hex string to an Ethereum address.
 // The hex string can be with or without the 0x prefix.
 func NewAddress(hexAddress string) common.Address {
@@ -34,6 +37,22 @@ func FilterByAddress(receipts []*types.Receipt, contractAddress string) []*types
 	return filtered
 }
 
+// FilterByAddresses filters receipts to return only those containing logs from any of the given contract addresses.
+func FilterByAddresses(receipts []*types.Receipt, contractAddresses []string) []*types.Receipt {
+	filtered := make([]*types.Receipt, 0)
+
+	for _, receipt := range receipts {
+		for _, contractAddress := range contractAddresses {
+			if hasLogsFromAddress(receipt, NewAddress(contractAddress)) {
+				filtered = append(filtered, receipt)
+				break
+			}
+		}
+	}
+
+	return filtered
+}
+
 // hasLogsFromAddress checks if a receipt contains any logs from the specified address.
 // Returns true if at least one log matches the address, false otherwise.
 func hasLogsFromAddress(receipt *types.Receipt, address common.Address) bool {
@@ -0,0 +1,148 @@
+// Package verify checks a sink for blocks missing after a backfill
+package verify
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"usdc-event-tracker/internal/sinks"
+	"usdc-event-tracker/internal/tx"
+	"usdc-event-tracker/internal/usdc"
+)
+
+// Range is an inclusive range of block numbers
+type Range struct {
+	From uint64 `json:"from"`
+	To   uint64 `json:"to"`
+}
+
+// Report lists the blocks of a range without persisted events. Blocks without
+// USDC activity are never written, so a missing block is only a real gap if the
+// chain has activity in it; Rescan finds out which ones do.
+type Report struct {
+	Sink            string  `json:"sink"`
+	FromBlock       uint64  `json:"from_block"`
+	ToBlock         uint64  `json:"to_block"`
+	PersistedBlocks int     `json:"persisted_blocks"`
+	MissingBlocks   uint64  `json:"missing_blocks"`
+	Gaps            []Range `json:"gaps"` // Consecutive blocks without persisted events
+
+	// Set by Rescan: missing blocks that have USDC activity on chain
+	Rescanned           bool     `json:"rescanned"`
+	MissingWithActivity []uint64 `json:"missing_with_activity,omitempty"`
+}
+
+// ActivityFunc reports whether a block has USDC activity on chain
+type ActivityFunc func(ctx context.Context, blockNumber uint64) (bool, error)
+
+// ParseRange parses a block range given as FROM-TO
+func ParseRange(value string) (uint64, uint64, error) {
+	fromText, toText, ok := strings.Cut(value, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid block range %q, expected FROM-TO", value)
+	}
+
+	from, err := strconv.ParseUint(strings.TrimSpace(fromText), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid start block %q: %w", fromText, err)
+	}
+	to, err := strconv.ParseUint(strings.TrimSpace(toText), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid end block %q: %w", toText, err)
+	}
+	if to < from {
+		return 0, 0, fmt.Errorf("invalid block range %q, end is before start", value)
+	}
+
+	return from, to, nil
+}
+
+// Blocks compares the blocks persisted by a sink with every block in [from, to]
+func Blocks(ctx context.Context, name string, lister sinks.BlockLister, from, to uint64) (*Report, error) {
+	persisted, err := lister.PersistedBlocks(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blocks persisted by %s: %w", name, err)
+	}
+
+	report := &Report{
+		Sink:            name,
+		FromBlock:       from,
+		ToBlock:         to,
+		PersistedBlocks: len(persisted),
+		Gaps:            make([]Range, 0),
+	}
+
+	next, complete := from, false
+	for _, block := range persisted {
+		if block < next || block > to {
+			continue
+		}
+		if block > next {
+			report.addGap(next, block-1)
+		}
+		if block == to {
+			complete = true
+			break
+		}
+		next = block + 1
+	}
+	if !complete {
+		report.addGap(next, to)
+	}
+
+	return report, nil
+}
+
+// Rescan checks every missing block with hasActivity and records the ones that
+// should have had events. It may make one RPC call per missing block.
+func (r *Report) Rescan(ctx context.Context, hasActivity ActivityFunc) error {
+	r.MissingWithActivity = make([]uint64, 0)
+
+	for _, gap := range r.Gaps {
+		for block := gap.From; block <= gap.To; block++ {
+			active, err := hasActivity(ctx, block)
+			if err != nil {
+				return fmt.Errorf("failed to rescan block %d: %w", block, err)
+			}
+			if active {
+				r.MissingWithActivity = append(r.MissingWithActivity, block)
+			}
+		}
+	}
+
+	r.Rescanned = true
+	return nil
+}
+
+// NeedsBackfill reports whether blocks have to be re-backfilled. Without a
+// rescan, every block without persisted events is treated as a gap.
+func (r *Report) NeedsBackfill() bool {
+	if r.Rescanned {
+		return len(r.MissingWithActivity) > 0
+	}
+	return r.MissingBlocks > 0
+}
+
+// addGap records a range of blocks without persisted events
+func (r *Report) addGap(from, to uint64) {
+	r.Gaps = append(r.Gaps, Range{From: from, To: to})
+	r.MissingBlocks += to - from + 1
+}
+
+// ChainActivity returns an ActivityFunc that fetches a block's receipts and
+// checks them for logs of the given contracts. The limiter may be nil.
+func ChainActivity(fetcher *tx.ReceiptFetcher, limiter *tx.RateLimiter, contracts []string) ActivityFunc {
+	return func(ctx context.Context, blockNumber uint64) (bool, error) {
+		if err := limiter.Wait(ctx); err != nil {
+			return false, err
+		}
+		receipts, err := fetcher.BlockReceipts(ctx, blockNumber)
+		limiter.Observe(err)
+		if err != nil {
+			return false, err
+		}
+		return len(usdc.FilterByAddresses(receipts, contracts)) > 0, nil
+	}
+}
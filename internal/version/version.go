@@ -0,0 +1,62 @@
+// Package version reports which build of the tracker is running
+package version
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Build information, injected at link time:
+//
+//	go build -ldflags "-X usdc-event-tracker/internal/version.Version=v1.2.0 \
+//	  -X usdc-event-tracker/internal/version.Commit=$(git rev-parse HEAD) \
+//	  -X usdc-event-tracker/internal/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Commit and BuildDate fall back to the VCS information the Go toolchain embeds
+// when building from a git checkout.
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildDate = ""
+)
+
+func init() {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if Commit == "" {
+				Commit = setting.Value
+			}
+		case "vcs.time":
+			if BuildDate == "" {
+				BuildDate = setting.Value
+			}
+		}
+	}
+}
+
+// String returns a one-line description of the build, e.g. for -version
+func String() string {
+	return fmt.Sprintf("usdc-event-tracker %s (commit %s, built %s)", Version, orUnknown(Commit), orUnknown(BuildDate))
+}
+
+// Fields returns the build information as structured log fields
+func Fields() map[string]interface{} {
+	return map[string]interface{}{
+		"version":    Version,
+		"commit":     orUnknown(Commit),
+		"build_date": orUnknown(BuildDate),
+	}
+}
+
+// orUnknown substitutes "unknown" for information that was not available at build time
+func orUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
@@ -0,0 +1,107 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"usdc-event-tracker/internal/config"
+	"usdc-event-tracker/internal/logging"
+	"usdc-event-tracker/internal/tx"
+)
+
+// maxConnectBackoff caps the wait between connection attempts
+const maxConnectBackoff = time.Minute
+
+// Connect creates a client with NewFailoverClient and checks that an endpoint
+// answers a NetworkID call. Dialing an HTTP endpoint never fails by itself, so the
+// call is what proves the node is reachable. Each attempt tries every endpoint,
+// starting with the active one. On failure it retries up to retries more times,
+// waiting backoff before the first retry and doubling the wait after each one, up
+// to a minute. It gives up early if ctx is canceled.
+func Connect(ctx context.Context, endpoints *Endpoints, limiter *tx.RateLimiter, transport *http.Transport, retries int, backoff time.Duration) (*ethclient.Client, error) {
+	logger := logging.GetLogger("ws")
+
+	for attempt := 0; ; attempt++ {
+		client, err := dialEndpoints(ctx, endpoints, limiter, transport)
+		if err == nil {
+			if attempt > 0 {
+				logger.Info("Connected to Ethereum node", map[string]interface{}{
+					"attempts": attempt + 1,
+				})
+			}
+			return client, nil
+		}
+		if attempt >= retries {
+			return nil, err
+		}
+
+		logger.Warn("Ethereum node not reachable, retrying", map[string]interface{}{
+			"attempt":     attempt + 1,
+			"max_retries": retries,
+			"retry_in":    backoff.String(),
+			"error":       err.Error(),
+		})
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+}
+
+// dialEndpoints dials the active endpoint and verifies the node responds. HTTP
+// clients fail over by themselves, while WebSocket endpoints are dialed one after
+// another until one responds.
+func dialEndpoints(ctx context.Context, endpoints *Endpoints, limiter *tx.RateLimiter, transport *http.Transport) (*ethclient.Client, error) {
+	if isHTTP(endpoints.Active()) {
+		return dialAndCheck(ctx, endpoints, limiter, transport)
+	}
+
+	var err error
+	for range endpoints.urls {
+		index := int(endpoints.active.Load())
+		var client *ethclient.Client
+		if client, err = dialAndCheck(ctx, endpoints, limiter, transport); err == nil {
+			return client, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		if len(endpoints.urls) > 1 {
+			endpoints.failover(index, err.Error())
+		}
+	}
+	return nil, err
+}
+
+// dialAndCheck dials the active endpoint and verifies the node responds.
+// Returned errors never contain the endpoint's unredacted URL.
+func dialAndCheck(ctx context.Context, endpoints *Endpoints, limiter *tx.RateLimiter, transport *http.Transport) (*ethclient.Client, error) {
+	client, err := NewFailoverClient(endpoints, limiter, transport)
+	if err != nil {
+		return nil, redactURLError(err)
+	}
+
+	if _, err := client.NetworkID(ctx); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("ethereum node did not respond: %w", redactURLError(err))
+	}
+	return client, nil
+}
+
+// redactURLError masks the URL of a *url.Error in err's chain, which the HTTP
+// client reports with the full endpoint URL including any API key in its path
+func redactURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = config.RedactURL(urlErr.URL)
+	}
+	return err
+}
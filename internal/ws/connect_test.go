@@ -0,0 +1,83 @@
+package ws
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"usdc-event-tracker/internal/logging"
+)
+
+// captureStdout returns what fn writes to os.Stdout, where the logger writes
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		output <- buf.String()
+	}()
+
+	fn()
+	w.Close()
+	return <-output
+}
+
+func TestConnectFailureDoesNotLogAPIKey(t *testing.T) {
+	endpoints := NewEndpoints([]string{"http://127.0.0.1:1/v3/SECRET"}, 0)
+
+	var err error
+	logs := captureStdout(t, func() {
+		_, err = Connect(context.Background(), endpoints, nil, nil, 1, time.Millisecond)
+		if err != nil {
+			// As main logs it
+			logging.GetLogger("main").Error("Failed to create Ethereum client", err)
+		}
+	})
+
+	if err == nil {
+		t.Fatal("Connect succeeded, want an error for an unreachable endpoint")
+	}
+	if strings.Contains(err.Error(), "SECRET") {
+		t.Errorf("error contains the API key: %v", err)
+	}
+	if !strings.Contains(logs, "Ethereum node not reachable, retrying") {
+		t.Errorf("retry was not logged:\n%s", logs)
+	}
+	if strings.Contains(logs, "SECRET") {
+		t.Errorf("logs contain the API key:\n%s", logs)
+	}
+	if !strings.Contains(err.Error(), "http://127.0.0.1:1/v3/***") {
+		t.Errorf("error = %v, want the redacted endpoint", err)
+	}
+}
+
+func TestFailoverClientErrorsDoNotContainAPIKey(t *testing.T) {
+	endpoints := NewEndpoints([]string{"http://127.0.0.1:1/v3/SECRET"}, 0)
+	client, err := NewFailoverClient(endpoints, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	_, err = client.BlockNumber(context.Background())
+	if err == nil {
+		t.Fatal("BlockNumber succeeded, want an error for an unreachable endpoint")
+	}
+	if strings.Contains(err.Error(), "SECRET") {
+		t.Errorf("error contains the API key: %v", err)
+	}
+}
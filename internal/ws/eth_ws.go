@@ -2,18 +2,147 @@
 package ws
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"usdc-event-tracker/internal/config"
+	"usdc-event-tracker/internal/tx"
 )
 
 // NewClient creates a new Ethereum client connection using the provided URL.
-// The URL can be HTTP, HTTPS, WS, or WSS endpoint.
+// The URL can be HTTP, HTTPS, WS, or WSS endpoint; only WS and WSS clients
+// support eth_subscribe, see SupportsSubscriptions.
+// For HTTP endpoints, Retry-After headers on 429 responses are forwarded to the
+// limiter so that subsequent calls back off. The limiter may be nil.
 // Returns an error if the connection cannot be established.
-func NewClient(url string) (*ethclient.Client, error) {
-	client, err := ethclient.Dial(url)
+func NewClient(url string, limiter *tx.RateLimiter) (*ethclient.Client, error) {
+	return NewClientWithTransport(url, limiter, nil)
+}
+
+// NewClientWithTransport is like NewClient, but sends HTTP(S) requests through
+// transport, e.g. one from NewTransport with a larger connection pool. A nil
+// transport uses http.DefaultTransport. WebSocket endpoints ignore it.
+func NewClientWithTransport(url string, limiter *tx.RateLimiter, transport *http.Transport) (*ethclient.Client, error) {
+	if !isHTTP(url) {
+		rpcClient, err := rpc.DialOptions(context.Background(), url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to Ethereum node: %w", err)
+		}
+		return ethclient.NewClient(rpcClient), nil
+	}
+
+	httpClient := &http.Client{
+		Transport: newRetryAfterTransport(limiter, transport),
+	}
+
+	rpcClient, err := rpc.DialHTTPWithClient(url, httpClient)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Ethereum node: %w", err)
 	}
-	return client, nil
+	return ethclient.NewClient(rpcClient), nil
+}
+
+// NewFailoverClient is like NewClientWithTransport, but for HTTP(S) endpoints
+// every request goes to the active one of endpoints and fails over to the next
+// when it errors or times out. WebSocket clients hold a single connection, so
+// they are connected to the active endpoint only; Connect fails over between
+// WebSocket endpoints when dialing.
+func NewFailoverClient(endpoints *Endpoints, limiter *tx.RateLimiter, transport *http.Transport) (*ethclient.Client, error) {
+	if !isHTTP(endpoints.Active()) {
+		return NewClientWithTransport(endpoints.Active(), limiter, transport)
+	}
+
+	failover, err := newFailoverTransport(newRetryAfterTransport(limiter, transport), endpoints)
+	if err != nil {
+		return nil, err
+	}
+
+	// The transport sends every request to an endpoint's real URL, so the client
+	// only needs the redacted one, which *url.Error messages of failed calls repeat
+	rpcClient, err := rpc.DialHTTPWithClient(config.RedactURL(endpoints.Active()), &http.Client{Transport: failover})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Ethereum node: %w", err)
+	}
+	return ethclient.NewClient(rpcClient), nil
+}
+
+// newRetryAfterTransport wraps transport, or http.DefaultTransport if nil, so
+// Retry-After hints reach the limiter
+func newRetryAfterTransport(limiter *tx.RateLimiter, transport *http.Transport) *retryAfterTransport {
+	var base http.RoundTripper = http.DefaultTransport
+	if transport != nil {
+		base = transport
+	}
+	return &retryAfterTransport{base: base, limiter: limiter}
+}
+
+// NewTransport returns an HTTP transport keeping up to maxConns connections to
+// the RPC endpoint open and reusable. The default transport keeps only two idle
+// connections per host, so concurrent receipt fetches keep reconnecting.
+// Returns nil, i.e. the default transport, if maxConns is not positive.
+func NewTransport(maxConns int) *http.Transport {
+	if maxConns <= 0 {
+		return nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = maxConns
+	transport.MaxIdleConnsPerHost = maxConns
+	transport.MaxConnsPerHost = maxConns
+	transport.IdleConnTimeout = 90 * time.Second
+	return transport
+}
+
+// SupportsSubscriptions reports whether client can use eth_subscribe, e.g. for
+// SubscribeNewHead and SubscribeFilterLogs. This is true for WebSocket and IPC
+// connections but not HTTP(S), where subscribing fails with rpc.ErrNotificationsUnsupported.
+func SupportsSubscriptions(client *ethclient.Client) bool {
+	return client.Client().SupportsSubscriptions()
+}
+
+// isHTTP reports whether url is an HTTP(S) endpoint
+func isHTTP(url string) bool {
+	lower := strings.ToLower(url)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// retryAfterTransport inspects HTTP responses for rate limiting hints
+type retryAfterTransport struct {
+	base    http.RoundTripper
+	limiter *tx.RateLimiter
+}
+
+// RoundTrip performs the request and applies any Retry-After delay from a 429 response
+func (t *retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		t.limiter.BackoffFor(parseRetryAfter(resp.Header.Get("Retry-After")))
+	}
+
+	return resp, nil
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date.
+// Returns zero if the header is missing or invalid.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return 0
 }
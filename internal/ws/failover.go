@@ -0,0 +1,176 @@
+package ws
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"usdc-event-tracker/internal/config"
+	"usdc-event-tracker/internal/logging"
+)
+
+// Endpoints is an ordered list of RPC endpoints of the same network, one of
+// which is active. Requests go to the active endpoint; when it fails, the next
+// endpoint in the list becomes active, wrapping around, and stays active until it
+// fails in turn. It is safe for concurrent use.
+type Endpoints struct {
+	urls    []string
+	timeout time.Duration
+	logger  *logging.Logger
+
+	active    atomic.Int32
+	failovers atomic.Int64
+}
+
+// NewEndpoints creates the endpoint list, starting with the first URL active.
+// With several endpoints, each HTTP request attempt is bounded by timeout so a
+// hanging endpoint fails over instead of stalling; zero disables the bound.
+func NewEndpoints(urls []string, timeout time.Duration) *Endpoints {
+	return &Endpoints{
+		urls:    urls,
+		timeout: timeout,
+		logger:  logging.GetLogger("ws"),
+	}
+}
+
+// Active returns the URL of the active endpoint
+func (e *Endpoints) Active() string {
+	return e.urls[e.active.Load()]
+}
+
+// Stats returns the active endpoint, redacted for logging, and the failover count
+func (e *Endpoints) Stats() map[string]interface{} {
+	return map[string]interface{}{
+		"active_endpoint": config.RedactURL(e.Active()),
+		"endpoint_index":  int(e.active.Load()),
+		"endpoints":       len(e.urls),
+		"failovers":       e.failovers.Load(),
+	}
+}
+
+// failover makes the endpoint after index active, unless another request already
+// moved on from index
+func (e *Endpoints) failover(index int, reason string) {
+	next := (index + 1) % len(e.urls)
+	if !e.active.CompareAndSwap(int32(index), int32(next)) {
+		return
+	}
+	e.failovers.Add(1)
+	e.logger.Warn("RPC endpoint failed, failing over", map[string]interface{}{
+		"failed_endpoint": config.RedactURL(e.urls[index]),
+		"active_endpoint": config.RedactURL(e.urls[next]),
+		"reason":          reason,
+	})
+}
+
+// failoverTransport sends each JSON-RPC request to the active endpoint and
+// retries it on the following endpoints if the attempt fails with a transport
+// error, a timeout or a 5xx status. JSON-RPC errors come with a 200 status and
+// are returned as-is, since they concern the request rather than the endpoint.
+type failoverTransport struct {
+	base      http.RoundTripper
+	endpoints *Endpoints
+	targets   []*url.URL
+}
+
+// newFailoverTransport wraps base so requests go to endpoints
+func newFailoverTransport(base http.RoundTripper, endpoints *Endpoints) (*failoverTransport, error) {
+	targets := make([]*url.URL, 0, len(endpoints.urls))
+	for _, rawURL := range endpoints.urls {
+		target, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RPC endpoint %s: %w", config.RedactURL(rawURL), err)
+		}
+		targets = append(targets, target)
+	}
+	return &failoverTransport{base: base, endpoints: endpoints, targets: targets}, nil
+}
+
+// RoundTrip sends the request to the active endpoint, failing over at most once
+// per endpoint
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// The body is replayed for every endpoint tried
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		resp *http.Response
+		err  error
+	)
+	start := int(t.endpoints.active.Load())
+	for i := range t.targets {
+		index := (start + i) % len(t.targets)
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		resp, err = t.attempt(req, index, body)
+		if err == nil && resp.StatusCode < http.StatusInternalServerError {
+			return resp, nil
+		}
+		if req.Context().Err() != nil {
+			// The caller gave up; that is not the endpoint's fault
+			return resp, err
+		}
+
+		var reason string
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+		}
+		if len(t.targets) > 1 {
+			t.endpoints.failover(index, reason)
+		}
+	}
+	return resp, err
+}
+
+// attempt sends the request to the endpoint at index, bounded by the endpoint timeout
+func (t *failoverTransport) attempt(req *http.Request, index int, body []byte) (*http.Response, error) {
+	ctx, cancel := req.Context(), context.CancelFunc(func() {})
+	if t.endpoints.timeout > 0 && len(t.targets) > 1 {
+		ctx, cancel = context.WithTimeout(ctx, t.endpoints.timeout)
+	}
+
+	target := *t.targets[index]
+	out := req.Clone(ctx)
+	out.URL = &target
+	out.Host = target.Host
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	out.ContentLength = int64(len(body))
+
+	resp, err := t.base.RoundTrip(out)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// Keep the attempt's context alive until the response has been read
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases an attempt's context once its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the body and cancels the attempt's context
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
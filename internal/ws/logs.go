@@ -0,0 +1,40 @@
+package ws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// USDCLogsQuery builds a filter matching logs emitted by any of the given contract
+// addresses whose first topic (the event signature) is one of topics.
+// An empty topics list matches every event.
+func USDCLogsQuery(addresses []string, topics []common.Hash) ethereum.FilterQuery {
+	query := ethereum.FilterQuery{
+		Addresses: make([]common.Address, 0, len(addresses)),
+	}
+	for _, address := range addresses {
+		query.Addresses = append(query.Addresses, common.HexToAddress(address))
+	}
+	if len(topics) > 0 {
+		query.Topics = [][]common.Hash{topics}
+	}
+	return query
+}
+
+// SubscribeUSDCLogs subscribes via eth_subscribe("logs") to logs matching
+// USDCLogsQuery(addresses, topics) and delivers them on the returned channel.
+// The client must be connected over WebSocket. Callers should watch sub.Err()
+// and call sub.Unsubscribe() when done.
+func SubscribeUSDCLogs(client ethereum.LogFilterer, ctx context.Context, addresses []string, topics []common.Hash) (<-chan types.Log, ethereum.Subscription, error) {
+	logs := make(chan types.Log, 256)
+
+	sub, err := client.SubscribeFilterLogs(ctx, USDCLogsQuery(addresses, topics), logs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to subscribe to USDC logs: %w", err)
+	}
+	return logs, sub, nil
+}
@@ -2,18 +2,43 @@ package main
 
 import (
 	"context"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"flag"
+	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	_ "time/tzdata" // ROTATION_TIMEZONE works without system zoneinfo, e.g. in scratch images
+
+	"github.com/ethereum/go-ethereum/common"
 
 	"usdc-event-tracker/internal/config"
 	"usdc-event-tracker/internal/logging"
+	"usdc-event-tracker/internal/sinks"
+	_ "usdc-event-tracker/internal/sinks/all" // Register built-in sinks
 	"usdc-event-tracker/internal/tracker"
+	"usdc-event-tracker/internal/tx"
+	"usdc-event-tracker/internal/verify"
+	"usdc-event-tracker/internal/version"
 	"usdc-event-tracker/internal/ws"
 )
 
 func main() {
+	showVersion := flag.Bool("version", false, "Print version information and exit")
+	verifyRange := flag.String("verify", "", "Report blocks in FROM-TO without persisted events in -verify-sink, then exit")
+	verifySink := flag.String("verify-sink", "sql", "Sink checked by -verify")
+	verifyRescan := flag.Bool("verify-rescan", false, "With -verify, fetch missing blocks from the chain and only report those with USDC activity")
+	txHash := flag.String("tx", "", "Write the USDC events of a single transaction hash to the configured sinks, then exit")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println(version.String())
+		return
+	}
+
 	// Initialize structured logging
 	logging.Init("main")
 	logger := logging.GetLogger("main")
@@ -21,39 +46,118 @@ func main() {
 	// Load configuration
 	cfg := config.Load()
 
-	logger.Info("Starting USDC Event Tracker", map[string]interface{}{
-		"network":     cfg.Network,
-		"sinks":       cfg.Sink,
+	startFields := map[string]interface{}{
+		"network":      cfg.Network,
+		"track_mode":   cfg.TrackMode,
+		"sinks":        cfg.Sink,
 		"usdc_address": cfg.USDCAddress,
-	})
-
-	// Create Ethereum client
-	client, err := ws.NewClient(cfg.WebhookURL)
-	if err != nil {
-		logger.Error("Failed to create Ethereum client", err)
-		os.Exit(1)
+		"usdc_variant": cfg.USDCVariant,
+		"webhook_url":  config.RedactURL(cfg.WebhookURL),
+		"dry_run":      cfg.DryRun,
 	}
-	defer client.Close()
+	if len(cfg.RPCURLs) > 1 {
+		startFields["rpc_endpoints"] = len(cfg.RPCURLs)
+	}
+	// Correlate behavior with a specific build
+	for key, value := range version.Fields() {
+		startFields[key] = value
+	}
+	logger.Info("Starting USDC Event Tracker", startFields)
 
-	// Create and start tracker
-	t := tracker.New(client, cfg)
+	// Throttle RPC calls if a rate limit is configured
+	limiter := tx.NewRateLimiter(cfg.RPCRateLimit)
+
+	if *verifyRange != "" {
+		os.Exit(runVerify(cfg, limiter, *verifyRange, *verifySink, *verifyRescan))
+	}
 
 	// Setup graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Handle interrupt signals
+	// Handle interrupt signals in two phases: the first lets the blocks in flight
+	// finish and be flushed, a second one stops immediately
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	stopRequested := make(chan struct{})
 
 	go func() {
 		<-sigChan
-		logger.Info("Shutdown signal received, stopping gracefully")
+		logger.Info("Shutdown signal received, finishing in-flight blocks; send it again to stop immediately")
+		close(stopRequested)
+		<-sigChan
+		logger.Info("Second shutdown signal received, stopping immediately")
 		cancel()
 	}()
 
+	// Nothing is in flight while connecting, so the first signal stops right away
+	connectCtx, stopConnecting := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-stopRequested:
+			stopConnecting()
+		case <-connectCtx.Done():
+		}
+	}()
+
+	// Create Ethereum client, waiting for the node if it is not up yet
+	endpoints := ws.NewEndpoints(cfg.RPCURLs, cfg.RPCFailoverTimeout)
+	client, err := ws.Connect(connectCtx, endpoints, limiter, ws.NewTransport(cfg.RPCMaxConns), cfg.RPCConnectRetries, cfg.RPCConnectBackoff)
+	stopConnecting()
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			logger.Info("Tracker stopped before connecting")
+			return
+		}
+		logger.Error("Failed to create Ethereum client", err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	// Create and start tracker
+	t := tracker.New(client, cfg, limiter)
+
+	if *txHash != "" {
+		code := runTx(ctx, t, *txHash)
+		client.Close()
+		os.Exit(code)
+	}
+
+	go func() {
+		select {
+		case <-stopRequested:
+			t.Drain()
+		case <-ctx.Done():
+		}
+	}()
+
+	// Dump stats on SIGUSR1 without stopping, for hosts without a metrics port
+	statsChan := make(chan os.Signal, 1)
+	signal.Notify(statsChan, syscall.SIGUSR1)
+	defer signal.Stop(statsChan)
+
+	go func() {
+		for {
+			select {
+			case <-statsChan:
+				logger.Info("Stats dump requested")
+				t.DumpStats()
+				if len(cfg.RPCURLs) > 1 {
+					logger.Info("RPC endpoint status", endpoints.Stats())
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
 	// Start tracking
 	if err := t.Start(ctx); err != nil {
+		if errors.Is(err, sinks.ErrCloseTimeout) {
+			// Sinks may still hold goroutines or connections, exit without waiting on them
+			logger.Error("Shutdown timed out, forcing exit", err)
+			os.Exit(1)
+		}
 		if !errors.Is(err, context.Canceled) {
 			logger.Error("Tracker error", err)
 			os.Exit(1)
@@ -62,3 +166,95 @@ func main() {
 
 	logger.Info("Tracker stopped successfully")
 }
+
+// runTx writes the events of a single transaction to the configured sinks and
+// returns the process exit status: 0 on success, even if the transaction has no
+// USDC events, 1 on errors.
+func runTx(ctx context.Context, t *tracker.Tracker, txHash string) int {
+	logger := logging.GetLogger("main")
+
+	if len(txHash) != 66 || !strings.HasPrefix(txHash, "0x") {
+		logger.Error("Invalid -tx hash", fmt.Errorf("%q is not a 0x-prefixed 32-byte hash", txHash))
+		return 1
+	}
+	if _, err := hex.DecodeString(txHash[2:]); err != nil {
+		logger.Error("Invalid -tx hash", fmt.Errorf("%q is not a 0x-prefixed 32-byte hash", txHash))
+		return 1
+	}
+
+	if _, err := t.ProcessTxHash(ctx, common.HexToHash(txHash)); err != nil {
+		logger.Error("Transaction lookup failed", err, map[string]interface{}{"tx_hash": txHash})
+		return 1
+	}
+	return 0
+}
+
+// runVerify prints a verify.Report for the blocks of blockRange persisted by
+// sinkName and returns the process exit status: 0 if nothing needs to be
+// re-backfilled, 2 if blocks do, 1 on errors.
+func runVerify(cfg *config.Config, limiter *tx.RateLimiter, blockRange, sinkName string, rescan bool) int {
+	logger := logging.GetLogger("verify")
+
+	from, to, err := verify.ParseRange(blockRange)
+	if err != nil {
+		logger.Error("Invalid -verify range", err)
+		return 1
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	sink, err := sinks.Create(sinkName, sinks.Options{USDCAddress: cfg.USDCAddress})
+	if err != nil {
+		logger.Error("Failed to create sink", err, map[string]interface{}{"sink": sinkName})
+		return 1
+	}
+	lister, ok := sink.(sinks.BlockLister)
+	if !ok {
+		logger.Error("Sink cannot list persisted blocks", fmt.Errorf("%s does not support verification", sinkName))
+		return 1
+	}
+	if err := sink.Initialize(ctx); err != nil {
+		logger.Error("Failed to initialize sink", err, map[string]interface{}{"sink": sinkName})
+		return 1
+	}
+	defer sink.Close()
+
+	report, err := verify.Blocks(ctx, sinkName, lister, from, to)
+	if err != nil {
+		logger.Error("Verification failed", err)
+		return 1
+	}
+
+	if rescan && len(report.Gaps) > 0 {
+		endpoints := ws.NewEndpoints(cfg.RPCURLs, cfg.RPCFailoverTimeout)
+		client, err := ws.Connect(ctx, endpoints, limiter, ws.NewTransport(cfg.RPCMaxConns), cfg.RPCConnectRetries, cfg.RPCConnectBackoff)
+		if err != nil {
+			logger.Error("Failed to create Ethereum client", err)
+			return 1
+		}
+		defer client.Close()
+
+		contracts := make([]string, 0, len(cfg.USDCContracts))
+		for _, contract := range cfg.USDCContracts {
+			contracts = append(contracts, contract.Address)
+		}
+		hasActivity := verify.ChainActivity(tx.NewReceiptFetcher(client, limiter, nil), limiter, contracts)
+		if err := report.Rescan(ctx, hasActivity); err != nil {
+			logger.Error("Rescan failed", err)
+			return 1
+		}
+	}
+
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		logger.Error("Failed to encode report", err)
+		return 1
+	}
+	fmt.Println(string(out))
+
+	if report.NeedsBackfill() {
+		return 2
+	}
+	return 0
+}